@@ -0,0 +1,124 @@
+package latch
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// auditDepth is the most caller frames an AuditEntry
+// keeps.
+const auditDepth = 8
+
+// WithAudit makes a latch record its last n transitions,
+// with who made them, for Audit: so "who closed the
+// shutdown latch?" has an answer in production. Each
+// entry costs a runtime.Callers, and a goroutine id
+// lookup, under the latch's lock; cheap next to a
+// shutdown, but not free on a hot path.
+func WithAudit(n int) Option {
+	return func(r *Latch) {
+		r.audit = &auditLog{ring: make([]AuditEntry, n)}
+	}
+}
+
+// AuditEntry is one transition in a latch's Audit log.
+type AuditEntry struct {
+	Time      time.Time
+	Op        string // "close", "set" (closed to closed), or "open".
+	Version   uint64
+	Goroutine uint64
+	Err       error    // the Packet's Err, when closed.
+	Stack     []string // "function file:line", innermost first.
+}
+
+func (e AuditEntry) String() string {
+	s := fmt.Sprintf("%s v%d %s by goroutine %d", e.Time.Format(time.RFC3339Nano), e.Version, e.Op, e.Goroutine)
+	if e.Err != nil {
+		s += fmt.Sprintf(" (err: %v)", e.Err)
+	}
+	if len(e.Stack) > 0 {
+		s += "\n\t" + strings.Join(e.Stack, "\n\t")
+	}
+	return s
+}
+
+type auditLog struct {
+	ring   []AuditEntry
+	next   uint64
+	closed bool
+}
+
+// record logs the transition r just made.
+// Caller must hold r.mut.
+func (a *auditLog) record(r *Latch) {
+	op := "open"
+	switch {
+	case r.avail && a.closed:
+		op = "set"
+	case r.avail:
+		op = "close"
+	}
+	a.closed = r.avail
+	if len(a.ring) == 0 {
+		return
+	}
+	e := AuditEntry{
+		Time:      r.clock().Now(),
+		Op:        op,
+		Version:   r.version,
+		Goroutine: goid(),
+		Stack:     callersOutside(auditDepth),
+	}
+	if r.avail && r.cur != nil {
+		e.Err = r.cur.Err
+	}
+	a.ring[a.next%uint64(len(a.ring))] = e
+	a.next++
+}
+
+// Audit returns the transitions recorded by WithAudit,
+// oldest first; nil without WithAudit.
+func (r *Latch) Audit() []AuditEntry {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	a := r.audit
+	if a == nil {
+		return nil
+	}
+	n := uint64(len(a.ring))
+	start := uint64(0)
+	if a.next > n {
+		start = a.next - n
+	}
+	out := make([]AuditEntry, 0, a.next-start)
+	for i := start; i < a.next; i++ {
+		out = append(out, a.ring[i%n])
+	}
+	return out
+}
+
+// callersOutside returns up to max frames of the stack,
+// starting with the nearest caller outside latch's own
+// (non-test) code.
+func callersOutside(max int) []string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var out []string
+	for {
+		f, more := frames.Next()
+		if len(out) > 0 || filepath.Dir(f.File) != pkgDir ||
+			strings.HasSuffix(f.File, "_test.go") {
+			out = append(out, fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line))
+			if len(out) == max {
+				return out
+			}
+		}
+		if !more {
+			return out
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package latch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func closeForAudit(l *Latch) {
+	l.Bcast(&Packet{Err: errors.New("fatal")})
+}
+
+func TestAudit(t *testing.T) {
+
+	if NewLatch(1).Audit() != nil {
+		t.Fatal("no audit log without WithAudit")
+	}
+	l := NewLatch(1, WithAudit(2))
+	closeForAudit(l)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Bcast(&Packet{})
+	}()
+	<-done
+	l.Clear()
+
+	log := l.Audit()
+	if len(log) != 2 {
+		t.Fatalf("expected the last 2 transitions, got %d", len(log))
+	}
+	if log[0].Op != "set" || log[1].Op != "open" || log[1].Version != 3 {
+		t.Fatalf("got %v", log)
+	}
+	if log[0].Goroutine == log[1].Goroutine || log[1].Goroutine == 0 {
+		t.Fatal("entries should carry their goroutine ids")
+	}
+	if !strings.Contains(log[1].Stack[0], "TestAudit") {
+		t.Fatalf("the stack should start at the caller: %v", log[1].Stack)
+	}
+
+	l = NewLatch(1, WithAudit(4))
+	closeForAudit(l)
+	e := l.Audit()[0]
+	if e.Op != "close" || e.Err == nil || !strings.Contains(e.Stack[0], "closeForAudit") {
+		t.Fatalf("got %v", e)
+	}
+}
+
+func TestAuditBcastNil(t *testing.T) {
+
+	l := NewLatch(1, WithAudit(4))
+	l.Bcast(nil)
+	l.Clear()
+	a := l.Audit()
+	if len(a) != 2 || a[0].Op != "close" || a[0].Err != nil || a[1].Op != "open" {
+		t.Fatalf("Audit() = %v", a)
+	}
+}
@@ -0,0 +1,256 @@
+package latch
+
+import (
+	"sync"
+	"time"
+)
+
+// Bcast broadcasts the latest T to any number of
+// receivers, which must call Reload after each receive:
+//
+//	for {
+//		v, ok := <-b.Ch()
+//		b.Reload()
+//		if !ok {
+//			continue // Ch has moved; see below.
+//		}
+//		use(v)
+//	}
+//
+// There is no need to guess the number of receivers
+// up front: when a Reload finds the channel already
+// empty, receivers are consuming faster than one fill
+// covers, so the channel capacity is doubled (up to
+// maxBcastCap). The old channel is then closed, so
+// receivers should call Ch() afresh each time around
+// their receive loop, and skip a receive that finds it
+// closed.
+type Bcast[T any] struct {
+	ch  chan T
+	mu  sync.Mutex
+	on  bool
+	cur T
+
+	// debouncing state, see SetDebounced.
+	debounceSeq   uint64
+	debounceTimer Timer
+
+	// throttling state, see SetMaxRate.
+	minGap        time.Duration
+	lastApply     time.Time
+	throttled     T
+	throttleTimer Timer
+
+	// clk, if set by SetClock, times the above.
+	clk Clock
+
+	// reloads counts Reload calls. See Stats.
+	reloads uint64
+}
+
+// initial and maximum Bcast channel capacities.
+const (
+	minBcastCap = 2
+	maxBcastCap = 1 << 16
+)
+
+// NewBcast makes a Bcast, off until On or Set.
+func NewBcast[T any]() *Bcast[T] {
+	return &Bcast[T]{
+		ch: make(chan T, minBcastCap),
+	}
+}
+
+// Ch returns the channel to receive on. It may be
+// replaced by a larger one as the receiver count is
+// discovered, closing this one, so call Ch() on each
+// receive.
+func (b *Bcast[T]) Ch() <-chan T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.ch
+}
+
+// Stats reports the number of Reload calls so far, and
+// the current channel capacity, which tracks the
+// measured receiver concurrency.
+func (b *Bcast[T]) Stats() (reloads uint64, capacity int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.reloads, cap(b.ch)
+}
+
+// On broadcasts the current value again, after Off.
+func (b *Bcast[T]) On() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.on = true
+	b.fill()
+}
+
+// Set broadcasts val, turning b on.
+func (b *Bcast[T]) Set(val T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.set(val)
+}
+
+// SetMaxRate limits how often subscribers are
+// refreshed with a new value to at most perSec times
+// per second. Set may still be called as often as
+// you like; values arriving too quickly are held back
+// and only the latest is broadcast once the rate
+// allows, so subscribers always converge to the
+// latest value. A perSec <= 0 removes the limit.
+func (b *Bcast[T]) SetMaxRate(perSec int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if perSec <= 0 {
+		b.minGap = 0
+		return
+	}
+	b.minGap = time.Second / time.Duration(perSec)
+}
+
+// SetClock makes SetMaxRate and SetDebounced use c,
+// as WithClock does for a Latch.
+func (b *Bcast[T]) SetClock(c Clock) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clk = c
+}
+
+// set does the work of Set, subject to
+// any SetMaxRate limit. Caller must hold b.mu.
+func (b *Bcast[T]) set(val T) {
+	if b.minGap > 0 {
+		wait := b.minGap - orReal(b.clk).Now().Sub(b.lastApply)
+		if wait > 0 {
+			b.throttled = val
+			if b.throttleTimer == nil {
+				b.throttleTimer = orReal(b.clk).AfterFunc(wait, func() {
+					b.mu.Lock()
+					defer b.mu.Unlock()
+					if b.throttleTimer == nil {
+						// cancelled by Off.
+						return
+					}
+					b.throttleTimer = nil
+					b.apply(b.throttled)
+				})
+			}
+			return
+		}
+	}
+	b.apply(val)
+}
+
+// apply broadcasts val now. Caller must hold b.mu.
+func (b *Bcast[T]) apply(val T) {
+	b.on = true
+	b.cur = val
+	b.drain()
+	b.fill()
+	b.lastApply = orReal(b.clk).Now()
+}
+
+// SetDebounced coalesces rapid successive calls:
+// val is only broadcast (as if by Set) once window
+// has passed without another SetDebounced call.
+// Only the final value of a burst is broadcast, so
+// consumers aren't thrashed by e.g. a config file
+// being rewritten several times in quick succession.
+func (b *Bcast[T]) SetDebounced(val T, window time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.debounceSeq++
+	seq := b.debounceSeq
+	if b.debounceTimer != nil {
+		b.debounceTimer.Stop()
+	}
+	b.debounceTimer = orReal(b.clk).AfterFunc(window, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if seq != b.debounceSeq {
+			// superseded by a later SetDebounced.
+			return
+		}
+		b.debounceTimer = nil
+		b.set(val)
+	})
+}
+
+// Off stops broadcasting, emptying Ch, until the
+// next On or Set.
+func (b *Bcast[T]) Off() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.on = false
+	// a held-back, or debounced, Set must
+	// not turn us back on.
+	if b.throttleTimer != nil {
+		b.throttleTimer.Stop()
+		b.throttleTimer = nil
+	}
+	b.debounceSeq++
+	if b.debounceTimer != nil {
+		b.debounceTimer.Stop()
+		b.debounceTimer = nil
+	}
+	b.drain()
+}
+
+// drain all messages, leaving Ch empty.
+func (b *Bcast[T]) drain() {
+	// empty chan
+	for {
+		select {
+		case <-b.ch:
+		default:
+			return
+		}
+	}
+}
+
+// all clients should call Reload after receiving
+// on the channel. This makes such channels
+// self-servicing.
+func (b *Bcast[T]) Reload() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reloads++
+	if !b.on {
+		return
+	}
+	if len(b.ch) == 0 && cap(b.ch) < maxBcastCap {
+		// receivers drained every copy before this
+		// Reload; there are more of them than we
+		// thought. Grow, so one fill covers them.
+		b.grow()
+	}
+	b.fill()
+}
+
+// grow doubles the channel capacity. Receivers
+// pick up the new channel on their next Ch().
+// Caller must hold b.mu.
+func (b *Bcast[T]) grow() {
+	old := b.ch
+	b.ch = make(chan T, 2*cap(old))
+	// wake any receivers already waiting on the old,
+	// empty, channel, without handing them a value
+	// that a later Set would make stale; they'll
+	// Reload and move over.
+	close(old)
+}
+
+// fill up the channel
+func (b *Bcast[T]) fill() {
+	for {
+		select {
+		case b.ch <- b.cur:
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package latch
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestBcastGrows(t *testing.T) {
+
+	b := NewBcast[int]()
+	b.Set(4)
+	old := b.Ch()
+	for i := 0; i < minBcastCap; i++ {
+		if v := <-old; v != 4 {
+			t.Fatalf("got %v", v)
+		}
+	}
+	// every copy taken before this Reload: grow.
+	b.Reload()
+	if _, capacity := b.Stats(); capacity != 2*minBcastCap {
+		t.Fatalf("capacity %v, expected it to double", capacity)
+	}
+	if _, ok := <-old; ok {
+		t.Fatal("the outgrown channel should be closed, not refilled")
+	}
+	b.Set(5)
+	if v := <-b.Ch(); v != 5 {
+		t.Fatalf("got %v from the new channel", v)
+	}
+	if reloads, _ := b.Stats(); reloads != 1 {
+		t.Fatalf("reloads = %v", reloads)
+	}
+}
+
+func TestBcastOff(t *testing.T) {
+
+	b := NewBcast[string]()
+	b.Set("a")
+	b.Off()
+	select {
+	case v := <-b.Ch():
+		t.Fatalf("Off should empty Ch, got %v", v)
+	default:
+	}
+	b.Reload()
+	if len(b.Ch()) != 0 {
+		t.Fatal("Reload should not refill while off")
+	}
+	b.On()
+	if v := <-b.Ch(); v != "a" {
+		t.Fatalf("On should resume with the current value, got %v", v)
+	}
+}
+
+func TestBcastDebounced(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		b := NewBcast[int]()
+		b.SetDebounced(1, time.Second)
+		time.Sleep(500 * time.Millisecond)
+		b.SetDebounced(2, time.Second)
+		time.Sleep(999 * time.Millisecond)
+		if len(b.Ch()) != 0 {
+			t.Fatal("nothing should go out within the window")
+		}
+		time.Sleep(time.Millisecond)
+		synctest.Wait()
+		if v := <-b.Ch(); v != 2 {
+			t.Fatalf("only the last of the burst should go out, got %v", v)
+		}
+
+		// Off cancels one still pending.
+		b.SetDebounced(3, time.Second)
+		b.Off()
+		time.Sleep(2 * time.Second)
+		synctest.Wait()
+		if len(b.Ch()) != 0 {
+			t.Fatal("a debounced Set should not turn us back on after Off")
+		}
+	})
+}
+
+func TestBcastMaxRate(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		b := NewBcast[int]()
+		b.SetMaxRate(10)
+		b.Set(1)
+		b.Set(2)
+		b.Set(3)
+		if v := <-b.Ch(); v != 1 {
+			t.Fatalf("got %v; the rest should be held back", v)
+		}
+		time.Sleep(100 * time.Millisecond)
+		synctest.Wait()
+		b.Reload()
+		if v := <-b.Ch(); v != 3 {
+			t.Fatalf("the latest held-back value should go out, got %v", v)
+		}
+	})
+}
+
+func TestBcastClock(t *testing.T) {
+
+	w := newTimerWheel(time.Millisecond)
+	b := NewBcast[int]()
+	b.SetClock(w)
+	b.SetDebounced(1, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if len(b.Ch()) != 0 {
+		t.Fatal("the debounce window should pass on the Clock, not in real time")
+	}
+	advanceBy(w, 10*time.Millisecond)
+	waitFor(t, func() bool { return len(b.Ch()) > 0 })
+}
@@ -0,0 +1,146 @@
+package latch
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a Breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed: the circuit is complete, calls flow.
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen: tripped; calls are refused until
+	// the cool-down expires.
+	BreakerOpen
+
+	// BreakerHalfOpen: cooled down; a single trial call
+	// is let through to decide between Closed and Open.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Breaker is a circuit breaker. After threshold
+// consecutive failures it trips Open, refusing calls
+// for the cool-down period, then goes Half-Open to let
+// one trial call decide whether to close again.
+//
+// Every state change is broadcast on the Breaker's
+// latch (see Latch()), as a Packet whose Item is the
+// new BreakerState; so any number of goroutines can
+// cheaply observe trips and resets.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     BreakerState
+	trial     bool // a half-open trial call is in flight.
+	timer     Timer
+	l         *Latch
+}
+
+// NewBreaker makes a new, Closed, Breaker that trips
+// after threshold consecutive failures and cools down
+// for cooldown. Its latch has a backing channel of size sz,
+// and opts, as for NewLatch.
+func NewBreaker(sz int, threshold int, cooldown time.Duration, opts ...Option) *Breaker {
+	b := &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		l:         NewLatch(sz, opts...),
+	}
+	b.l.Bcast(&Packet{Item: BreakerClosed})
+	return b
+}
+
+// Latch returns the latch on which state
+// changes are broadcast.
+func (b *Breaker) Latch() *Latch {
+	return b.l
+}
+
+// State returns the current state.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call may proceed now. Callers
+// that are allowed must report the outcome with
+// Success or Failure.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerHalfOpen:
+		if !b.trial {
+			b.trial = true
+			return true
+		}
+	}
+	return false
+}
+
+// Success records a successful call.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	if b.state == BreakerHalfOpen {
+		b.trial = false
+		b.set(BreakerClosed)
+	}
+}
+
+// Failure records a failed call.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	switch b.state {
+	case BreakerClosed:
+		if b.failures >= b.threshold {
+			b.trip()
+		}
+	case BreakerHalfOpen:
+		b.trial = false
+		b.trip()
+	}
+}
+
+// trip opens the breaker and starts the cool-down.
+// Caller must hold b.mu.
+func (b *Breaker) trip() {
+	b.set(BreakerOpen)
+	b.timer = b.l.clock().AfterFunc(b.cooldown, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.state == BreakerOpen {
+			b.set(BreakerHalfOpen)
+		}
+	})
+}
+
+// set changes state and broadcasts it.
+// Caller must hold b.mu.
+func (b *Breaker) set(s BreakerState) {
+	b.state = s
+	b.l.Bcast(&Packet{Item: s})
+}
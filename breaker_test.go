@@ -0,0 +1,36 @@
+package latch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+
+	b := NewBreaker(1, 2, 10*time.Millisecond)
+	watch := b.Latch()
+
+	b.Failure()
+	if b.State() != BreakerClosed {
+		t.Fatal("one failure should not trip a threshold of 2")
+	}
+	b.Failure()
+	if b.State() != BreakerOpen || b.Allow() {
+		t.Fatal("two failures should trip the breaker open")
+	}
+	if pak := <-watch.Ch(); pak.Item != BreakerOpen {
+		t.Fatalf("observers should see the trip, got %v", pak.Item)
+	}
+
+	waitFor(t, func() bool { return b.State() == BreakerHalfOpen })
+	if !b.Allow() {
+		t.Fatal("half-open should allow one trial call")
+	}
+	if b.Allow() {
+		t.Fatal("half-open should allow only one trial call")
+	}
+	b.Success()
+	if b.State() != BreakerClosed {
+		t.Fatal("a successful trial should close the breaker")
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Package chaos makes latches misbehave, within the bounds
+of what they promise, so that code consuming latches can
+be tested for robustness. With chaos, Refresh may be slow,
+or may not refill at all for a while (a starvation
+window); and watchers using Observe, and everything built
+on it, are woken late, in shuffled order.
+
+	l := latch.NewLatch(4, chaos.Option(chaos.Config{
+		Seed:           1,
+		MaxRefillDelay: 50 * time.Millisecond,
+		MaxNotifyDelay: 10 * time.Millisecond,
+		StarveProb:     0.1,
+		StarveWindow:   time.Second,
+	}))
+
+Chaos is for tests only.
+*/
+package chaos
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/glycerine/latch"
+)
+
+// Config says how badly to behave. Zero
+// values disable the corresponding fault.
+type Config struct {
+	// Seed seeds the randomness, for reproducible runs.
+	Seed int64
+
+	// Refresh waits up to MaxRefillDelay before refilling.
+	MaxRefillDelay time.Duration
+
+	// Watchers are woken up to MaxNotifyDelay late.
+	MaxNotifyDelay time.Duration
+
+	// Each Refresh, with probability StarveProb, begins
+	// a starvation window of StarveWindow, in which
+	// Refreshes do not refill.
+	StarveProb   float64
+	StarveWindow time.Duration
+}
+
+// Injector is a latch.Faults driven by a Config.
+type Injector struct {
+	cfg Config
+
+	mu          sync.Mutex
+	rng         *rand.Rand
+	starveUntil time.Time
+}
+
+var _ latch.Faults = (*Injector)(nil)
+
+// New returns an Injector for cfg.
+func New(cfg Config) *Injector {
+	return &Injector{cfg: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+// Option returns a latch.Option applying a new
+// Injector for cfg.
+func Option(cfg Config) latch.Option {
+	return latch.WithFaults(New(cfg))
+}
+
+// upTo returns a random duration in [0, max].
+// Caller must hold i.mu.
+func (i *Injector) upTo(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(i.rng.Int63n(int64(max) + 1))
+}
+
+// RefillDelay implements latch.Faults.
+func (i *Injector) RefillDelay() time.Duration {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.upTo(i.cfg.MaxRefillDelay)
+}
+
+// NotifyDelay implements latch.Faults.
+func (i *Injector) NotifyDelay() time.Duration {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.upTo(i.cfg.MaxNotifyDelay)
+}
+
+// Starve implements latch.Faults.
+func (i *Injector) Starve() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	now := time.Now()
+	if now.Before(i.starveUntil) {
+		return true
+	}
+	if i.cfg.StarveProb > 0 && i.rng.Float64() < i.cfg.StarveProb {
+		i.starveUntil = now.Add(i.cfg.StarveWindow)
+		return true
+	}
+	return false
+}
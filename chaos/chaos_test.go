@@ -0,0 +1,62 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glycerine/latch"
+)
+
+func TestStarvation(t *testing.T) {
+
+	l := latch.NewLatch(1, Option(Config{
+		StarveProb:   1,
+		StarveWindow: 50 * time.Millisecond,
+	}))
+	l.Bcast(&latch.Packet{Item: 1})
+	<-l.Ch()
+
+	l.Refresh()
+	if len(l.Ch()) != 0 {
+		t.Fatal("Refresh should be starved")
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	// a new window starts straight away, with StarveProb 1.
+	l.Refresh()
+	if len(l.Ch()) != 0 {
+		t.Fatal("Refresh should be starved again")
+	}
+}
+
+func TestNotifyDelay(t *testing.T) {
+
+	l := latch.NewLatch(1, Option(Config{
+		Seed:           7,
+		MaxRefillDelay: 5 * time.Millisecond,
+		MaxNotifyDelay: 20 * time.Millisecond,
+	}))
+	const watchers = 8
+	woke := make(chan int, watchers)
+	for i := 0; i < watchers; i++ {
+		_, _, next := l.Observe()
+		go func(i int) {
+			<-next
+			woke <- i
+		}(i)
+	}
+	start := time.Now()
+	l.Bcast(&latch.Packet{Item: 1})
+	for i := 0; i < watchers; i++ {
+		<-woke
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("watchers should all be woken, within the bound")
+	}
+
+	<-l.Ch()
+	l.Refresh()
+	if (<-l.Ch()).Item != 1 {
+		t.Fatal("a delayed Refresh should still refill")
+	}
+}
@@ -0,0 +1,36 @@
+package latch
+
+import "sync/atomic"
+
+// WithRefresherCleanup keeps a BackgroundRefresher from
+// outliving its latch. Normally the refresher goroutine
+// holds its latch, so a latch dropped without Stop is
+// never collected, and its refresher runs forever. With
+// this option the refresher holds the latch only weakly;
+// once the latch is garbage, the refresher exits.
+func WithRefresherCleanup() Option {
+	return func(r *Latch) {
+		r.gcRefresher = true
+	}
+}
+
+// activeRefreshers counts running refresher goroutines.
+var activeRefreshers atomic.Int64
+
+// ActiveRefreshers returns the number of refresher
+// goroutines running: from BackgroundRefresher (of
+// latches and LatchArrays) and RefresherPools. Leak
+// tests can check that it returns to where it started.
+func ActiveRefreshers() int {
+	return int(activeRefreshers.Load())
+}
+
+// closeStop is the cleanup for a collected latch's
+// refresher. Nobody else can now Stop it.
+func closeStop(stop chan struct{}) {
+	select {
+	case <-stop:
+	default:
+		close(stop)
+	}
+}
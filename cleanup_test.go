@@ -0,0 +1,30 @@
+package latch
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestRefresherCleanup(t *testing.T) {
+
+	before := ActiveRefreshers()
+
+	l := NewLatch(1)
+	l.BackgroundRefresher()
+	if ActiveRefreshers() != before+1 {
+		t.Fatal("expected one more refresher")
+	}
+	l.Stop()
+	waitFor(t, func() bool { return ActiveRefreshers() == before })
+
+	// dropped without Stop.
+	func() {
+		l := NewLatch(1, WithRefresherCleanup())
+		l.Bcast(&Packet{})
+		l.BackgroundRefresher()
+	}()
+	waitFor(t, func() bool {
+		runtime.GC()
+		return ActiveRefreshers() == before
+	})
+}
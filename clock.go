@@ -0,0 +1,90 @@
+package latch
+
+import "time"
+
+// Clock is the source of time for the latch features
+// that use timers, or tell the time: BackgroundRefresher,
+// CloseAfter and DeadlineLatch, CloseAt, Watchdog,
+// WithTTL, Lease, Sampler, Schedule, RefresherPool,
+// Breaker, FileWatcher, WithRefreshLimit, WithStats,
+// WithAudit and ReadWithProgress. WithClock substitutes
+// one, such as latchtest.Clock, so that tests can step
+// through time instead of sleeping; Bcast, Hub,
+// LatchArray, TaskGroup, Phases and Orchestrator take
+// theirs by SetClock.
+//
+// The default Clock is the time package, which
+// testing/synctest already fakes inside its bubbles; so
+// tests using synctest need no Clock of their own.
+// TimerWheel is a Clock for programs with very many
+// timed latches: give them all WithClock(w), and
+// NewRefresherPool the same w.
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the part of *time.Timer that latches use.
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the part of *time.Ticker that latches use.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// WithClock makes a latch, and the Watchdog, Breaker,
+// DeadlineLatch, Lease, Sampler, Schedule or FileWatcher
+// built on it, use c for all timing.
+func WithClock(c Clock) Option {
+	return func(r *Latch) {
+		r.clk = c
+	}
+}
+
+// clock returns the Clock r was given, or the real one.
+func (r *Latch) clock() Clock {
+	return orReal(r.clk)
+}
+
+// after returns a channel that c closes once d has
+// passed, and the Timer that will close it; Stop it
+// when done waiting.
+func after(c Clock, d time.Duration) (<-chan struct{}, Timer) {
+	ch := make(chan struct{})
+	return ch, orReal(c).AfterFunc(d, func() { close(ch) })
+}
+
+// orReal returns c, or the real Clock if c is nil.
+func orReal(c Clock) Clock {
+	if c == nil {
+		return realClock{}
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
@@ -0,0 +1,30 @@
+package latch
+
+// WithCloner gives every reader its own copy of the
+// broadcast Item, made by clone, so that no reader can
+// corrupt what the others see. Each Packet received from
+// Ch(), and each value returned by Peek, Observe, WaitFor,
+// and Read, holds a fresh clone(Item); Err is shared.
+//
+// Copies are made as the channel is filled, so a latch of
+// size sz clones sz times per Bcast, and again on each
+// Refresh top-up. clone must be safe to call concurrently.
+func WithCloner(clone func(interface{}) interface{}) Option {
+	return func(r *Latch) {
+		r.cloner = clone
+	}
+}
+
+// copyOf returns the Packet a reader should get for p:
+// p itself, or a clone of it if WithCloner was given.
+func (r *Latch) copyOf(p *Packet) *Packet {
+	return copyWith(r.cloner, p)
+}
+
+// copyWith is copyOf, given the cloner.
+func copyWith(clone func(interface{}) interface{}, p *Packet) *Packet {
+	if clone == nil || p == nil {
+		return p
+	}
+	return &Packet{Item: clone(p.Item), Err: p.Err, Priority: p.Priority}
+}
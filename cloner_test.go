@@ -0,0 +1,33 @@
+package latch
+
+import "testing"
+
+func TestCloner(t *testing.T) {
+
+	cloneMap := func(x interface{}) interface{} {
+		m := x.(map[string]int)
+		c := make(map[string]int, len(m))
+		for k, v := range m {
+			c[k] = v
+		}
+		return c
+	}
+
+	for _, sz := range []int{2, MaxBufferedSz + 1} {
+		l := NewLatch(sz, WithCloner(cloneMap), WithMutationCheck())
+		l.Bcast(&Packet{Item: map[string]int{"workers": 4}})
+
+		a := <-l.Ch()
+		b := <-l.Ch()
+		a.Item.(map[string]int)["workers"] = 99
+		if b.Item.(map[string]int)["workers"] != 4 {
+			t.Fatalf("sz %v: readers should not share the Item", sz)
+		}
+		// the latch's own copy is untouched, so this
+		// doesn't trip the mutation check either.
+		if l.Peek().Item.(map[string]int)["workers"] != 4 {
+			t.Fatalf("sz %v: the latch's value should be unchanged", sz)
+		}
+		l.Clear()
+	}
+}
@@ -0,0 +1,49 @@
+package latch
+
+import (
+	"sync"
+	"time"
+)
+
+// CloseAt schedules Bcast(pak) for the wall-clock time t,
+// as for a maintenance window: "enter read-only mode at
+// 02:00". A t already past closes the latch straight
+// away. Calling cancel before then calls it off.
+//
+// Timing uses the latch's Clock, as CloseAfter's does:
+// a runtime timer by default. The shared timer wheel is
+// opt-in: give the latches WithClock(w), with one
+// TimerWheel w, and it serves many scheduled closes
+// cheaply; a fake Clock makes them testable. If the
+// timer fires early, because the wall clock was set
+// back meanwhile, it waits out the rest.
+func (r *Latch) CloseAt(t time.Time, pak *Packet) (cancel func()) {
+	clk := r.clock()
+	var mu sync.Mutex
+	var timer Timer
+	cancelled := false
+
+	var arm func()
+	arm = func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if cancelled {
+			return
+		}
+		if d := t.Sub(clk.Now()); d > 0 {
+			timer = clk.AfterFunc(d, arm)
+			return
+		}
+		cancelled = true
+		r.Bcast(pak)
+	}
+	arm()
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		cancelled = true
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package latch
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestCloseAt(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		l := NewLatch(1)
+		at := time.Now().Add(time.Hour)
+		l.CloseAt(at, &Packet{Item: "read-only"})
+		cancel := l.CloseAt(at.Add(-time.Minute), &Packet{Item: "cancelled"})
+		cancel()
+
+		time.Sleep(time.Hour - time.Second)
+		synctest.Wait()
+		if l.IsClosed() {
+			t.Fatal("closed early")
+		}
+		time.Sleep(time.Second)
+		synctest.Wait()
+		if pak := l.Peek(); pak == nil || pak.Item != "read-only" {
+			t.Fatalf("got %v", pak)
+		}
+		if !time.Now().Equal(at) {
+			t.Fatal("closed at the wrong time")
+		}
+
+		l.CloseAt(at.Add(-time.Minute), &Packet{Item: "past"})
+		if l.Peek().Item != "past" {
+			t.Fatal("a past time should close at once")
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package latch
+
+import (
+	"context"
+	"time"
+)
+
+// CloseWithDeadline is Bcast(pak), further telling readers
+// that they have until t to finish up: "shut down, and be
+// done by t", in one transition. Readers learn t from
+// Deadline, or get it as a context deadline from Context.
+// The deadline lasts until the next transition.
+func (r *Latch) CloseWithDeadline(pak *Packet, t time.Time) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.bcast(pak) {
+		r.deadline = t
+	}
+}
+
+// Deadline returns the deadline given to
+// CloseWithDeadline, if that was the latch's
+// last transition.
+func (r *Latch) Deadline() (t time.Time, ok bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.deadline, !r.deadline.IsZero()
+}
+
+// Context returns a context derived from parent for the
+// work a reader does in response to the latch: if the
+// latch was closed by CloseWithDeadline, the context
+// carries that deadline; otherwise it is simply
+// cancellable. When the deadline passes, the context's
+// cause is the Err the latch closed with (or ErrClosed),
+// and CauseOf returns its Packet.
+//
+//	pak, err := l.Read(ctx)
+//	...
+//	wctx, cancel := l.Context(ctx)
+//	defer cancel()
+//	flush(wctx) // gives up at the shutdown deadline.
+func (r *Latch) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	r.mut.Lock()
+	t, pak := r.deadline, r.copyOf(r.cur)
+	r.mut.Unlock()
+	if t.IsZero() {
+		return context.WithCancel(parent)
+	}
+	cc := &closeCause{parent: causeIn(parent)}
+	err := cc.set(pak)
+	return context.WithDeadlineCause(context.WithValue(parent, causeKey{}, cc), t, err)
+}
@@ -0,0 +1,72 @@
+package latch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCloseWithDeadline(t *testing.T) {
+
+	l := NewLatch(1)
+	by := time.Now().Add(time.Hour)
+	l.CloseWithDeadline(&Packet{Item: "shutdown"}, by)
+
+	pak, err := l.Read(context.Background())
+	if err != nil || pak.Item != "shutdown" {
+		t.Fatalf("Read got %v, %v", pak, err)
+	}
+	ctx, cancel := l.Context(context.Background())
+	defer cancel()
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(by) {
+		t.Fatalf("the reader's context should carry the deadline, got %v, %v", d, ok)
+	}
+
+	l.Bcast(&Packet{Item: "again"})
+	if _, ok := l.Deadline(); ok {
+		t.Fatal("a plain Bcast should clear the deadline")
+	}
+	ctx, cancel = l.Context(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("without a deadline, the context should have none")
+	}
+}
+
+func TestContextCause(t *testing.T) {
+
+	l := NewLatch(1)
+	drain := errors.New("draining")
+	l.CloseWithDeadline(&Packet{Item: "bye", Err: drain}, time.Now().Add(10*time.Millisecond))
+
+	ctx, cancel := l.Context(context.Background())
+	defer cancel()
+	<-ctx.Done()
+	if context.Cause(ctx) != drain {
+		t.Fatalf("cause should be the packet's Err, got %v", context.Cause(ctx))
+	}
+	if pak := CauseOf(ctx); pak == nil || pak.Item != "bye" {
+		t.Fatalf("CauseOf got %v", pak)
+	}
+
+	// cancelled by the caller, not the deadline.
+	l.CloseWithDeadline(&Packet{}, time.Now().Add(time.Hour))
+	ctx, cancel = l.Context(context.Background())
+	cancel()
+	if CauseOf(ctx) != nil {
+		t.Fatal("the latch didn't end ctx")
+	}
+}
+
+func TestContextBcastNil(t *testing.T) {
+
+	l := NewLatch(1)
+	l.CloseWithDeadline(nil, time.Now().Add(10*time.Millisecond))
+	ctx, cancel := l.Context(context.Background())
+	defer cancel()
+	<-ctx.Done()
+	if context.Cause(ctx) != ErrClosed {
+		t.Fatalf("a nil packet's cause should be ErrClosed, got %v", context.Cause(ctx))
+	}
+}
@@ -0,0 +1,59 @@
+package latch
+
+import (
+	"context"
+	"sync"
+)
+
+// CloseDuring closes the latch with pak for a while:
+// until restore is called, or ctx ends, whichever is
+// first; then the latch goes back to its previous state,
+// open, or closed with its previous value. That is only
+// if pak is still the latch's value: if anything else
+// has changed the latch meanwhile, restore leaves it be.
+// Maintenance windows, and tests, are the usual cases.
+//
+// Going back is not a new write: it is not subject to
+// the write policy, veto hooks, WithWriteOnce or a
+// reducer, any of which could otherwise refuse or alter
+// the previous value.
+//
+// restore reports whether the previous state was put
+// back, by this call or by ctx ending first; false if
+// pak was refused (see TryBcast), or the latch changed
+// meanwhile. It may be called more than once.
+func (r *Latch) CloseDuring(ctx context.Context, pak *Packet) (restore func() bool) {
+	r.mut.Lock()
+	prev, wasClosed := r.cur, r.avail
+	if !r.bcast(pak) {
+		r.mut.Unlock()
+		return func() bool { return false }
+	}
+	gen := r.gen
+	r.mut.Unlock()
+
+	var once sync.Once
+	var restored bool
+	undo := func() {
+		once.Do(func() {
+			r.mut.Lock()
+			defer r.mut.Unlock()
+			if r.gen != gen {
+				return
+			}
+			if wasClosed {
+				r.install(prev)
+				r.publish()
+			} else {
+				r.open()
+			}
+			restored = true
+		})
+	}
+	stop := context.AfterFunc(ctx, undo)
+	return func() bool {
+		stop()
+		undo()
+		return restored
+	}
+}
@@ -0,0 +1,62 @@
+package latch
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCloseDuring(t *testing.T) {
+
+	l := NewLatch(1)
+	restore := l.CloseDuring(context.Background(), &Packet{Item: "maintenance"})
+	if pak := l.Peek(); pak == nil || pak.Item != "maintenance" {
+		t.Fatalf("got %v", pak)
+	}
+	if !restore() || !restore() {
+		t.Fatal("restore should report putting the open state back")
+	}
+	if l.IsClosed() {
+		t.Fatal("restore should reopen a latch that was open")
+	}
+
+	// ctx ending restores the previous value.
+	l.Bcast(&Packet{Item: "v1"})
+	ctx, cancel := context.WithCancel(context.Background())
+	l.CloseDuring(ctx, &Packet{Item: "maintenance"})
+	_, _, next := l.Observe()
+	cancel()
+	<-next
+	if pak := l.Peek(); pak == nil || pak.Item != "v1" {
+		t.Fatalf("expected v1 back, got %v", pak)
+	}
+
+	// a later write is not undone.
+	restore = l.CloseDuring(context.Background(), &Packet{Item: "maintenance"})
+	l.Bcast(&Packet{Item: "fatal"})
+	if restore() {
+		t.Fatal("restore should report it was pre-empted")
+	}
+	if pak := l.Peek(); pak.Item != "fatal" {
+		t.Fatalf("restore clobbered a later write: %v", pak)
+	}
+}
+
+func TestCloseDuringBypassesPolicy(t *testing.T) {
+
+	l := NewLatch(1, WithWritePolicy(HighestPriorityWins))
+	l.Bcast(&Packet{Item: "v1"})
+	restore := l.CloseDuring(context.Background(), &Packet{Item: "maintenance", Priority: 5})
+	if !restore() {
+		t.Fatal("restore refused by the write policy")
+	}
+	if pak := l.Peek(); pak == nil || pak.Item != "v1" {
+		t.Fatalf("expected v1 back, got %v", pak)
+	}
+
+	// a write-once latch can still be reopened.
+	l = NewLatch(1, WithWriteOnce())
+	restore = l.CloseDuring(context.Background(), &Packet{Item: "maintenance"})
+	if !restore() || l.IsClosed() {
+		t.Fatal("restore refused by WithWriteOnce")
+	}
+}
@@ -0,0 +1,42 @@
+package latch
+
+// ClosePriority is Bcast of pak at priority prio, which
+// can't overwrite a value of higher priority: a "fatal
+// error" close is not replaced by a "normal shutdown"
+// one. It sets Priority on a copy of pak, so pak itself
+// is untouched, and reports whether the close took. On
+// a latch WithWritePolicy, the policy must also accept
+// it.
+func (r *Latch) ClosePriority(pak *Packet, prio int) bool {
+	var p Packet
+	if pak != nil {
+		p = *pak
+	}
+	p.Priority = prio
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.avail && priorityOf(r.cur) > prio {
+		return false
+	}
+	return r.bcast(&p)
+}
+
+// Priority returns the Priority of the current value,
+// and whether the latch is closed.
+func (r *Latch) Priority() (prio int, closed bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if !r.avail {
+		return 0, false
+	}
+	return priorityOf(r.cur), true
+}
+
+// priorityOf is p's Priority; a nil Packet, as from
+// Bcast(nil), has priority 0.
+func priorityOf(p *Packet) int {
+	if p == nil {
+		return 0
+	}
+	return p.Priority
+}
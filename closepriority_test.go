@@ -0,0 +1,50 @@
+package latch
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClosePriority(t *testing.T) {
+
+	l := NewLatch(1)
+	if _, closed := l.Priority(); closed {
+		t.Fatal("a new latch is open")
+	}
+	fatal := &Packet{Err: errors.New("disk on fire")}
+	if !l.ClosePriority(fatal, 10) {
+		t.Fatal("first close should take")
+	}
+	if fatal.Priority != 0 {
+		t.Fatal("ClosePriority should leave pak alone")
+	}
+	if l.ClosePriority(&Packet{Item: "normal shutdown"}, 1) {
+		t.Fatal("a lower priority close should not overwrite")
+	}
+	if prio, closed := l.Priority(); !closed || prio != 10 {
+		t.Fatalf("Priority() = %d, %v", prio, closed)
+	}
+	if pak := <-l.Ch(); pak.Err != fatal.Err {
+		t.Fatalf("got %v", pak)
+	}
+	if !l.ClosePriority(&Packet{Item: "fatal too"}, 10) {
+		t.Fatal("an equal priority close should take")
+	}
+}
+
+func TestClosePriorityBcastNil(t *testing.T) {
+
+	l := NewLatch(1, WithWritePolicy(HighestPriorityWins))
+	l.Bcast(nil)
+	if prio, closed := l.Priority(); prio != 0 || !closed {
+		t.Fatalf("Priority() = %v, %v after Bcast(nil)", prio, closed)
+	}
+	if !l.ClosePriority(nil, 1) {
+		t.Fatal("priority 1 should beat a nil Packet")
+	}
+	tx := Begin()
+	tx.Set(l, nil)
+	if err := tx.Commit(); err == nil {
+		t.Fatal("a nil Packet, priority 0, should lose to priority 1")
+	}
+}
@@ -0,0 +1,48 @@
+package latch
+
+import (
+	"io"
+	"sync"
+)
+
+// Closer returns an io.Closer whose first Close
+// does Bcast(pak) on r. Later Closes do nothing.
+// Close always returns nil. It lets a latch be
+// handed to code that manages resources as
+// io.Closers, such as a list of things to close
+// at shutdown.
+func (r *Latch) Closer(pak *Packet) io.Closer {
+	return &latchCloser{r: r, pak: pak}
+}
+
+type latchCloser struct {
+	r    *Latch
+	pak  *Packet
+	once sync.Once
+}
+
+func (c *latchCloser) Close() error {
+	c.once.Do(func() { c.r.Bcast(c.pak) })
+	return nil
+}
+
+// CloseOnClose closes c when r closes (or straight
+// away, if r is already closed). The result of
+// c.Close is delivered on errc, which is then closed.
+// Calling cancel before then abandons the wait; c
+// is not closed, and errc is closed without a value.
+func (r *Latch) CloseOnClose(c io.Closer) (errc <-chan error, cancel func()) {
+	ch := make(chan error, 1)
+	stop := make(chan struct{})
+	var once sync.Once
+	done := r.Done()
+	goLabeled(RoleFollower, r.name, func() {
+		defer close(ch)
+		select {
+		case <-done:
+			ch <- c.Close()
+		case <-stop:
+		}
+	})
+	return ch, func() { once.Do(func() { close(stop) }) }
+}
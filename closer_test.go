@@ -0,0 +1,62 @@
+package latch
+
+import (
+	"errors"
+	"testing"
+)
+
+type closeCounter struct {
+	n   int
+	err error
+}
+
+func (c *closeCounter) Close() error {
+	c.n++
+	return c.err
+}
+
+func TestCloser(t *testing.T) {
+
+	l := NewLatch(1)
+	c := l.Closer(&Packet{Item: "closed"})
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if l.Peek().Item != "closed" {
+		t.Fatal("Close should close the latch")
+	}
+	l.Clear()
+	c.Close()
+	if l.IsClosed() {
+		t.Fatal("only the first Close should close the latch")
+	}
+}
+
+func TestCloseOnClose(t *testing.T) {
+
+	l := NewLatch(1)
+	boom := errors.New("boom")
+	res := &closeCounter{err: boom}
+	errc, cancel := l.CloseOnClose(res)
+	defer cancel()
+
+	l.Bcast(&Packet{})
+	if err := <-errc; err != boom {
+		t.Fatalf("expected Close's error, got %v", err)
+	}
+	if res.n != 1 {
+		t.Fatalf("expected one Close, got %v", res.n)
+	}
+
+	l.Clear()
+	other := &closeCounter{}
+	errc, cancel = l.CloseOnClose(other)
+	cancel()
+	if _, ok := <-errc; ok {
+		t.Fatal("a cancelled wait should deliver nothing")
+	}
+	l.Bcast(&Packet{})
+	if other.n != 0 {
+		t.Fatal("a cancelled wait should not Close")
+	}
+}
@@ -0,0 +1,103 @@
+package latch
+
+// CloseSeq closes r with each of paks in turn, under one
+// acquisition of the lock, as if by a series of Bcasts
+// that nobody could interleave with. Each value is a
+// separate transition: it bumps the Version, passes
+// through any WithReducer, and is seen, in order, by
+// WithHistory, WithPersistence, WithLogger and the like.
+// Readers of Ch() see only the final value.
+//
+// It suits replaying a log of transitions, such as one
+// persisted earlier. CloseSeq returns the number of
+// values applied, which is less than len(paks) only if
+// one was refused, by WithWriteOnce, the write policy or
+// a veto hook; the rest are not tried.
+func (r *Latch) CloseSeq(paks []*Packet) int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	n := 0
+	for _, pak := range paks {
+		if !r.set(pak) {
+			break
+		}
+		n++
+	}
+	if n > 0 {
+		r.publish()
+	}
+	return n
+}
+
+// WithHistory makes a latch remember its last n
+// transitions, for History.
+func WithHistory(n int) Option {
+	return func(r *Latch) {
+		r.history = &history{ring: make([]Update, n)}
+	}
+}
+
+type history struct {
+	ring []Update
+	next uint64 // count of transitions recorded.
+}
+
+// record notes the transition r just made.
+// Caller must hold r.mut.
+func (h *history) record(r *Latch) {
+	if len(h.ring) == 0 {
+		return
+	}
+	u := Update{Version: r.version}
+	if r.avail {
+		u.Packet = r.cur
+	}
+	h.ring[h.next%uint64(len(h.ring))] = u
+	h.next++
+}
+
+// History returns the remembered transitions with
+// Versions after since, oldest first, and whether that
+// is all of them: false means some were forgotten,
+// because more than WithHistory's n happened since, or
+// because since is from before a Reset, which starts
+// the Versions, and the history, over. Without
+// WithHistory, History returns nil, false.
+//
+// Together with Observe, it lets a watcher see every
+// value, even those CloseSeq applies in one go:
+//
+//	for {
+//		_, _, next := l.Observe()
+//		ups, _ := l.History(seen)
+//		for _, u := range ups { ...; seen = u.Version }
+//		<-next
+//	}
+func (r *Latch) History(since uint64) (ups []Update, complete bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	h := r.history
+	if h == nil {
+		return nil, false
+	}
+	reset := since > r.version
+	if reset {
+		// since is from before a Reset.
+		since = 0
+	}
+	for i := uint64(0); i < h.next && i < uint64(len(h.ring)); i++ {
+		u := h.ring[(h.next-1-i)%uint64(len(h.ring))]
+		if u.Version <= since {
+			break
+		}
+		ups = append(ups, u)
+	}
+	// we collected newest first.
+	for i, j := 0, len(ups)-1; i < j; i, j = i+1, j-1 {
+		ups[i], ups[j] = ups[j], ups[i]
+	}
+	// versions are consecutive, so a gap
+	// means we've forgotten some.
+	complete = !reset && (len(ups) == 0 || ups[0].Version == since+1)
+	return ups, complete
+}
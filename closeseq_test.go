@@ -0,0 +1,68 @@
+package latch
+
+import "testing"
+
+func TestCloseSeq(t *testing.T) {
+
+	l := NewLatch(2, WithHistory(3))
+	l.Bcast(&Packet{Item: 0})
+	_, seen, next := l.Observe()
+
+	paks := []*Packet{{Item: 1}, {Item: 2}, {Item: 3}}
+	if n := l.CloseSeq(paks); n != 3 {
+		t.Fatalf("expected 3 applied, got %v", n)
+	}
+	<-next
+	if l.Version() != 4 {
+		t.Fatalf("each value should be a transition, version is %v", l.Version())
+	}
+
+	// plain readers see only the final value.
+	for i := 0; i < 2; i++ {
+		if (<-l.Ch()).Item != 3 {
+			t.Fatal("Ch should hold only the final value")
+		}
+	}
+
+	// a watcher with history sees every one.
+	ups, complete := l.History(seen)
+	if !complete || len(ups) != 3 {
+		t.Fatalf("expected all 3 updates, got %v, %v", ups, complete)
+	}
+	for i, u := range ups {
+		if u.Version != seen+uint64(i)+1 || u.Packet.Item != i+1 {
+			t.Fatalf("update %v out of order: %#v", i, u)
+		}
+	}
+
+	// only 3 are kept.
+	ups, complete = l.History(0)
+	if complete || len(ups) != 3 || ups[0].Version != 2 {
+		t.Fatalf("expected the last 3, incomplete, got %v, %v", ups, complete)
+	}
+
+	l.Clear()
+	ups, _ = l.History(4)
+	if len(ups) != 1 || ups[0].Packet != nil {
+		t.Fatalf("a Clear should be recorded with a nil Packet, got %v", ups)
+	}
+
+	w := NewLatch(1, WithWriteOnce())
+	if n := w.CloseSeq(paks); n != 1 || w.Peek().Item != 1 {
+		t.Fatalf("write-once should stop after the first, got %v", n)
+	}
+}
+
+func TestHistoryReset(t *testing.T) {
+
+	l := NewLatch(1, WithHistory(8))
+	l.CloseSeq([]*Packet{{Item: 1}, {Item: 2}, {Item: 3}})
+	seen := l.Version()
+	l.Reset(1)
+	l.Bcast(&Packet{Item: "after"})
+
+	ups, complete := l.History(seen)
+	if complete || len(ups) != 1 || ups[0].Packet.Item != "after" {
+		t.Fatalf("History across a Reset got %v, %v", ups, complete)
+	}
+}
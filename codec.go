@@ -0,0 +1,150 @@
+package latch
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// Codec serializes Packets, for persistence and for
+// carrying latches between processes. JSONCodec and
+// GobCodec are provided; both restore Items of the
+// concrete types given to RegisterType. Errors are
+// carried as their message only, since arbitrary error
+// types can't be serialized; the decoded Err is an
+// error with the same message.
+type Codec interface {
+	Encode(pak *Packet) ([]byte, error)
+	Decode(data []byte) (*Packet, error)
+}
+
+// the types registered with RegisterType, both ways.
+var itemTypes = struct {
+	mu     sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{
+	byName: make(map[string]reflect.Type),
+	byType: make(map[reflect.Type]string),
+}
+
+// RegisterType records the concrete type of sample under
+// name, so that Codecs can restore Items of that type.
+// Call it during init, as with gob.Register; name must be
+// the same in every process exchanging Packets. Items
+// of unregistered types still encode, but JSONCodec
+// decodes them generically and GobCodec refuses them.
+func RegisterType(name string, sample interface{}) {
+	t := reflect.TypeOf(sample)
+	itemTypes.mu.Lock()
+	itemTypes.byName[name] = t
+	itemTypes.byType[t] = name
+	itemTypes.mu.Unlock()
+	gob.RegisterName(name, sample)
+}
+
+// JSONCodec encodes Packets as JSON. Items of registered
+// types are tagged with their name, and decode back
+// into that type; others decode as the generic types
+// encoding/json produces (string, float64,
+// map[string]interface{}, ...).
+type JSONCodec struct{}
+
+type jsonPacket struct {
+	Type string          `json:"type,omitempty"`
+	Item json.RawMessage `json:"item,omitempty"`
+	Err  string          `json:"err,omitempty"`
+	// HasErr tells an Err with an empty message from
+	// none.
+	HasErr bool `json:"has_err,omitempty"`
+	Prio   int  `json:"prio,omitempty"`
+}
+
+// Encode implements Codec.
+func (JSONCodec) Encode(pak *Packet) ([]byte, error) {
+	jp := jsonPacket{Prio: pak.Priority}
+	if pak.Item != nil {
+		item, err := json.Marshal(pak.Item)
+		if err != nil {
+			return nil, err
+		}
+		jp.Item = item
+		itemTypes.mu.RLock()
+		jp.Type = itemTypes.byType[reflect.TypeOf(pak.Item)]
+		itemTypes.mu.RUnlock()
+	}
+	if pak.Err != nil {
+		jp.Err = pak.Err.Error()
+		jp.HasErr = true
+	}
+	return json.Marshal(jp)
+}
+
+// Decode implements Codec.
+func (JSONCodec) Decode(data []byte) (*Packet, error) {
+	var jp jsonPacket
+	if err := json.Unmarshal(data, &jp); err != nil {
+		return nil, err
+	}
+	pak := &Packet{Priority: jp.Prio}
+	if len(jp.Item) > 0 {
+		itemTypes.mu.RLock()
+		t, ok := itemTypes.byName[jp.Type]
+		itemTypes.mu.RUnlock()
+		if ok {
+			v := reflect.New(t)
+			if err := json.Unmarshal(jp.Item, v.Interface()); err != nil {
+				return nil, err
+			}
+			pak.Item = v.Elem().Interface()
+		} else if err := json.Unmarshal(jp.Item, &pak.Item); err != nil {
+			return nil, err
+		}
+	}
+	if jp.HasErr || jp.Err != "" {
+		pak.Err = errors.New(jp.Err)
+	}
+	return pak, nil
+}
+
+// GobCodec encodes Packets with encoding/gob. Items
+// must be of basic types, or types registered with
+// RegisterType (or gob.Register).
+type GobCodec struct{}
+
+type gobPacket struct {
+	Item     interface{}
+	Err      string
+	HasErr   bool
+	Priority int
+}
+
+// Encode implements Codec.
+func (GobCodec) Encode(pak *Packet) ([]byte, error) {
+	gp := gobPacket{Item: pak.Item, Priority: pak.Priority}
+	if pak.Err != nil {
+		gp.Err = pak.Err.Error()
+		gp.HasErr = true
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&gp); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode implements Codec.
+func (GobCodec) Decode(data []byte) (*Packet, error) {
+	var gp gobPacket
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&gp); err != nil {
+		return nil, err
+	}
+	pak := &Packet{Item: gp.Item, Priority: gp.Priority}
+	if gp.HasErr {
+		pak.Err = errors.New(gp.Err)
+	}
+	return pak, nil
+}
@@ -0,0 +1,52 @@
+package latch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testConfig struct {
+	Name    string
+	Workers int
+}
+
+func init() {
+	RegisterType("latch.testConfig", testConfig{})
+}
+
+func TestCodecs(t *testing.T) {
+
+	for _, codec := range []Codec{JSONCodec{}, GobCodec{}} {
+		in := &Packet{
+			Item: testConfig{Name: "prod", Workers: 8},
+			Err:  errors.New("degraded"),
+		}
+		data, err := codec.Encode(in)
+		if err != nil {
+			t.Fatalf("%T: %v", codec, err)
+		}
+		out, err := codec.Decode(data)
+		if err != nil {
+			t.Fatalf("%T: %v", codec, err)
+		}
+		if !reflect.DeepEqual(out.Item, in.Item) {
+			t.Fatalf("%T: registered Item should round-trip exactly, got %#v", codec, out.Item)
+		}
+		if out.Err == nil || out.Err.Error() != "degraded" {
+			t.Fatalf("%T: Err should round-trip by message, got %v", codec, out.Err)
+		}
+
+		empty, err := codec.Encode(&Packet{})
+		if err != nil {
+			t.Fatalf("%T: %v", codec, err)
+		}
+		out, err = codec.Decode(empty)
+		if err != nil {
+			t.Fatalf("%T: %v", codec, err)
+		}
+		if out.Item != nil || out.Err != nil {
+			t.Fatalf("%T: empty Packet should round-trip empty, got %#v", codec, out)
+		}
+	}
+}
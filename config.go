@@ -0,0 +1,87 @@
+package latch
+
+import "context"
+
+// Config publishes immutable snapshots of a configuration
+// struct: atomic.Value semantics, plus blocking Wait and
+// change watching. Writers Store a new T, which is copied
+// ("frozen") as it is published; readers Load the latest
+// snapshot at the cost of an atomic load.
+//
+// Store copies T by value, which is only a shallow copy
+// if T holds pointers, slices, or maps. Give NewConfig a
+// clone func to copy those deeply, so that a writer
+// re-using its struct cannot disturb published snapshots.
+// Readers must still treat what Load returns as
+// read-only, since the snapshots are shared.
+type Config[T any] struct {
+	v     *ValueLatch[T]
+	clone func(T) T
+}
+
+// NewConfig makes a Config with nothing stored yet.
+// clone may be nil.
+func NewConfig[T any](clone func(T) T) *Config[T] {
+	return &Config[T]{
+		v:     NewValueLatch[T](),
+		clone: clone,
+	}
+}
+
+// Store publishes a copy of cfg as the latest snapshot.
+func (c *Config[T]) Store(cfg T) {
+	if c.clone != nil {
+		cfg = c.clone(cfg)
+	}
+	c.v.Bcast(cfg, nil)
+}
+
+// Load returns the latest snapshot, or the
+// zero T if nothing has been stored.
+func (c *Config[T]) Load() T {
+	p, _ := c.v.Load()
+	return p.Item
+}
+
+// Wait blocks until something has been stored,
+// and returns the latest snapshot; or returns
+// ctx.Err() if ctx is done first.
+func (c *Config[T]) Wait(ctx context.Context) (T, error) {
+	p, ok := c.v.Wait(ctx.Done())
+	if !ok {
+		return p.Item, ctx.Err()
+	}
+	return p.Item, nil
+}
+
+// Version returns the number of Stores so far.
+func (c *Config[T]) Version() uint64 {
+	_, version, _ := c.v.Observe()
+	return version
+}
+
+// Next waits for a Store after version, and returns
+// the latest snapshot with its version. Passing back
+// the version each time, a watcher sees every change,
+// with bursts of Stores coalesced:
+//
+//	var cfg T
+//	var v uint64
+//	for {
+//		cfg, v, err = c.Next(ctx, v)
+//		...
+//	}
+func (c *Config[T]) Next(ctx context.Context, version uint64) (T, uint64, error) {
+	for {
+		cur, v, next := c.v.Observe()
+		if cur != nil && v > version {
+			return cur.Item, v, nil
+		}
+		select {
+		case <-next:
+		case <-ctx.Done():
+			var zero T
+			return zero, version, ctx.Err()
+		}
+	}
+}
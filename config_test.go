@@ -0,0 +1,53 @@
+package latch
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestConfig(t *testing.T) {
+
+	type settings struct {
+		Hosts []string
+	}
+	c := NewConfig(func(s settings) settings {
+		s.Hosts = slices.Clone(s.Hosts)
+		return s
+	})
+	ctx := context.Background()
+
+	if len(c.Load().Hosts) != 0 || c.Version() != 0 {
+		t.Fatal("a new Config should be empty")
+	}
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Wait(short); err != context.DeadlineExceeded {
+		t.Fatalf("Wait should block until a Store, got %v", err)
+	}
+
+	got := make(chan settings)
+	go func() {
+		s, v, err := c.Next(ctx, 0)
+		if err != nil || v != 1 {
+			t.Errorf("Next got version %v, err %v", v, err)
+		}
+		got <- s
+	}()
+
+	s := settings{Hosts: []string{"a", "b"}}
+	c.Store(s)
+	// the writer re-uses its struct; the snapshot is frozen.
+	s.Hosts[0] = "z"
+
+	if h := (<-got).Hosts; h[0] != "a" {
+		t.Fatalf("Next should see the stored snapshot, got %v", h)
+	}
+	if h := c.Load().Hosts; h[0] != "a" {
+		t.Fatalf("Load should see the frozen snapshot, got %v", h)
+	}
+	if w, err := c.Wait(ctx); err != nil || w.Hosts[1] != "b" {
+		t.Fatalf("Wait got %v, %v", w, err)
+	}
+}
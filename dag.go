@@ -0,0 +1,196 @@
+package latch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DAGNode declares a latch in a DAG, and the names of
+// the nodes it depends on.
+type DAGNode struct {
+	Name  string
+	Latch *Latch
+	Deps  []string
+}
+
+// CycleError reports a dependency cycle found by NewDAG;
+// Path starts and ends with the same node.
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "latch: dependency cycle: " + strings.Join(e.Path, " -> ")
+}
+
+// DAG relates latches by dependency: "cache-ready
+// depends on db-ready". A node is Ready once the latches
+// of all its dependencies are closed; so startup can be
+// ordered by closing each latch once its node is Ready
+// and its work is done, the mirror image of ordered
+// shutdown. A DAG is fixed once made.
+type DAG struct {
+	nodes map[string]*DAGNode
+	order []string
+}
+
+// NewDAG makes a DAG of nodes. It returns a *CycleError
+// if the dependencies have a cycle, and an error for a
+// dependency on an unknown node, or a repeated name.
+func NewDAG(nodes ...DAGNode) (*DAG, error) {
+	d := &DAG{nodes: make(map[string]*DAGNode, len(nodes))}
+	for i := range nodes {
+		n := &nodes[i]
+		if d.nodes[n.Name] != nil {
+			return nil, fmt.Errorf("latch: DAG node %q repeated", n.Name)
+		}
+		d.nodes[n.Name] = n
+	}
+	for _, n := range nodes {
+		for _, dep := range n.Deps {
+			if d.nodes[dep] == nil {
+				return nil, fmt.Errorf("latch: DAG node %q depends on unknown %q", n.Name, dep)
+			}
+		}
+	}
+
+	// depth first, in the order given, so that Order is
+	// stable; a node on the stack seen again is a cycle.
+	const (
+		unseen = iota
+		onStack
+		finished
+	)
+	state := make(map[string]int, len(nodes))
+	var stack []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case finished:
+			return nil
+		case onStack:
+			i := len(stack) - 1
+			for stack[i] != name {
+				i--
+			}
+			path := append(append([]string(nil), stack[i:]...), name)
+			return &CycleError{Path: path}
+		}
+		state[name] = onStack
+		stack = append(stack, name)
+		for _, dep := range d.nodes[name].Deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = finished
+		d.order = append(d.order, name)
+		return nil
+	}
+	for _, n := range nodes {
+		if err := visit(n.Name); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Order returns the node names in dependency order:
+// every node comes after all of its dependencies.
+func (d *DAG) Order() []string {
+	return append([]string(nil), d.order...)
+}
+
+// Latch returns the latch of node name, or nil.
+func (d *DAG) Latch(name string) *Latch {
+	if n := d.nodes[name]; n != nil {
+		return n.Latch
+	}
+	return nil
+}
+
+// Deps returns the dependencies of node name.
+func (d *DAG) Deps(name string) []string {
+	if n := d.nodes[name]; n != nil {
+		return append([]string(nil), n.Deps...)
+	}
+	return nil
+}
+
+// Dependents returns the nodes that depend directly on
+// node name, in Order.
+func (d *DAG) Dependents(name string) []string {
+	var out []string
+	for _, n := range d.order {
+		for _, dep := range d.nodes[n].Deps {
+			if dep == name {
+				out = append(out, n)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// waiting returns the dependencies of name whose
+// latches are still open.
+func (d *DAG) waiting(name string) []string {
+	var out []string
+	for _, dep := range d.nodes[name].Deps {
+		if !d.nodes[dep].Latch.IsClosed() {
+			out = append(out, dep)
+		}
+	}
+	return out
+}
+
+// Ready reports whether every dependency of node name
+// is closed. An unknown name is never Ready.
+func (d *DAG) Ready(name string) bool {
+	return d.nodes[name] != nil && len(d.waiting(name)) == 0
+}
+
+// WaitReady waits until node name is Ready, or ctx is
+// done.
+func (d *DAG) WaitReady(ctx context.Context, name string) error {
+	n := d.nodes[name]
+	if n == nil {
+		return fmt.Errorf("latch: no DAG node %q", name)
+	}
+	for {
+		waiting := d.waiting(name)
+		if len(waiting) == 0 {
+			return nil
+		}
+		select {
+		case <-d.nodes[waiting[0]].Latch.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// NodeStatus describes one node of a DAG.
+type NodeStatus struct {
+	Name    string
+	Ready   bool     // every dependency is closed.
+	Closed  bool     // the node's own latch is closed.
+	Waiting []string // dependencies still open.
+}
+
+// Status describes every node, in Order.
+func (d *DAG) Status() []NodeStatus {
+	out := make([]NodeStatus, 0, len(d.order))
+	for _, name := range d.order {
+		waiting := d.waiting(name)
+		out = append(out, NodeStatus{
+			Name:    name,
+			Ready:   len(waiting) == 0,
+			Closed:  d.nodes[name].Latch.IsClosed(),
+			Waiting: waiting,
+		})
+	}
+	return out
+}
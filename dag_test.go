@@ -0,0 +1,54 @@
+package latch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDAG(t *testing.T) {
+
+	db, cache, api := NewLatch(1), NewLatch(1), NewLatch(1)
+	d, err := NewDAG(
+		DAGNode{Name: "api", Latch: api, Deps: []string{"cache", "db"}},
+		DAGNode{Name: "cache", Latch: cache, Deps: []string{"db"}},
+		DAGNode{Name: "db", Latch: db},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Order(); !reflect.DeepEqual(got, []string{"db", "cache", "api"}) {
+		t.Fatalf("Order() = %v", got)
+	}
+	if got := d.Dependents("db"); !reflect.DeepEqual(got, []string{"cache", "api"}) {
+		t.Fatalf("Dependents(db) = %v", got)
+	}
+	if !d.Ready("db") || d.Ready("cache") {
+		t.Fatal("db has no deps; cache waits on db")
+	}
+
+	done := make(chan error)
+	go func() { done <- d.WaitReady(context.Background(), "api") }()
+	db.Bcast(&Packet{})
+	if st := d.Status(); !st[1].Ready || st[2].Ready || !reflect.DeepEqual(st[2].Waiting, []string{"cache"}) {
+		t.Fatalf("Status() = %+v", st)
+	}
+	cache.Bcast(&Packet{})
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewDAG(
+		DAGNode{Name: "a", Latch: NewLatch(1), Deps: []string{"b"}},
+		DAGNode{Name: "b", Latch: NewLatch(1), Deps: []string{"c"}},
+		DAGNode{Name: "c", Latch: NewLatch(1), Deps: []string{"a"}},
+	)
+	var cycle *CycleError
+	if !errors.As(err, &cycle) || !reflect.DeepEqual(cycle.Path, []string{"a", "b", "c", "a"}) {
+		t.Fatalf("expected the cycle a -> b -> c -> a, got %v", err)
+	}
+	if _, err := NewDAG(DAGNode{Name: "a", Deps: []string{"nope"}}); err == nil {
+		t.Fatal("an unknown dependency should fail")
+	}
+}
@@ -0,0 +1,109 @@
+package latch
+
+import "sync"
+
+// ControlState is the lifecycle state of a DataLatch.
+type ControlState int
+
+const (
+	// ControlOpen: the subsystem is running.
+	ControlOpen ControlState = iota
+
+	// ControlDraining: finishing in-flight work,
+	// accepting no more.
+	ControlDraining
+
+	// ControlClosed: stopped.
+	ControlClosed
+)
+
+func (s ControlState) String() string {
+	switch s {
+	case ControlOpen:
+		return "open"
+	case ControlDraining:
+		return "draining"
+	case ControlClosed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// DataLatch pairs a subsystem's lifecycle (its control
+// plane) with its configuration or other data (its data
+// plane). Each plane has its own latch, so watchers of
+// one aren't woken by the other; but the pair is updated
+// under one lock, so State returns a consistent view of
+// both, and Update changes both together.
+//
+// The control latch is always closed, with the current
+// ControlState as its Item, like a Breaker's. The data
+// latch is open until data is first set.
+type DataLatch struct {
+	mu      sync.Mutex
+	control *Latch
+	data    *Latch
+	state   ControlState
+}
+
+// NewDataLatch makes a DataLatch in ControlOpen, with
+// no data. Both latches have a backing channel of
+// size sz, and opts as for NewLatch.
+func NewDataLatch(sz int, opts ...Option) *DataLatch {
+	d := &DataLatch{
+		control: NewLatch(sz, opts...),
+		data:    NewLatch(sz, opts...),
+	}
+	d.control.Bcast(&Packet{Item: ControlOpen})
+	return d
+}
+
+// Control returns the control plane's latch.
+func (d *DataLatch) Control() *Latch {
+	return d.control
+}
+
+// Data returns the data plane's latch.
+func (d *DataLatch) Data() *Latch {
+	return d.data
+}
+
+// State returns the control state and the data
+// (nil if none has been set), consistently.
+func (d *DataLatch) State() (ControlState, *Packet) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state, d.data.Peek()
+}
+
+// SetControl changes the control state, if it differs.
+func (d *DataLatch) SetControl(s ControlState) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.setControl(s)
+}
+
+// SetData closes the data latch with pak.
+func (d *DataLatch) SetData(pak *Packet) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.data.Bcast(pak)
+}
+
+// Update sets both planes at once, so no State
+// caller sees one changed without the other.
+func (d *DataLatch) Update(s ControlState, pak *Packet) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.data.Bcast(pak)
+	d.setControl(s)
+}
+
+// setControl. Caller must hold d.mu.
+func (d *DataLatch) setControl(s ControlState) {
+	if s == d.state {
+		return
+	}
+	d.state = s
+	d.control.Bcast(&Packet{Item: s})
+}
@@ -0,0 +1,39 @@
+package latch
+
+import "testing"
+
+func TestDataLatch(t *testing.T) {
+
+	d := NewDataLatch(1)
+	if s, pak := d.State(); s != ControlOpen || pak != nil {
+		t.Fatalf("expected open with no data, got %v, %v", s, pak)
+	}
+
+	_, cv, controlChanged := d.Control().Observe()
+	_, dv, dataChanged := d.Data().Observe()
+
+	d.SetData(&Packet{Item: "cfg-1"})
+	<-dataChanged
+	select {
+	case <-controlChanged:
+		t.Fatal("a data change should not wake control watchers")
+	default:
+	}
+
+	d.Update(ControlDraining, &Packet{Item: "cfg-2"})
+	<-controlChanged
+	if s, pak := d.State(); s != ControlDraining || pak.Item != "cfg-2" {
+		t.Fatalf("Update should set both, got %v, %v", s, pak)
+	}
+	if (<-d.Control().Ch()).Item != ControlDraining {
+		t.Fatal("the control latch should carry the state")
+	}
+
+	d.SetControl(ControlDraining)
+	if d.Control().Version() != cv+1 || d.Data().Version() != dv+2 {
+		t.Fatal("setting an unchanged state should be a no-op")
+	}
+	if ControlClosed.String() != "closed" {
+		t.Fatal("unexpected String")
+	}
+}
@@ -0,0 +1,51 @@
+package latch
+
+import "time"
+
+// DeadlineLatch is a Latch that closes itself
+// (as if by Bcast) once its timer fires. Many readers
+// can then observe the timeout, just as they
+// would any other broadcast.
+//
+// If somebody calls Bcast or Clear on the latch
+// before the timer fires, the timer's close is
+// abandoned; the earlier transition wins.
+type DeadlineLatch struct {
+	*Latch
+	timer Timer
+}
+
+// NewDeadlineLatch makes a new latch with a backing
+// channel of size sz, that will Bcast(pak) after
+// duration d. opts are as for NewLatch.
+func NewDeadlineLatch(sz int, d time.Duration, pak *Packet, opts ...Option) *DeadlineLatch {
+	return NewLatch(sz, opts...).CloseAfter(d, pak)
+}
+
+// CloseAfter arranges for r to Bcast(pak) after
+// duration d, unless r sees a Bcast or Clear
+// before then. Call Cancel on the returned
+// DeadlineLatch to abort.
+func (r *Latch) CloseAfter(d time.Duration, pak *Packet) *DeadlineLatch {
+	r.mut.Lock()
+	armed := r.gen
+	r.mut.Unlock()
+
+	return &DeadlineLatch{
+		Latch: r,
+		timer: r.clock().AfterFunc(d, func() {
+			r.mut.Lock()
+			if r.gen == armed {
+				r.bcast(pak)
+			}
+			r.mut.Unlock()
+		}),
+	}
+}
+
+// Cancel stops the deadline timer. It returns
+// true if the call stopped the timer, false if the
+// timer had already fired or been cancelled.
+func (d *DeadlineLatch) Cancel() bool {
+	return d.timer.Stop()
+}
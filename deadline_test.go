@@ -0,0 +1,61 @@
+package latch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadlineLatch(t *testing.T) {
+
+	timeout := &Packet{Item: "timeout"}
+	dl := NewDeadlineLatch(1, 10*time.Millisecond, timeout)
+
+	select {
+	case <-dl.Ch():
+		t.Fatal("deadline latch should start open")
+	default:
+	}
+
+	select {
+	case b := <-dl.Ch():
+		if b != timeout {
+			t.Fatal("expected the timeout packet after the deadline")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadline latch never closed")
+	}
+
+	// an earlier Bcast pre-empts the timer.
+	latch := NewLatch(1)
+	latch.CloseAfter(10*time.Millisecond, timeout)
+	early := &Packet{Item: "early"}
+	latch.Bcast(early)
+	time.Sleep(50 * time.Millisecond)
+	latch.Refresh()
+	if b := <-latch.Ch(); b != early {
+		t.Fatal("the deadline should not overwrite an earlier Bcast")
+	}
+
+	// Cancel aborts.
+	dl = NewDeadlineLatch(1, 10*time.Millisecond, timeout)
+	if !dl.Cancel() {
+		t.Fatal("Cancel should have stopped the pending timer")
+	}
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-dl.Ch():
+		t.Fatal("cancelled deadline latch should remain open")
+	default:
+	}
+}
+
+func TestCloseAfterReset(t *testing.T) {
+
+	l := NewLatch(1)
+	l.CloseAfter(20*time.Millisecond, &Packet{Item: "stale"})
+	l.Reset(1)
+	time.Sleep(60 * time.Millisecond)
+	if l.IsClosed() {
+		t.Fatal("a CloseAfter armed before Reset should not close the latch")
+	}
+}
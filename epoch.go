@@ -0,0 +1,98 @@
+package latch
+
+import (
+	"context"
+	"sync"
+)
+
+// epoch counts transitions across a set of latches.
+type epoch struct {
+	mu  sync.Mutex
+	n   uint64
+	chg chan struct{}
+
+	// names are the names each latch is watched
+	// under, for the watchers (see WatchAll).
+	names    map[*Latch][]string
+	watchers map[*allWatcher]struct{}
+}
+
+func (e *epoch) bump() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.n++
+	close(e.chg)
+	e.chg = make(chan struct{})
+}
+
+func (e *epoch) observe() (uint64, <-chan struct{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.n, e.chg
+}
+
+// watchEpoch has r bump e on each of its transitions,
+// as name; once for each time it is watched.
+func (r *Latch) watchEpoch(e *epoch, name string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.epochs == nil {
+		r.epochs = make(map[*epoch]int)
+	}
+	r.epochs[e]++
+	e.join(r, name)
+}
+
+// unwatchEpoch undoes one watchEpoch.
+func (r *Latch) unwatchEpoch(e *epoch, name string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.epochs[e]--; r.epochs[e] <= 0 {
+		delete(r.epochs, e)
+	}
+	e.leave(r, name)
+}
+
+// bumpEpochs bumps the epochs watching r.
+// Caller must hold r.mut.
+func (r *Latch) bumpEpochs() {
+	for e := range r.epochs {
+		e.note(r)
+		e.bump()
+	}
+}
+
+// Epoch returns the Registry's epoch, which goes up on
+// every transition of any registered latch, and every
+// Register and Unregister.
+func (g *Registry) Epoch() uint64 {
+	n, _ := g.epoch.observe()
+	return n
+}
+
+// WaitEpochAfter waits until the epoch is past e, and
+// returns it; or returns ctx.Err() if ctx is done
+// first. A coordinator that only needs to know that
+// anything changed anywhere can wait here, rather than
+// on every latch:
+//
+//	e := reg.Epoch()
+//	for {
+//		rescan(reg)
+//		if e, err = reg.WaitEpochAfter(ctx, e); err != nil {
+//			return err
+//		}
+//	}
+func (g *Registry) WaitEpochAfter(ctx context.Context, e uint64) (uint64, error) {
+	for {
+		n, next := g.epoch.observe()
+		if n > e {
+			return n, nil
+		}
+		select {
+		case <-next:
+		case <-ctx.Done():
+			return n, ctx.Err()
+		}
+	}
+}
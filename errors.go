@@ -0,0 +1,139 @@
+package latch
+
+import "errors"
+
+// Misuse of a latch is reported with these errors by the
+// APIs below, which return an error where the older ones
+// panic or quietly do nothing; callers can then branch
+// with errors.Is, rather than matching panic strings.
+var (
+	// ErrStopped: the BackgroundRefresher was Stopped, and
+	// can't be restarted until Reset.
+	ErrStopped = errors.New("latch: refresher stopped")
+
+	// ErrStarved: the latch is closed, but Ch() has been
+	// drained of copies of its value; see Refresh.
+	ErrStarved = errors.New("latch: closed, but Ch() drained")
+
+	// ErrNotClosed: the latch is open.
+	ErrNotClosed = errors.New("latch: not closed")
+
+	// ErrWriteOnceViolated: a WithWriteOnce latch has
+	// already been written.
+	ErrWriteOnceViolated = errors.New("latch: write to a written WithWriteOnce latch")
+
+	// ErrPolicyRefused: the WithWritePolicy refused a
+	// write.
+	ErrPolicyRefused = errors.New("latch: write refused by the write policy")
+
+	// ErrVetoed: a veto hook refused a close; see AddVeto.
+	ErrVetoed = errors.New("latch: close vetoed")
+
+	// ErrResizeWhileClosed: Resize of a closed latch,
+	// whose channel holds copies of its value.
+	ErrResizeWhileClosed = errors.New("latch: resize of a closed latch")
+
+	// ErrWriterIssued: the Writer of a WithSingleWriter
+	// latch has already been issued.
+	ErrWriterIssued = errors.New("latch: Writer already issued for a WithSingleWriter latch")
+
+	// ErrForeignToken: a Token from another latch.
+	ErrForeignToken = errors.New("latch: Token from another latch")
+)
+
+// BcastErr is Bcast, returning why, where TryBcast would
+// return false: ErrWriteOnceViolated, ErrPolicyRefused,
+// or (wrapping the hook's error) ErrVetoed.
+func (r *Latch) BcastErr(pak *Packet) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if !r.bcast(pak) {
+		return r.refusal
+	}
+	return nil
+}
+
+// ClearErr is Clear, returning ErrWriteOnceViolated
+// where TryClear would return false.
+func (r *Latch) ClearErr() error {
+	if !r.TryClear() {
+		return ErrWriteOnceViolated
+	}
+	return nil
+}
+
+// TryRecv receives from Ch() without blocking. Where a
+// receive would block, it returns ErrNotClosed if the
+// latch is open, or ErrStarved if it is closed but Ch()
+// needs a Refresh.
+func (r *Latch) TryRecv() (*Packet, error) {
+	select {
+	case pak := <-r.Ch():
+		if r.low != nil {
+			r.low.check(r)
+		}
+		return pak, nil
+	default:
+	}
+	if r.IsClosed() {
+		return nil, ErrStarved
+	}
+	return nil, ErrNotClosed
+}
+
+// StartRefresher is BackgroundRefresher, returning
+// ErrStopped if the refresher has been Stopped; where
+// BackgroundRefresher would quietly do nothing.
+func (r *Latch) StartRefresher() error {
+	r.mut.Lock()
+	stop := r.fillerStop
+	r.mut.Unlock()
+	if stop != nil {
+		select {
+		case <-stop:
+			return ErrStopped
+		default:
+		}
+	}
+	r.BackgroundRefresher()
+	return nil
+}
+
+// Resize gives an open latch a channel of size sz. It
+// returns ErrResizeWhileClosed if the latch is closed.
+// As with Reset, a reader holding the old Ch() will
+// never hear from the latch again.
+func (r *Latch) Resize(sz int) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.avail {
+		return ErrResizeWhileClosed
+	}
+	if sz != r.c().sz {
+		r.makeCh(sz)
+	}
+	return nil
+}
+
+// TryWriter is Writer, returning ErrWriterIssued where
+// Writer would panic.
+func (r *Latch) TryWriter() (*Writer, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.singleWriter {
+		if r.writerIssued {
+			return nil, ErrWriterIssued
+		}
+		r.writerIssued = true
+	}
+	return &Writer{l: r}, nil
+}
+
+// CloseWithErr is CloseWith, returning ErrForeignToken
+// where CloseWith would panic.
+func (r *Latch) CloseWithErr(tok Token, pak *Packet) (bool, error) {
+	if tok.t == nil || tok.t.l != r {
+		return false, ErrForeignToken
+	}
+	return r.CloseWith(tok, pak), nil
+}
@@ -0,0 +1,65 @@
+package latch
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorTaxonomy(t *testing.T) {
+
+	l := NewLatch(2, WithWriteOnce())
+	if _, err := l.TryRecv(); err != ErrNotClosed {
+		t.Fatalf("open latch: got %v", err)
+	}
+	if err := l.BcastErr(&Packet{Item: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.BcastErr(&Packet{Item: 2}); err != ErrWriteOnceViolated {
+		t.Fatalf("second write: got %v", err)
+	}
+	if err := l.ClearErr(); err != ErrWriteOnceViolated {
+		t.Fatalf("clear after write: got %v", err)
+	}
+	if err := l.Resize(4); err != ErrResizeWhileClosed {
+		t.Fatalf("resize while closed: got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if pak, err := l.TryRecv(); err != nil || pak.Item != 1 {
+			t.Fatalf("got %v, %v", pak, err)
+		}
+	}
+	if _, err := l.TryRecv(); err != ErrStarved {
+		t.Fatalf("drained: got %v", err)
+	}
+
+	l = NewLatch(1, WithSingleWriter())
+	if err := l.Resize(3); err != nil || cap(l.Ch()) != 3 {
+		t.Fatalf("resize while open: %v, cap %d", err, cap(l.Ch()))
+	}
+	if _, err := l.TryWriter(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.TryWriter(); err != ErrWriterIssued {
+		t.Fatalf("second writer: got %v", err)
+	}
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ErrWriterIssued) {
+				t.Fatalf("Writer should panic with ErrWriterIssued, got %v", err)
+			}
+		}()
+		l.Writer()
+	}()
+	if _, err := l.CloseWithErr(NewLatch(1).CloseToken(), &Packet{}); err != ErrForeignToken {
+		t.Fatalf("foreign token: got %v", err)
+	}
+
+	if err := l.StartRefresher(); err != nil {
+		t.Fatal(err)
+	}
+	l.Stop()
+	if err := l.StartRefresher(); err != ErrStopped {
+		t.Fatalf("restart after Stop: got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package latch
+
+import "context"
+
+// WithReaderQuota caps how many of the sz buffered copies
+// any one Reader (see Subscribe) may take between
+// refills, so that one hot-looping consumer can't drain
+// Ch() and starve the others until the next Refresh. A
+// Reader that has used up its quota waits in Recv until
+// the channel is refilled, by Refresh or a Bcast.
+//
+// The quota only binds Readers; receives made directly
+// from Ch() are not counted. Served latches (see
+// MaxBufferedSz) cannot be drained, and ignore it.
+func WithReaderQuota(n int) Option {
+	return func(r *Latch) {
+		r.fair = &fairness{quota: n, refill: make(chan struct{})}
+	}
+}
+
+type fairness struct {
+	quota int
+
+	// epoch counts refills; refill is closed,
+	// and replaced, at each one.
+	epoch  uint64
+	refill chan struct{}
+}
+
+// refilled starts a new epoch, resetting every
+// Reader's quota. Caller must hold r.mut.
+func (f *fairness) refilled() {
+	f.epoch++
+	close(f.refill)
+	f.refill = make(chan struct{})
+}
+
+// Reader is one consumer's handle on a latch, for
+// WithReaderQuota. A Reader must not be used by
+// more than one goroutine at a time.
+type Reader struct {
+	r     *Latch
+	epoch uint64
+	used  int
+}
+
+// Subscribe returns a new Reader of r.
+func (r *Latch) Subscribe() *Reader {
+	return &Reader{r: r}
+}
+
+// Recv receives a value from the latch's Ch(), waiting
+// first, if this Reader has used up its quota, for the
+// channel to be refilled. It returns ctx.Err() if ctx
+// is done first.
+func (rd *Reader) Recv(ctx context.Context) (*Packet, error) {
+	r := rd.r
+	for {
+		r.mut.Lock()
+		f := r.fair
+		if f == nil || r.c().served {
+			r.mut.Unlock()
+			break
+		}
+		if rd.epoch != f.epoch {
+			rd.epoch, rd.used = f.epoch, 0
+		}
+		if rd.used < f.quota {
+			rd.used++
+			r.mut.Unlock()
+			break
+		}
+		refill := f.refill
+		r.mut.Unlock()
+
+		select {
+		case <-refill:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	select {
+	case pak := <-r.Ch():
+		return pak, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,46 @@
+package latch
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReaderQuota(t *testing.T) {
+
+	l := NewLatch(4, WithReaderQuota(2))
+	l.Bcast(&Packet{Item: "go"})
+	ctx := context.Background()
+
+	hot := l.Subscribe()
+	for i := 0; i < 2; i++ {
+		if _, err := hot.Recv(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the hot reader is out of quota, though copies remain.
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := hot.Recv(short); err != context.DeadlineExceeded {
+		t.Fatalf("expected the hot reader to wait, got %v", err)
+	}
+
+	// so the others still get theirs.
+	for i := 0; i < 2; i++ {
+		if pak, err := l.Subscribe().Recv(ctx); err != nil || pak.Item != "go" {
+			t.Fatalf("a fresh reader should not be starved: %v, %v", pak, err)
+		}
+	}
+
+	// a Refresh renews the hot reader's quota.
+	got := make(chan *Packet)
+	go func() {
+		pak, _ := hot.Recv(ctx)
+		got <- pak
+	}()
+	l.Refresh()
+	if pak := <-got; pak.Item != "go" {
+		t.Fatalf("expected a value after Refresh, got %v", pak)
+	}
+}
@@ -0,0 +1,41 @@
+package latch
+
+import "time"
+
+// Faults injects the misbehaviour that a latch's delivery
+// guarantees permit, but that rarely shows up in tests;
+// so consumers can be tested for robustness against it.
+// See the chaos subpackage for a randomized Faults.
+type Faults interface {
+	// RefillDelay is how long Refresh waits before
+	// refilling Ch().
+	RefillDelay() time.Duration
+
+	// Starve reports whether Refresh should skip
+	// refilling altogether, this time.
+	Starve() bool
+
+	// NotifyDelay is how late, after a transition, to
+	// wake the watcher Observe is returning a channel
+	// for. Varying it wakes watchers in varying orders.
+	NotifyDelay() time.Duration
+}
+
+// WithFaults makes the latch misbehave as f directs.
+// It is for tests only.
+func WithFaults(f Faults) Option {
+	return func(r *Latch) {
+		r.faults = f
+	}
+}
+
+// delayed returns a channel closed d after next is.
+func delayed(next <-chan struct{}, d time.Duration) <-chan struct{} {
+	ch := make(chan struct{})
+	goLabeled(RoleFaults, "", func() {
+		<-next
+		time.Sleep(d)
+		close(ch)
+	})
+	return ch
+}
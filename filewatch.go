@@ -0,0 +1,101 @@
+package latch
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"time"
+)
+
+// Decoder turns the contents of a watched file into the
+// Item to broadcast; e.g. by json.Unmarshal-ing into
+// a config struct.
+type Decoder func(data []byte) (interface{}, error)
+
+// FileWatcher is a Latch that tracks the contents of a
+// file, for config hot-reload. Every time the file changes,
+// the latch is closed with the new contents (or the
+// result of the Decoder). If the file disappears, the
+// latch is opened (Clear-ed) until it comes back.
+//
+// A decoding failure closes the latch with a Packet
+// whose Err is the Decoder's error, so readers
+// learn about bad config rather than silently
+// keeping the old one.
+type FileWatcher struct {
+	*Latch
+
+	path   string
+	decode Decoder
+	poll   time.Duration
+	last   []byte
+	exists bool
+
+	halt     chan struct{}
+	haltOnce sync.Once
+}
+
+// WatchFile starts watching path, checking for changes
+// every poll interval. The returned FileWatcher's latch
+// has a backing channel of size sz. If decode is nil,
+// the Item broadcast is the raw []byte contents. opts
+// are as for NewLatch.
+//
+// Polling is used, rather than OS notifications, to
+// keep the package free of dependencies; and because
+// editors that save by rename defeat naive notifiers.
+func WatchFile(path string, sz int, poll time.Duration, decode Decoder, opts ...Option) *FileWatcher {
+	w := &FileWatcher{
+		Latch:  NewLatch(sz, opts...),
+		path:   path,
+		decode: decode,
+		poll:   poll,
+		halt:   make(chan struct{}),
+	}
+	w.check()
+	tick := w.clock().NewTicker(poll)
+	goLabeled(RoleWatcher, w.name, func() {
+		defer tick.Stop()
+		for {
+			select {
+			case <-w.halt:
+				return
+			case <-tick.C():
+				w.check()
+			}
+		}
+	})
+	return w
+}
+
+// Unwatch stops polling the file. The latch keeps
+// whatever state it had.
+func (w *FileWatcher) Unwatch() {
+	w.haltOnce.Do(func() { close(w.halt) })
+}
+
+// check compares the file to what we last saw, and
+// closes or opens the latch as required.
+func (w *FileWatcher) check() {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		if w.exists {
+			w.exists = false
+			w.last = nil
+			w.Clear()
+		}
+		return
+	}
+	if w.exists && bytes.Equal(data, w.last) {
+		return
+	}
+	w.exists = true
+	w.last = data
+
+	if w.decode == nil {
+		w.Bcast(&Packet{Item: data})
+		return
+	}
+	item, err := w.decode(data)
+	w.Bcast(&Packet{Item: item, Err: err})
+}
@@ -0,0 +1,51 @@
+package latch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchFile(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	decode := func(data []byte) (interface{}, error) {
+		return string(data), nil
+	}
+	w := WatchFile(path, 1, 5*time.Millisecond, decode)
+	defer w.Unwatch()
+
+	if b := w.Peek(); b == nil || b.Item != "v1" {
+		t.Fatalf("expected v1 right away, got %v", b)
+	}
+
+	if err := os.WriteFile(path, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		b := w.Peek()
+		return b != nil && b.Item == "v2"
+	})
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return !w.IsClosed() })
+}
+
+// waitFor polls cond until it is true, or fails the test.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
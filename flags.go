@@ -0,0 +1,156 @@
+package latch
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Flags is a set of named feature flags, each backed
+// by a latch. A flag's value may be a bool (see Enabled)
+// or any other variant value. Consumers that Watch a
+// flag get latch semantics: non-blocking reads of the
+// latest value once the flag has one.
+//
+// A flag with neither a default nor a set value
+// reads as open (Watch-ers block).
+type Flags struct {
+	mu sync.Mutex
+	sz int
+	m  map[string]*flagState
+}
+
+type flagState struct {
+	l     *Latch
+	def   interface{}
+	hasDf bool
+	val   interface{}
+	isSet bool
+}
+
+// NewFlags makes a new, empty set of flags, whose
+// latches will have backing channels of size sz.
+func NewFlags(sz int) *Flags {
+	return &Flags{
+		sz: sz,
+		m:  make(map[string]*flagState),
+	}
+}
+
+// get returns the state for name, creating it if need be.
+// Caller must hold f.mu.
+func (f *Flags) get(name string) *flagState {
+	s, ok := f.m[name]
+	if !ok {
+		s = &flagState{l: NewLatch(f.sz)}
+		f.m[name] = s
+	}
+	return s
+}
+
+// publish pushes the effective value of s to its latch.
+// Caller must hold f.mu.
+func (s *flagState) publish() {
+	switch {
+	case s.isSet:
+		s.l.Bcast(&Packet{Item: s.val})
+	case s.hasDf:
+		s.l.Bcast(&Packet{Item: s.def})
+	default:
+		s.l.Clear()
+	}
+}
+
+// Default sets the value name has when it has not
+// been explicitly Set (or has been Reset).
+func (f *Flags) Default(name string, v interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.get(name)
+	s.def, s.hasDf = v, true
+	if !s.isSet {
+		s.publish()
+	}
+}
+
+// Set gives name the value v.
+func (f *Flags) Set(name string, v interface{}) {
+	f.Update(map[string]interface{}{name: v})
+}
+
+// Reset reverts name to its default value.
+func (f *Flags) Reset(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.get(name)
+	s.val, s.isSet = nil, false
+	s.publish()
+}
+
+// Update sets all of vals at once. Readers using Value
+// and Enabled see either none or all of the update.
+// (Individual Watch latches are each updated in turn,
+// so a goroutine reading several latches directly can
+// still observe a mix.)
+func (f *Flags) Update(vals map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for name, v := range vals {
+		s := f.get(name)
+		s.val, s.isSet = v, true
+		s.publish()
+	}
+}
+
+// LoadJSON replaces the set flag values with those in the
+// JSON object doc, e.g. {"new-ui": true, "color": "blue"}.
+// Flags not mentioned in doc revert to their defaults.
+// The whole document is applied atomically, as with Update.
+func (f *Flags) LoadJSON(doc []byte) error {
+	var vals map[string]interface{}
+	if err := json.Unmarshal(doc, &vals); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for name, s := range f.m {
+		if _, ok := vals[name]; !ok && s.isSet {
+			s.val, s.isSet = nil, false
+			s.publish()
+		}
+	}
+	for name, v := range vals {
+		s := f.get(name)
+		s.val, s.isSet = v, true
+		s.publish()
+	}
+	return nil
+}
+
+// Value returns the current value of name: the set
+// value, or else the default, or else nil.
+func (f *Flags) Value(name string) interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.m[name]
+	switch {
+	case !ok:
+		return nil
+	case s.isSet:
+		return s.val
+	}
+	return s.def
+}
+
+// Enabled returns true if name's value is the bool true.
+func (f *Flags) Enabled(name string) bool {
+	b, _ := f.Value(name).(bool)
+	return b
+}
+
+// Watch returns the latch backing name. It is closed
+// with the flag's current value, when there is one.
+func (f *Flags) Watch(name string) *Latch {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.get(name).l
+}
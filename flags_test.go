@@ -0,0 +1,35 @@
+package latch
+
+import "testing"
+
+func TestFlags(t *testing.T) {
+
+	f := NewFlags(1)
+	f.Default("new-ui", false)
+
+	if f.Enabled("new-ui") {
+		t.Fatal("new-ui defaults to false")
+	}
+	if f.Watch("color").IsClosed() {
+		t.Fatal("a flag with no value or default should be open")
+	}
+
+	w := f.Watch("new-ui")
+	if err := f.LoadJSON([]byte(`{"new-ui": true, "color": "blue"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Enabled("new-ui") || f.Value("color") != "blue" {
+		t.Fatal("LoadJSON should have set both flags")
+	}
+	if b := <-w.Ch(); b.Item != true {
+		t.Fatal("watchers should see the flag flip")
+	}
+
+	// flags missing from a later document revert.
+	if err := f.LoadJSON([]byte(`{"color": "red"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if f.Enabled("new-ui") {
+		t.Fatal("new-ui should revert to its default")
+	}
+}
@@ -0,0 +1,101 @@
+package latch
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrClosed is the context cause reported when a latch
+// closes with a Packet that carries no Err.
+var ErrClosed = errors.New("latch: closed")
+
+// Follow ties a latch and a context tree together,
+// in both directions:
+//
+// When ctx is done, l is closed (if it is open) with a
+// Packet whose Err is context.Cause(ctx).
+//
+// When l closes, the returned context is cancelled; its
+// context.Cause is the Packet's Err, or ErrClosed
+// if there was none; and CauseOf returns the Packet.
+//
+// Calling the returned CancelFunc cancels the derived
+// context and stops following, without closing l.
+func Follow(ctx context.Context, l *Latch) (context.Context, context.CancelFunc) {
+	cc := &closeCause{parent: causeIn(ctx)}
+	derived, cancel := context.WithCancelCause(context.WithValue(ctx, causeKey{}, cc))
+	goLabeled(RoleFollower, l.name, func() {
+		for {
+			cur, closed, _, next := l.observe()
+			if closed {
+				cancel(cc.set(cur))
+				return
+			}
+			select {
+			case <-next:
+			case <-derived.Done():
+				if ctx.Err() != nil {
+					// ctx is done, not merely our caller.
+					l.mut.Lock()
+					l.bcastIfOpen(&Packet{Err: context.Cause(ctx)})
+					l.mut.Unlock()
+				}
+				return
+			}
+		}
+	})
+	return derived, func() { cancel(context.Canceled) }
+}
+
+// CauseOf returns the Packet that a latch closed with, if
+// that is what ended ctx: ctx, or an ancestor, came from
+// Follow and was cancelled by the latch closing, or came
+// from Context and ran out of the latch's deadline. This
+// is the whole Packet, where context.Cause(ctx) has only
+// its Err. Otherwise, CauseOf returns nil.
+func CauseOf(ctx context.Context) *Packet {
+	if ctx.Err() == nil {
+		return nil
+	}
+	cause := context.Cause(ctx)
+	for cc := causeIn(ctx); cc != nil; cc = cc.parent {
+		if pak := cc.pak.Load(); pak != nil && errors.Is(cause, cc.err) {
+			return pak
+		}
+	}
+	return nil
+}
+
+// causeKey is the context key for a *closeCause.
+type causeKey struct{}
+
+// closeCause is the cause a latch gave, or will give, a
+// context that it cancels; parent is that of any latch
+// further up the context tree.
+type closeCause struct {
+	pak    atomic.Pointer[Packet]
+	err    error // written before pak.
+	parent *closeCause
+}
+
+// set records pak as the cause, returning its error.
+func (cc *closeCause) set(pak *Packet) error {
+	cc.err = causeOf(pak)
+	cc.pak.Store(pak)
+	return cc.err
+}
+
+func causeIn(ctx context.Context) *closeCause {
+	cc, _ := ctx.Value(causeKey{}).(*closeCause)
+	return cc
+}
+
+// causeOf is the context cause for a latch closed with
+// pak, which is nil after Bcast(nil).
+func causeOf(pak *Packet) error {
+	if pak != nil && pak.Err != nil {
+		return pak.Err
+	}
+	return ErrClosed
+}
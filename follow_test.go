@@ -0,0 +1,67 @@
+package latch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFollow(t *testing.T) {
+
+	// latch -> context, with cause.
+	latch := NewLatch(1)
+	ctx, cancel := Follow(context.Background(), latch)
+	defer cancel()
+
+	fatal := errors.New("disk on fire")
+	latch.Bcast(&Packet{Err: fatal})
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("closing the latch should cancel the derived context")
+	}
+	if context.Cause(ctx) != fatal {
+		t.Fatalf("cause should be the packet's Err, got %v", context.Cause(ctx))
+	}
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+	if pak := CauseOf(child); pak == nil || pak.Err != fatal {
+		t.Fatalf("CauseOf should find the packet, got %v", pak)
+	}
+
+	// context -> latch.
+	latch = NewLatch(1)
+	parent, stop := context.WithCancelCause(context.Background())
+	_, cancel2 := Follow(parent, latch)
+	defer cancel2()
+	sigterm := errors.New("SIGTERM")
+	stop(sigterm)
+	select {
+	case b := <-latch.Ch():
+		if b.Err != sigterm {
+			t.Fatalf("latch should carry the context's cause, got %v", b.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelling the parent should close the latch")
+	}
+	if CauseOf(parent) != nil {
+		t.Fatal("a context no latch cancelled has no CauseOf")
+	}
+}
+
+func TestFollowBcastNil(t *testing.T) {
+
+	latch := NewLatch(1)
+	ctx, cancel := Follow(context.Background(), latch)
+	defer cancel()
+	latch.Bcast(nil)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Bcast(nil) should cancel the derived context")
+	}
+	if context.Cause(ctx) != ErrClosed {
+		t.Fatalf("cause should be ErrClosed, got %v", context.Cause(ctx))
+	}
+}
@@ -0,0 +1,109 @@
+package latch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrInvalidTransition is returned (wrapped) by FSM.To
+// for a transition not in the FSM's table.
+var ErrInvalidTransition = errors.New("latch: invalid state transition")
+
+// FSM is a latch whose state is one of an enumerated
+// set, rather than just open or closed; e.g.
+// Starting -> Running -> Draining -> Stopped.
+// Only the transitions given to NewFSM are allowed.
+//
+// Each state change is broadcast on the FSM's latch (see
+// Latch()) as a Packet whose Item is the new state. In(s)
+// gives a select-friendly channel per state, and WaitFor
+// blocks until a given state is reached.
+type FSM[S comparable] struct {
+	mu      sync.Mutex
+	cur     S
+	allowed map[S]map[S]bool
+	in      map[S]chan struct{}
+	l       *Latch
+}
+
+// NewFSM makes a new FSM in state initial, whose latch has
+// a backing channel of size sz. transitions maps each
+// state to the states it may move to.
+func NewFSM[S comparable](sz int, initial S, transitions map[S][]S) *FSM[S] {
+	f := &FSM[S]{
+		cur:     initial,
+		allowed: make(map[S]map[S]bool),
+		in:      make(map[S]chan struct{}),
+		l:       NewLatch(sz),
+	}
+	for from, tos := range transitions {
+		f.allowed[from] = make(map[S]bool)
+		for _, to := range tos {
+			f.allowed[from][to] = true
+		}
+	}
+	f.l.Bcast(&Packet{Item: initial})
+	return f
+}
+
+// Latch returns the latch on which state
+// changes are broadcast.
+func (f *FSM[S]) Latch() *Latch {
+	return f.l
+}
+
+// State returns the current state.
+func (f *FSM[S]) State() S {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cur
+}
+
+// To moves the FSM to state s. It returns an error
+// wrapping ErrInvalidTransition, and changes nothing,
+// if the move is not allowed.
+func (f *FSM[S]) To(s S) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.allowed[f.cur][s] {
+		return fmt.Errorf("%w: %v -> %v", ErrInvalidTransition, f.cur, s)
+	}
+	// leaving; the next In(f.cur) gets a fresh channel.
+	delete(f.in, f.cur)
+	f.cur = s
+	if ch, ok := f.in[s]; ok {
+		close(ch)
+	}
+	f.l.Bcast(&Packet{Item: s})
+	return nil
+}
+
+// In returns a channel that is closed while the FSM
+// is in state s; once the FSM leaves s, In(s)
+// returns a fresh channel.
+func (f *FSM[S]) In(s S) <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch, ok := f.in[s]
+	if !ok {
+		ch = make(chan struct{})
+		if f.cur == s {
+			close(ch)
+		}
+		f.in[s] = ch
+	}
+	return ch
+}
+
+// WaitFor blocks until the FSM is in state s,
+// or ctx is done.
+func (f *FSM[S]) WaitFor(ctx context.Context, s S) error {
+	select {
+	case <-f.In(s):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
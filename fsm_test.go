@@ -0,0 +1,65 @@
+package latch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type lifecycle string
+
+const (
+	starting lifecycle = "starting"
+	running  lifecycle = "running"
+	draining lifecycle = "draining"
+	stopped  lifecycle = "stopped"
+)
+
+func TestFSM(t *testing.T) {
+
+	f := NewFSM(1, starting, map[lifecycle][]lifecycle{
+		starting: {running, stopped},
+		running:  {draining},
+		draining: {stopped},
+	})
+
+	if err := f.To(draining); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("starting -> draining should be refused, got %v", err)
+	}
+
+	inRunning := f.In(running)
+	waited := make(chan error)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		waited <- f.WaitFor(ctx, stopped)
+	}()
+
+	if err := f.To(running); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-inRunning:
+	default:
+		t.Fatal("In(running) should be closed once running")
+	}
+	if b := f.Latch().Peek(); b.Item != running {
+		t.Fatalf("latch should carry the new state, got %v", b.Item)
+	}
+
+	if err := f.To(draining); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-f.In(running):
+		t.Fatal("In(running) should be fresh after leaving running")
+	default:
+	}
+	if err := f.To(stopped); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-waited; err != nil {
+		t.Fatalf("WaitFor(stopped) failed: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package latch
+
+import "sync"
+
+// Gate adapts a latch to sync.Locker, for handing to
+// APIs that take one: Lock blocks while the latch is open,
+// and returns once it is closed. Unlock does nothing.
+//
+// Gate is not a mutex; any number of goroutines may
+// hold it at once. It is a way to pause (Clear) and
+// resume (Bcast) whole pools of workers that call
+// Lock at the top of each unit of work.
+type Gate struct {
+	l *Latch
+}
+
+var _ sync.Locker = (*Gate)(nil)
+
+// NewGate makes a Gate that is passable
+// whenever l is closed.
+func NewGate(l *Latch) *Gate {
+	return &Gate{l: l}
+}
+
+// Lock blocks until the gate's latch is closed.
+func (g *Gate) Lock() {
+	<-g.l.Done()
+}
+
+// Unlock does nothing; it exists to satisfy sync.Locker.
+// Lock takes nothing that needs giving back: passing the
+// gate leaves it as it was, for every other goroutine.
+// So there is no misuse to catch here, unlike a
+// sync.Mutex: an Unlock without a Lock, or a Lock
+// without an Unlock, is harmless. To shut the gate,
+// Clear its latch.
+func (g *Gate) Unlock() {}
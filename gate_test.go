@@ -0,0 +1,32 @@
+package latch
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGate(t *testing.T) {
+
+	latch := NewLatch(1)
+	var locker sync.Locker = NewGate(latch)
+
+	passed := make(chan bool)
+	go func() {
+		locker.Lock()
+		locker.Unlock()
+		passed <- true
+	}()
+
+	select {
+	case <-passed:
+		t.Fatal("Lock should block while the latch is open")
+	case <-time.After(20 * time.Millisecond):
+	}
+	latch.Bcast(&Packet{Item: "go"})
+	select {
+	case <-passed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Lock should return once the latch closes")
+	}
+}
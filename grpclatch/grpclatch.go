@@ -0,0 +1,316 @@
+/*
+Package grpclatch serves the latches of a latch.Registry
+over gRPC, and mirrors them into local latches on the
+client side; for cluster-wide broadcast of the latest
+value. See latch.proto for the service definition.
+
+Messages are carried with a JSON codec, registered under
+the gRPC content-subtype "json", so no generated code is
+required. The Packets inside them are encoded with a
+latch.Codec, as for persistence and the ipc package, so
+Items of types given to latch.RegisterType arrive at
+mirrors as those types.
+*/
+package grpclatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/glycerine/latch"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+// WatchRequest asks to follow the named latch.
+type WatchRequest struct {
+	Name string `json:"name"`
+}
+
+// SetRequest closes the named latch with Packet,
+// as encoded by the latch.Codec.
+type SetRequest struct {
+	Name   string `json:"name"`
+	Packet []byte `json:"packet,omitempty"`
+}
+
+// OpenRequest clears the named latch.
+type OpenRequest struct {
+	Name string `json:"name"`
+}
+
+// State describes a latch; Packet is its value, as
+// encoded by the latch.Codec, if it is closed.
+type State struct {
+	Name    string `json:"name"`
+	Closed  bool   `json:"closed"`
+	Version uint64 `json:"version"`
+	Packet  []byte `json:"packet,omitempty"`
+}
+
+const serviceName = "latch.Latch"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec lets us skip protobuf generated code.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return "json" }
+
+// LatchServer is the server API for the Latch service.
+type LatchServer interface {
+	Watch(*WatchRequest, grpc.ServerStream) error
+	Set(context.Context, *SetRequest) (*State, error)
+	Open(context.Context, *OpenRequest) (*State, error)
+}
+
+// Server implements the Latch service over the
+// latches registered in Reg, encoding Packets
+// with Codec.
+type Server struct {
+	Reg   *latch.Registry
+	Codec latch.Codec
+}
+
+// NewServer makes a new Server for reg. Its clients
+// must use the same codec.
+func NewServer(reg *latch.Registry, codec latch.Codec) *Server {
+	return &Server{Reg: reg, Codec: codec}
+}
+
+// Register adds srv to the grpc.Server s.
+func Register(s *grpc.Server, srv LatchServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+func (s *Server) lookup(name string) (*latch.Latch, error) {
+	l := s.Reg.Get(name)
+	if l == nil {
+		return nil, status.Errorf(codes.NotFound, "no latch named %q", name)
+	}
+	return l, nil
+}
+
+// Watch streams the named latch's current
+// state, then each transition.
+func (s *Server) Watch(req *WatchRequest, stream grpc.ServerStream) error {
+	l, err := s.lookup(req.Name)
+	if err != nil {
+		return err
+	}
+	first := true
+	var seen uint64
+	for {
+		cur, closed, version, next := l.ObserveClosed()
+		if first || version != seen {
+			first = false
+			seen = version
+			st, err := s.stateOf(req.Name, cur, closed, version)
+			if err != nil {
+				return err
+			}
+			if err := stream.SendMsg(st); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-next:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// Set closes the named latch.
+func (s *Server) Set(ctx context.Context, req *SetRequest) (*State, error) {
+	l, err := s.lookup(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	pak, err := s.Codec.Decode(req.Packet)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "bad packet: %v", err)
+	}
+	l.Bcast(pak)
+	cur, closed, version, _ := l.ObserveClosed()
+	return s.stateOf(req.Name, cur, closed, version)
+}
+
+// Open clears the named latch.
+func (s *Server) Open(ctx context.Context, req *OpenRequest) (*State, error) {
+	l, err := s.lookup(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	l.Clear()
+	cur, closed, version, _ := l.ObserveClosed()
+	return s.stateOf(req.Name, cur, closed, version)
+}
+
+// stateOf describes a latch's state; a latch closed with
+// a nil Packet, as by Bcast(nil), is Closed with no Packet.
+func (s *Server) stateOf(name string, cur *latch.Packet, closed bool, version uint64) (*State, error) {
+	st := &State{Name: name, Closed: closed, Version: version}
+	if cur == nil {
+		return st, nil
+	}
+	b, err := s.Codec.Encode(cur)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot encode packet: %v", err)
+	}
+	st.Packet = b
+	return st, nil
+}
+
+// Client calls a remote Latch service.
+type Client struct {
+	cc    grpc.ClientConnInterface
+	codec latch.Codec
+}
+
+// NewClient makes a new Client using cc, encoding
+// Packets with codec, as the Server does.
+func NewClient(cc grpc.ClientConnInterface, codec latch.Codec) *Client {
+	return &Client{cc: cc, codec: codec}
+}
+
+// Set closes the remote latch name with a Packet
+// of item and (if non-nil) err.
+func (c *Client) Set(ctx context.Context, name string, item interface{}, err error) (*State, error) {
+	pak := &latch.Packet{Item: item, Err: err}
+	b, err := c.codec.Encode(pak)
+	if err != nil {
+		return nil, err
+	}
+	req := &SetRequest{Name: name, Packet: b}
+	out := new(State)
+	if err := c.cc.Invoke(ctx, "/"+serviceName+"/Set", req, out, grpc.CallContentSubtype("json")); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Open clears the remote latch name.
+func (c *Client) Open(ctx context.Context, name string) (*State, error) {
+	out := new(State)
+	if err := c.cc.Invoke(ctx, "/"+serviceName+"/Open", &OpenRequest{Name: name}, out, grpc.CallContentSubtype("json")); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Mirror follows the remote latch name, returning a
+// local latch, with a backing channel of size sz, that
+// tracks it until ctx is cancelled or the stream fails.
+// The first state is received before Mirror returns,
+// so errors such as an unknown name are reported here.
+func (c *Client) Mirror(ctx context.Context, name string, sz int) (*latch.Latch, error) {
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0], "/"+serviceName+"/Watch", grpc.CallContentSubtype("json"))
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(&WatchRequest{Name: name}); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	l := latch.NewLatch(sz)
+	st := new(State)
+	if err := stream.RecvMsg(st); err != nil {
+		return nil, err
+	}
+	if err := c.apply(l, st); err != nil {
+		return nil, err
+	}
+	latch.Go(ctx, latch.RoleMirror, name, func(context.Context) {
+		for {
+			st := new(State)
+			if err := stream.RecvMsg(st); err != nil {
+				return
+			}
+			c.apply(l, st)
+		}
+	})
+	return l, nil
+}
+
+// apply copies a remote State into the local latch l.
+// A Packet that won't decode closes l with the error,
+// rather than passing on a value that isn't the
+// remote's; apply returns it too.
+func (c *Client) apply(l *latch.Latch, st *State) error {
+	if !st.Closed {
+		l.Clear()
+		return nil
+	}
+	if len(st.Packet) == 0 {
+		l.Bcast(nil)
+		return nil
+	}
+	pak, err := c.codec.Decode(st.Packet)
+	if err != nil {
+		err = fmt.Errorf("grpclatch: cannot decode %q: %w", st.Name, err)
+		pak = &latch.Packet{Err: err}
+	}
+	l.Bcast(pak)
+	return err
+}
+
+// serviceDesc is what protoc-gen-go-grpc would
+// generate from latch.proto.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*LatchServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Set", Handler: setHandler},
+		{MethodName: "Open", Handler: openHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Watch", Handler: watchHandler, ServerStreams: true},
+	},
+	Metadata: "latch.proto",
+}
+
+func setHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LatchServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/Set"}
+	return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LatchServer).Set(ctx, req.(*SetRequest))
+	})
+}
+
+func openHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LatchServer).Open(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/Open"}
+	return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LatchServer).Open(ctx, req.(*OpenRequest))
+	})
+}
+
+func watchHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(WatchRequest)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(LatchServer).Watch(in, stream)
+}
@@ -0,0 +1,91 @@
+package grpclatch
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/glycerine/latch"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type window struct{ From, To int }
+
+func init() {
+	latch.RegisterType("grpclatch_test.window", window{})
+}
+
+func TestMirror(t *testing.T) {
+
+	reg := latch.NewRegistry()
+	maint := latch.NewLatch(1)
+	reg.Register("maintenance", maint)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer()
+	Register(gs, NewServer(reg, latch.JSONCodec{}))
+	go gs.Serve(ln)
+	defer gs.Stop()
+
+	cc, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	c := NewClient(cc, latch.JSONCodec{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mirror, err := c.Mirror(ctx, "maintenance", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mirror.IsClosed() {
+		t.Fatal("mirror should start open, like the remote latch")
+	}
+
+	if _, err := c.Set(ctx, "maintenance", "read-only", nil); err != nil {
+		t.Fatal(err)
+	}
+	if pak := maint.Peek(); pak == nil || pak.Item != "read-only" {
+		t.Fatal("Set should close the server-side latch")
+	}
+	select {
+	case pak := <-mirror.Ch():
+		if pak.Item != "read-only" {
+			t.Fatalf("mirror got %v", pak.Item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("mirror never saw the Set")
+	}
+
+	// registered types round-trip as themselves.
+	if _, err := c.Set(ctx, "maintenance", window{From: 1, To: 2}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mirror.WaitFor(ctx, func(p *latch.Packet) bool {
+		return p != nil && p.Item == window{From: 1, To: 2}
+	}); err != nil {
+		t.Fatalf("mirror never saw the typed Item: %v", err)
+	}
+
+	// a close with nil shows as closed, not open.
+	maint.Bcast(nil)
+	deadline := time.Now().Add(5 * time.Second)
+	for mirror.Peek() != nil || !mirror.IsClosed() {
+		if time.Now().After(deadline) {
+			t.Fatal("mirror never saw the Bcast(nil)")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := c.Mirror(ctx, "nonesuch", 1); err == nil {
+		t.Fatal("mirroring an unknown latch should fail")
+	}
+}
@@ -0,0 +1,133 @@
+package latch
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// Health composes named latches into Kubernetes-style
+// readiness and liveness probes.
+//
+// A readiness latch is satisfied once it is closed
+// with a nil Err ("db-ready", "cache-warm", ...).
+// A liveness latch signals death by being closed with
+// a non-nil Err; a Watchdog is the natural fit.
+type Health struct {
+	mu    sync.Mutex
+	ready []healthCheck
+	live  []healthCheck
+}
+
+type healthCheck struct {
+	name     string
+	l        *Latch
+	required bool
+}
+
+// NewHealth makes a new Health with no checks.
+// With no checks, it is both live and ready.
+func NewHealth() *Health {
+	return &Health{}
+}
+
+// AddReady adds a readiness latch. Only required latches
+// gate Ready(); the rest are merely reported.
+func (h *Health) AddReady(name string, l *Latch, required bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ready = append(h.ready, healthCheck{name: name, l: l, required: required})
+}
+
+// AddLive adds a liveness latch.
+func (h *Health) AddLive(name string, l *Latch) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.live = append(h.live, healthCheck{name: name, l: l, required: true})
+}
+
+// Ready returns true when every required readiness
+// latch is closed with a nil Err.
+func (h *Health) Ready() bool {
+	ok, _ := probe(h.readyChecks(), readyStatus)
+	return ok
+}
+
+// Live returns true unless some liveness latch
+// has been closed with a non-nil Err.
+func (h *Health) Live() bool {
+	ok, _ := probe(h.liveChecks(), liveStatus)
+	return ok
+}
+
+// Handler returns an http.Handler serving /healthz
+// (liveness) and /readyz (readiness). Each answers
+// 200 or 503, with a JSON body giving the status of
+// every latch behind the probe.
+func (h *Health) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		serveProbe(w, h.liveChecks(), liveStatus)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		serveProbe(w, h.readyChecks(), readyStatus)
+	})
+	return mux
+}
+
+func (h *Health) readyChecks() []healthCheck {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]healthCheck(nil), h.ready...)
+}
+
+func (h *Health) liveChecks() []healthCheck {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]healthCheck(nil), h.live...)
+}
+
+// readyStatus describes one readiness latch, with value
+// pak if closed.
+func readyStatus(pak *Packet, closed bool) (ok bool, status string) {
+	switch {
+	case !closed:
+		return false, "waiting"
+	case pak != nil && pak.Err != nil:
+		return false, pak.Err.Error()
+	}
+	return true, "ok"
+}
+
+// liveStatus describes one liveness latch, with value
+// pak if closed.
+func liveStatus(pak *Packet, closed bool) (ok bool, status string) {
+	if pak != nil && pak.Err != nil {
+		return false, pak.Err.Error()
+	}
+	return true, "ok"
+}
+
+// probe evaluates checks, returning whether all the
+// required ones pass, and a status for each by name.
+func probe(checks []healthCheck, status func(*Packet, bool) (bool, string)) (bool, map[string]string) {
+	all := true
+	report := make(map[string]string, len(checks))
+	for _, c := range checks {
+		ok, s := status(c.l.peek())
+		if !ok && c.required {
+			all = false
+		}
+		report[c.name] = s
+	}
+	return all, report
+}
+
+func serveProbe(w http.ResponseWriter, checks []healthCheck, status func(*Packet, bool) (bool, string)) {
+	ok, report := probe(checks, status)
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,72 @@
+package latch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+
+	db := NewLatch(1)
+	cache := NewLatch(1)
+	wd := NewLatch(1)
+
+	h := NewHealth()
+	h.AddReady("db-ready", db, true)
+	h.AddReady("cache-warm", cache, false)
+	h.AddLive("watchdog", wd)
+
+	if h.Ready() {
+		t.Fatal("should not be ready before db-ready closes")
+	}
+	if !h.Live() {
+		t.Fatal("should be live until a liveness latch fails")
+	}
+
+	db.Bcast(&Packet{Err: errors.New("connect refused")})
+	if h.Ready() {
+		t.Fatal("a readiness latch closed with an Err is not ready")
+	}
+	db.Bcast(&Packet{})
+	if !h.Ready() {
+		t.Fatal("optional cache-warm should not gate readiness")
+	}
+
+	srv := httptest.NewServer(h.Handler())
+	defer srv.Close()
+
+	expectCode(t, srv.URL+"/readyz", http.StatusOK)
+	expectCode(t, srv.URL+"/healthz", http.StatusOK)
+
+	wd.Bcast(&Packet{Err: ErrWatchdogTimeout})
+	if h.Live() {
+		t.Fatal("liveness latch closed with an Err should fail Live")
+	}
+	expectCode(t, srv.URL+"/healthz", http.StatusServiceUnavailable)
+}
+
+func expectCode(t *testing.T, url string, code int) {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != code {
+		t.Fatalf("GET %v: expected %v, got %v", url, code, resp.StatusCode)
+	}
+}
+
+func TestHealthBcastNil(t *testing.T) {
+
+	db := NewLatch(1)
+	h := NewHealth()
+	h.AddReady("db-ready", db, true)
+	h.AddLive("watchdog", db)
+
+	db.Bcast(nil)
+	if !h.Ready() || !h.Live() {
+		t.Fatal("a latch closed with nil should be ready, and live")
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Package httpadmin provides an http.Handler that
+exposes the latches in a latch.Registry as JSON,
+and optionally lets operators close and open them
+with a POST; for flipping feature or shutdown
+latches without a redeploy.
+
+Routes, relative to wherever the Handler is mounted
+(use http.StripPrefix when mounting under a path):
+
+	GET  /         list the state of every registered latch
+	GET  /{name}   state of one latch
+	POST /{name}   op=close&item=...  or  op=open
+
+POSTs are refused unless Authorize is set and
+approves the request. A POST the latch itself refuses
+(by WithWriteOnce, its write policy or a veto) is
+answered 409 Conflict.
+*/
+package httpadmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/glycerine/latch"
+)
+
+// LatchState is the JSON description of one latch.
+type LatchState struct {
+	Name    string `json:"name"`
+	Closed  bool   `json:"closed"`
+	Version uint64 `json:"version"`
+	Item    string `json:"item,omitempty"`
+	Err     string `json:"err,omitempty"`
+
+	// Stats, for latches kept WithStats.
+	Stats *StatsState `json:"stats,omitempty"`
+}
+
+// StatsState is the JSON description of a latch's
+// latch.Stats; times are in seconds.
+type StatsState struct {
+	Open        float64 `json:"open"`
+	Closed      float64 `json:"closed"`
+	DutyCycle   float64 `json:"duty_cycle"`
+	Transitions uint64  `json:"transitions"`
+	Waits       uint64  `json:"waits"`
+	MeanWait    float64 `json:"mean_wait"`
+}
+
+// Handler serves the latches in Reg.
+type Handler struct {
+	Reg *latch.Registry
+
+	// Authorize, if non-nil, is consulted on every POST;
+	// return false to reject it. When Authorize is nil
+	// the Handler is read-only.
+	Authorize func(r *http.Request) bool
+}
+
+// NewHandler makes a new, read-only Handler for reg.
+// Set Authorize to allow transitions.
+func NewHandler(reg *latch.Registry) *Handler {
+	return &Handler{Reg: reg}
+}
+
+// StateOf describes l, registered as name.
+func StateOf(name string, l *latch.Latch) LatchState {
+	pak, closed, version, _ := l.ObserveClosed()
+	s := LatchState{
+		Name:    name,
+		Closed:  closed,
+		Version: version,
+	}
+	if pak != nil {
+		if pak.Item != nil {
+			s.Item = fmt.Sprint(pak.Item)
+		}
+		if pak.Err != nil {
+			s.Err = pak.Err.Error()
+		}
+	}
+	if st, ok := l.Stats(); ok {
+		s.Stats = &StatsState{
+			Open:        st.Open.Seconds(),
+			Closed:      st.Closed.Seconds(),
+			DutyCycle:   st.DutyCycle(),
+			Transitions: st.Transitions,
+			Waits:       st.Waits,
+			MeanWait:    st.MeanWait().Seconds(),
+		}
+	}
+	return s
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(r.URL.Path, "/")
+
+	switch r.Method {
+	case "GET":
+		if name == "" {
+			names := h.Reg.Names()
+			states := make([]LatchState, 0, len(names))
+			for _, n := range names {
+				if l := h.Reg.Get(n); l != nil {
+					states = append(states, StateOf(n, l))
+				}
+			}
+			writeJSON(w, states)
+			return
+		}
+		l := h.Reg.Get(name)
+		if l == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, StateOf(name, l))
+
+	case "POST":
+		if h.Authorize == nil || !h.Authorize(r) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		l := h.Reg.Get(name)
+		if l == nil {
+			http.NotFound(w, r)
+			return
+		}
+		var err error
+		switch op := r.FormValue("op"); op {
+		case "close":
+			err = l.BcastErr(&latch.Packet{Item: r.FormValue("item")})
+		case "open":
+			err = l.ClearErr()
+		default:
+			http.Error(w, fmt.Sprintf("unknown op %q; want close or open", op), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, StateOf(name, l))
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
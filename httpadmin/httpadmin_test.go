@@ -0,0 +1,116 @@
+package httpadmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/glycerine/latch"
+)
+
+func TestHandler(t *testing.T) {
+
+	reg := latch.NewRegistry()
+	shutdown := latch.NewLatch(1)
+	reg.Register("shutdown", shutdown)
+
+	h := NewHandler(reg)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	var states []LatchState
+	getJSON(t, srv.URL+"/", &states)
+	if len(states) != 1 || states[0].Name != "shutdown" || states[0].Closed {
+		t.Fatalf("unexpected listing: %#v", states)
+	}
+
+	form := url.Values{"op": {"close"}, "item": {"maintenance"}}
+	resp, err := http.PostForm(srv.URL+"/shutdown", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("read-only handler should refuse POST, got %v", resp.Status)
+	}
+
+	h.Authorize = func(r *http.Request) bool { return true }
+	resp, err = http.PostForm(srv.URL+"/shutdown", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("authorized POST failed: %v", resp.Status)
+	}
+
+	var st LatchState
+	getJSON(t, srv.URL+"/shutdown", &st)
+	if !st.Closed || st.Item != "maintenance" {
+		t.Fatalf("expected closed with item maintenance, got %#v", st)
+	}
+	if b := shutdown.Peek(); b == nil || b.Item != "maintenance" {
+		t.Fatal("POST op=close should have closed the latch")
+	}
+}
+
+func getJSON(t *testing.T, u string, v interface{}) {
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStateOfStats(t *testing.T) {
+	if StateOf("plain", latch.NewLatch(1)).Stats != nil {
+		t.Fatal("no stats without WithStats")
+	}
+	l := latch.NewLatch(1, latch.WithStats())
+	l.Bcast(&latch.Packet{})
+	s := StateOf("counted", l)
+	if s.Stats == nil || s.Stats.Transitions != 1 {
+		t.Fatalf("got %+v", s.Stats)
+	}
+}
+
+func TestHandlerRefused(t *testing.T) {
+
+	reg := latch.NewRegistry()
+	once := latch.NewLatch(1, latch.WithWriteOnce())
+	reg.Register("once", once)
+
+	h := NewHandler(reg)
+	h.Authorize = func(r *http.Request) bool { return true }
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	for i, want := range []int{http.StatusOK, http.StatusConflict} {
+		form := url.Values{"op": {"close"}, "item": {fmt.Sprint(i)}}
+		resp, err := http.PostForm(srv.URL+"/once", form)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("POST %v: expected %v, got %v", i, want, resp.Status)
+		}
+	}
+	if once.Peek().Item != "0" {
+		t.Fatal("a refused POST should leave the latch be")
+	}
+}
+
+func TestStateOfBcastNil(t *testing.T) {
+	l := latch.NewLatch(1)
+	l.Bcast(nil)
+	if s := StateOf("nil", l); !s.Closed || s.Version != 1 {
+		t.Fatalf("got %+v", s)
+	}
+}
@@ -0,0 +1,52 @@
+package latch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ServeUntil runs srv.ListenAndServe until l closes, then
+// shuts srv down gracefully: it stops accepting, and
+// waits for active connections to finish, for up to
+// drainTimeout, after which any that remain are closed.
+// It returns once all connections are finished, with nil
+// after a graceful shutdown, context.DeadlineExceeded if
+// connections had to be cut off, or the error with which
+// srv failed to serve in the first place. Hijacked
+// connections, such as websockets, are not waited for.
+func ServeUntil(l *Latch, srv *http.Server, drainTimeout time.Duration) error {
+	pak, _ := ServeUntilDone(l, srv, drainTimeout).Read(context.Background())
+	return pak.Err
+}
+
+// ServeUntilDone is ServeUntil, without the wait: it
+// returns a done latch (with a sz of 1) which is closed,
+// with a Packet whose Err is what ServeUntil would
+// return, when all connections are finished.
+func ServeUntilDone(l *Latch, srv *http.Server, drainTimeout time.Duration) (done *Latch) {
+	done = NewLatch(1)
+	errc := make(chan error, 1)
+	// not labeled: srv's handlers would inherit it.
+	go func() { errc <- srv.ListenAndServe() }()
+	goLabeled(RoleFollower, l.name, func() {
+		select {
+		case err := <-errc:
+			done.Bcast(&Packet{Err: err})
+			return
+		case <-l.Done():
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		defer cancel()
+		err := srv.Shutdown(ctx)
+		if err != nil {
+			srv.Close()
+		}
+		if serr := <-errc; !errors.Is(serr, http.ErrServerClosed) && err == nil {
+			err = serr
+		}
+		done.Bcast(&Packet{Err: err})
+	})
+	return done
+}
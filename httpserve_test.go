@@ -0,0 +1,83 @@
+package latch
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeUntil(t *testing.T) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	inHandler := make(chan struct{})
+	release := make(chan struct{})
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(inHandler)
+			<-release
+		}),
+	}
+	stop := NewLatch(1)
+	done := ServeUntilDone(stop, srv, time.Minute)
+
+	got := make(chan error)
+	go func() {
+		var resp *http.Response
+		var err error
+		for i := 0; i < 100; i++ {
+			if resp, err = http.Get("http://" + addr); err == nil {
+				resp.Body.Close()
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		got <- err
+	}()
+	<-inHandler
+
+	stop.Bcast(&Packet{})
+	time.Sleep(20 * time.Millisecond)
+	if done.IsClosed() {
+		t.Fatal("done before the request finished")
+	}
+	close(release)
+	if err := <-got; err != nil {
+		t.Fatal(err)
+	}
+	pak, err := done.Read(context.Background())
+	if err != nil || pak.Err != nil {
+		t.Fatalf("graceful shutdown got %v, %v", pak, err)
+	}
+
+	// cut off after the drain timeout.
+	inHandler = make(chan struct{})
+	release = make(chan struct{})
+	defer close(release)
+	srv = &http.Server{Addr: addr, Handler: srv.Handler}
+	stop = NewLatch(1)
+	errc := make(chan error)
+	go func() { errc <- ServeUntil(stop, srv, 10*time.Millisecond) }()
+	go func() {
+		for i := 0; i < 100; i++ {
+			if resp, err := http.Get("http://" + addr); err == nil {
+				resp.Body.Close()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	<-inHandler
+	stop.Bcast(&Packet{})
+	if err := <-errc; err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+}
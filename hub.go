@@ -0,0 +1,368 @@
+package latch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Hub broadcasts like a Latch, but gives each subscriber
+// its own channel. Each subscriber's channel holds at most
+// the latest Update, so a slow subscriber never blocks the
+// publisher and never sees stale values queue up; there is
+// nothing to Refresh. Because the Hub knows its
+// subscribers, it can also track delivery: see
+// SubscribeAcked and WaitDelivered.
+type Hub struct {
+	mu      sync.Mutex
+	cur     *Packet // nil when open.
+	version uint64
+	subs    map[*Subscription]struct{}
+
+	// acked is closed, and replaced, whenever an
+	// acknowledgement or unsubscribe might let a
+	// WaitDelivered return.
+	acked chan struct{}
+
+	// slow subscriber policy, see SetSlowPolicy.
+	slow     SlowPolicy
+	slowStop chan struct{}
+
+	// clk, if set by SetClock, times slow subscribers.
+	clk Clock
+}
+
+// Update is one transition: as delivered to a Hub
+// subscriber, or as recorded by WithHistory.
+type Update struct {
+	// Version counts the transitions; for a Hub, pass it
+	// to Subscription.Ack once the Update has been handled.
+	Version uint64
+
+	// Packet is the new value, or nil if the
+	// Hub (or latch) was opened (Clear-ed).
+	Packet *Packet
+}
+
+// Subscription is one subscriber's view of a Hub.
+type Subscription struct {
+	hub   *Hub
+	label string
+	ch    chan Update
+	acks  bool
+	ack   uint64 // highest Version acknowledged.
+
+	// since is when the subscriber last fell behind:
+	// an Update delivered to it but not yet received
+	// (or, if acked, not yet acknowledged). Zero
+	// when caught up.
+	since time.Time
+}
+
+// NewHub makes a new, open, Hub with no subscribers.
+func NewHub() *Hub {
+	return &Hub{
+		subs:  make(map[*Subscription]struct{}),
+		acked: make(chan struct{}),
+	}
+}
+
+// Subscribe adds a subscriber. If the Hub is closed, its
+// current value is waiting on the Subscription's channel.
+// label identifies the subscriber in diagnostics.
+func (h *Hub) Subscribe(label string) *Subscription {
+	return h.subscribe(label, false)
+}
+
+// SubscribeAcked adds a subscriber that promises to Ack
+// each Update it handles; WaitDelivered waits for
+// such subscribers.
+func (h *Hub) SubscribeAcked(label string) *Subscription {
+	return h.subscribe(label, true)
+}
+
+func (h *Hub) subscribe(label string, acks bool) *Subscription {
+	s := &Subscription{
+		hub:   h,
+		label: label,
+		ch:    make(chan Update, 1),
+		acks:  acks,
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[s] = struct{}{}
+	if h.cur != nil {
+		s.ch <- Update{Version: h.version, Packet: h.cur}
+	} else {
+		// nothing to deliver, so nothing to acknowledge.
+		s.ack = h.version
+	}
+	return s
+}
+
+// Bcast closes the Hub with pak, delivering it to
+// every subscriber.
+//
+// Bcast loads every subscriber's channel before it
+// returns. That is the Hub's happens-before edge: once
+// Bcast has returned, or a Version call has returned
+// its version or later, any receive by any subscriber
+// yields that Update or a newer one (or finds nothing
+// new, if the subscriber already took it). So a
+// publisher can signal onward straight after Bcast, or
+// another goroutine can check Version, with no further
+// synchronization.
+func (h *Hub) Bcast(pak *Packet) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cur = pak
+	h.publish()
+}
+
+// Clear opens the Hub. Subscribers are sent an
+// Update with a nil Packet.
+func (h *Hub) Clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cur = nil
+	h.publish()
+}
+
+// publish delivers the current value to every
+// subscriber, replacing anything they have not yet
+// received. Caller must hold h.mu.
+func (h *Hub) publish() {
+	h.version++
+	u := Update{Version: h.version, Packet: h.cur}
+	for s := range h.subs {
+		s.deliver(u)
+	}
+}
+
+// deliver replaces any pending Update with u.
+// Caller must hold s.hub.mu.
+func (s *Subscription) deliver(u Update) {
+	if s.caughtUp(u.Version - 1) {
+		s.since = orReal(s.hub.clk).Now()
+	}
+	select {
+	case <-s.ch:
+	default:
+	}
+	s.ch <- u
+}
+
+// caughtUp reports whether the subscriber has handled
+// everything up to version. Caller must hold s.hub.mu.
+func (s *Subscription) caughtUp(version uint64) bool {
+	return len(s.ch) == 0 && (!s.acks || s.ack >= version)
+}
+
+// Version returns the count of Bcast and Clear calls.
+// Every Update up to it has been loaded into every
+// subscriber's channel; see Bcast.
+func (h *Hub) Version() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.version
+}
+
+// Sync returns once every subscriber's channel has been
+// loaded with the Hub's current value (or the subscriber
+// has already received it), and returns that value's
+// Version: after Sync returns, any receive by any
+// subscriber yields that Update or a newer one.
+//
+// Bcast and Clear load every channel before they return,
+// so Sync only waits out any in progress; it is Version,
+// under a name that says what the caller relies on. Use
+// it where one goroutine publishes, and another must be
+// sure the value has gone out before signalling onward.
+func (h *Hub) Sync() uint64 {
+	return h.Version()
+}
+
+// WaitDelivered blocks until every current acked
+// subscriber has acknowledged version (or later), or
+// ctx is done. It lets a publisher know, e.g., that new
+// config has been applied everywhere before proceeding.
+func (h *Hub) WaitDelivered(ctx context.Context, version uint64) error {
+	for {
+		h.mu.Lock()
+		all := true
+		for s := range h.subs {
+			if s.acks && s.ack < version {
+				all = false
+				break
+			}
+		}
+		next := h.acked
+		h.mu.Unlock()
+		if all {
+			return nil
+		}
+		select {
+		case <-next:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// wakeAcked wakes WaitDelivered callers.
+// Caller must hold h.mu.
+func (h *Hub) wakeAcked() {
+	close(h.acked)
+	h.acked = make(chan struct{})
+}
+
+// Ch returns the subscriber's channel. It holds at
+// most one Update: the latest not yet received. It is
+// closed once the subscriber is unsubscribed, or
+// evicted as slow.
+func (s *Subscription) Ch() <-chan Update {
+	return s.ch
+}
+
+// Label returns the label given at Subscribe.
+func (s *Subscription) Label() string {
+	return s.label
+}
+
+// Ack records that the subscriber has handled
+// every Update up to and including version.
+func (s *Subscription) Ack(version uint64) {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if version > s.ack {
+		s.ack = version
+		if s.caughtUp(h.version) {
+			s.since = time.Time{}
+		}
+		h.wakeAcked()
+	}
+}
+
+// Unsubscribe removes the subscriber from the Hub.
+// Its channel receives no further Updates, and is
+// closed, once any Update pending on it is received.
+func (s *Subscription) Unsubscribe() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.remove(s) {
+		h.wakeAcked()
+	}
+}
+
+// remove drops s from the Hub, closing its channel;
+// it returns false if s was already gone.
+// Caller must hold h.mu.
+func (h *Hub) remove(s *Subscription) bool {
+	if _, ok := h.subs[s]; !ok {
+		return false
+	}
+	delete(h.subs, s)
+	close(s.ch)
+	return true
+}
+
+// SlowPolicy says what a Hub does about subscribers
+// that fall behind.
+type SlowPolicy struct {
+	// Deadline is how long a subscriber may leave an
+	// Update unreceived (or, for acked subscribers,
+	// unacknowledged) before it is deemed slow.
+	Deadline time.Duration
+
+	// OnSlow, if non-nil, is called with the slow
+	// subscribers each time some are found. It is
+	// called without the Hub's lock held.
+	OnSlow func(slow []*Subscription)
+
+	// Evict unsubscribes slow subscribers, so one stuck
+	// goroutine can't stall WaitDelivered forever. An
+	// evicted subscriber finds its channel closed.
+	Evict bool
+}
+
+// SetSlowPolicy starts checking for slow subscribers,
+// every half Deadline, according to p; replacing any
+// previous policy. A zero Deadline stops checking.
+func (h *Hub) SetSlowPolicy(p SlowPolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopSlowCheck()
+	h.slow = p
+	if p.Deadline <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	h.slowStop = stop
+	tick := orReal(h.clk).NewTicker(p.Deadline / 2)
+	goLabeled(RoleHub, "", func() {
+		defer tick.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-tick.C():
+				h.checkSlow()
+			}
+		}
+	})
+}
+
+// SetClock makes slow subscriber checking use c, as
+// WithClock does for a Latch. Call it before
+// SetSlowPolicy.
+func (h *Hub) SetClock(c Clock) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clk = c
+}
+
+// Stop ends any slow subscriber checking.
+func (h *Hub) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopSlowCheck()
+}
+
+// stopSlowCheck. Caller must hold h.mu.
+func (h *Hub) stopSlowCheck() {
+	if h.slowStop != nil {
+		close(h.slowStop)
+		h.slowStop = nil
+	}
+}
+
+// checkSlow finds, reports, and perhaps evicts
+// slow subscribers.
+func (h *Hub) checkSlow() {
+	h.mu.Lock()
+	p := h.slow
+	now := orReal(h.clk).Now()
+	var slow []*Subscription
+	for s := range h.subs {
+		if s.caughtUp(h.version) {
+			s.since = time.Time{}
+			continue
+		}
+		if !s.since.IsZero() && now.Sub(s.since) > p.Deadline {
+			slow = append(slow, s)
+		}
+	}
+	if p.Evict && len(slow) > 0 {
+		for _, s := range slow {
+			h.remove(s)
+		}
+		h.wakeAcked()
+	}
+	h.mu.Unlock()
+
+	if len(slow) > 0 && p.OnSlow != nil {
+		p.OnSlow(slow)
+	}
+}
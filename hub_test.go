@@ -0,0 +1,219 @@
+package latch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHubWaitDelivered(t *testing.T) {
+
+	h := NewHub()
+	fast := h.SubscribeAcked("fast")
+	slow := h.SubscribeAcked("slow")
+	h.Subscribe("no-acks") // never acks; must not hold anybody up.
+
+	h.Bcast(&Packet{Item: "v1"})
+	v2 := &Packet{Item: "v2"}
+	h.Bcast(v2)
+	version := h.Version()
+
+	// subscribers only see the latest.
+	u := <-fast.Ch()
+	if u.Packet != v2 || u.Version != version {
+		t.Fatalf("expected only the latest update, got %#v", u)
+	}
+	fast.Ack(u.Version)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.WaitDelivered(ctx, version); err != context.DeadlineExceeded {
+		t.Fatalf("slow has not acked, so WaitDelivered should time out; got %v", err)
+	}
+
+	go func() {
+		u := <-slow.Ch()
+		slow.Ack(u.Version)
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.WaitDelivered(ctx, version); err != nil {
+		t.Fatalf("all acked subscribers have acked, got %v", err)
+	}
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+
+	h := NewHub()
+	defer h.Stop()
+	stuck := h.SubscribeAcked("stuck") // never receives, until evicted.
+	ok := h.SubscribeAcked("ok")
+	defer ok.Unsubscribe()
+
+	reported := make(chan []*Subscription, 1)
+	h.SetSlowPolicy(SlowPolicy{
+		Deadline: 10 * time.Millisecond,
+		OnSlow: func(slow []*Subscription) {
+			select {
+			case reported <- slow:
+			default:
+			}
+		},
+		Evict: true,
+	})
+
+	go func() {
+		for u := range ok.Ch() {
+			ok.Ack(u.Version)
+		}
+	}()
+	h.Bcast(&Packet{Item: "v1"})
+
+	select {
+	case slow := <-reported:
+		if len(slow) != 1 || slow[0].Label() != "stuck" {
+			t.Fatalf("expected only stuck to be reported, got %v", slow)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stuck subscriber never reported")
+	}
+
+	// with stuck evicted, delivery completes.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.WaitDelivered(ctx, h.Version()); err != nil {
+		t.Fatalf("eviction should unblock WaitDelivered, got %v", err)
+	}
+
+	// the evicted subscriber can still take what was
+	// pending, and then learns it was evicted.
+	if u := <-stuck.Ch(); u.Packet.Item != "v1" {
+		t.Fatalf("expected the pending update, got %#v", u)
+	}
+	if _, open := <-stuck.Ch(); open {
+		t.Fatal("an evicted subscriber's channel should be closed")
+	}
+}
+
+func TestHubUnsubscribe(t *testing.T) {
+
+	h := NewHub()
+	s := h.Subscribe("gone")
+	got := make(chan bool)
+	go func() {
+		_, open := <-s.Ch()
+		got <- open
+	}()
+	s.Unsubscribe()
+	s.Unsubscribe() // twice is fine.
+	if <-got {
+		t.Fatal("a blocked subscriber should see its channel closed")
+	}
+	h.Bcast(&Packet{Item: 1}) // doesn't send on the closed channel.
+}
+
+func TestHubHappensBefore(t *testing.T) {
+
+	h := NewHub()
+	subs := make([]*Subscription, 4)
+	for i := range subs {
+		subs[i] = h.Subscribe(fmt.Sprintf("sub%d", i))
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h.Bcast(&Packet{Item: i})
+		}(i)
+	}
+	wg.Wait()
+
+	if v := h.Version(); v != 100 {
+		t.Fatalf("expected version 100, got %v", v)
+	}
+	for _, s := range subs {
+		select {
+		case u := <-s.Ch():
+			if u.Version != 100 {
+				t.Fatalf("%v: expected the latest Update after Version, got %v", s.Label(), u.Version)
+			}
+		default:
+			t.Fatalf("%v: channel should be loaded after Version", s.Label())
+		}
+	}
+}
+
+func TestHubSync(t *testing.T) {
+
+	h := NewHub()
+	subs := make([]*Subscription, 4)
+	for i := range subs {
+		subs[i] = h.Subscribe(fmt.Sprintf("sub%d", i))
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			h.Bcast(&Packet{Item: i})
+		}
+	}()
+	// each subscriber has seen, or has waiting,
+	// whatever Sync reports.
+	seen := make([]uint64, len(subs))
+	for i := 0; i < 100; i++ {
+		v := h.Sync()
+		for j, s := range subs {
+			select {
+			case u := <-s.Ch():
+				seen[j] = u.Version
+			default:
+			}
+			if seen[j] < v {
+				t.Fatalf("%v: saw Version %v after Sync returned %v", s.Label(), seen[j], v)
+			}
+		}
+	}
+	wg.Wait()
+}
+
+func TestHubClock(t *testing.T) {
+
+	w := newTimerWheel(time.Millisecond)
+	h := NewHub()
+	h.SetClock(w)
+	slow := make(chan []*Subscription, 1)
+	h.SetSlowPolicy(SlowPolicy{
+		Deadline: 10 * time.Millisecond,
+		OnSlow: func(s []*Subscription) {
+			select {
+			case slow <- s:
+			default:
+			}
+		},
+	})
+	defer h.Stop()
+	h.Subscribe("laggard")
+	h.Bcast(&Packet{})
+
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case <-slow:
+		t.Fatal("slow checks should tick on the Clock, not in real time")
+	default:
+	}
+	advanceBy(w, 5*time.Millisecond)
+	select {
+	case s := <-slow:
+		if len(s) != 1 || s[0].Label() != "laggard" {
+			t.Fatalf("got %v", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the laggard was never found slow")
+	}
+}
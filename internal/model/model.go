@@ -0,0 +1,49 @@
+// Package model is the sequential specification of a
+// latch, against which concurrent histories are checked
+// by latchtest.CheckLinearizable.
+package model
+
+import "github.com/glycerine/latch"
+
+// Kind is the kind of an operation on a latch.
+type Kind int
+
+const (
+	Close Kind = iota // Bcast(Packet).
+	Open              // Clear().
+	Read              // non-blocking read: Packet, or nil if open.
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Close:
+		return "Close"
+	case Open:
+		return "Open"
+	case Read:
+		return "Read"
+	}
+	return "unknown"
+}
+
+// State is a latch's state: the Packet it is
+// closed with, or nil while open.
+type State struct {
+	Packet *latch.Packet
+}
+
+// Step applies an operation of kind k, with argument or
+// result pak, to s; it returns the new state, and whether
+// the operation is legal in s. Only a Read can be
+// illegal: it must see exactly s's Packet.
+func Step(s State, k Kind, pak *latch.Packet) (State, bool) {
+	switch k {
+	case Close:
+		return State{Packet: pak}, true
+	case Open:
+		return State{}, true
+	case Read:
+		return s, pak == s.Packet
+	}
+	return s, false
+}
@@ -0,0 +1,199 @@
+/*
+Package ipc mirrors a latch across processes over a
+unix domain socket (or any net.Listener). A supervisor
+process Serve-s a latch; worker processes Dial it and
+get a local *latch.Latch that follows every transition
+of the original, so shutdown and config broadcast work
+the same way between processes as within one.
+
+Packets are serialized with a latch.Codec, which both
+ends must agree on. Each transition is one frame: a
+state byte, 0 when the latch was opened (Clear-ed) and 1
+when it was closed; then, for a close, a uvarint length
+followed by that many bytes of encoded Packet. A zero
+length is a close with a nil Packet, as by Bcast(nil).
+*/
+package ipc
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/glycerine/latch"
+)
+
+// Serve accepts connections on ln and publishes the
+// transitions of src to each of them, encoded with codec,
+// starting with its current state. It returns when
+// ln.Accept fails, e.g. because ln was closed;
+// connections in progress are then hung up.
+func Serve(ln net.Listener, src *latch.Latch, codec latch.Codec) error {
+	done := make(chan struct{})
+	defer close(done)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		latch.Go(context.Background(), latch.RolePublisher, src.Name(),
+			func(context.Context) { publish(conn, src, codec, done) })
+	}
+}
+
+// publish writes src's transitions to conn until
+// a write fails or done is closed.
+func publish(conn net.Conn, src *latch.Latch, codec latch.Codec, done chan struct{}) {
+	defer conn.Close()
+	first := true
+	var seen uint64
+	for {
+		cur, closed, version, next := src.ObserveClosed()
+		if first || version != seen {
+			first = false
+			seen = version
+			if err := writeFrame(conn, cur, closed, codec); err != nil {
+				return
+			}
+		}
+		select {
+		case <-next:
+		case <-done:
+			return
+		}
+	}
+}
+
+// frame state bytes.
+const (
+	frameOpen   = 0
+	frameClosed = 1
+)
+
+func writeFrame(w io.Writer, pak *latch.Packet, closed bool, codec latch.Codec) error {
+	if !closed {
+		_, err := w.Write([]byte{frameOpen})
+		return err
+	}
+	var data []byte
+	if pak != nil {
+		var err error
+		data, err = codec.Encode(pak)
+		if err != nil {
+			return err
+		}
+	}
+	frame := binary.AppendUvarint([]byte{frameClosed}, uint64(len(data)))
+	_, err := w.Write(append(frame, data...))
+	return err
+}
+
+// readFrame returns the next state: whether the latch
+// is closed, and if so with what Packet.
+func readFrame(r *bufio.Reader, codec latch.Codec) (pak *latch.Packet, closed bool, err error) {
+	state, err := r.ReadByte()
+	if err != nil {
+		return nil, false, err
+	}
+	switch state {
+	case frameOpen:
+		return nil, false, nil
+	case frameClosed:
+	default:
+		return nil, false, fmt.Errorf("ipc: bad frame state %v", state)
+	}
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, false, err
+	}
+	if n == 0 {
+		return nil, true, nil
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, false, err
+	}
+	pak, err = codec.Decode(data)
+	return pak, err == nil, err
+}
+
+// Client is a local latch that mirrors a
+// latch Serve-d by another process.
+type Client struct {
+	*latch.Latch
+
+	conn  net.Conn
+	codec latch.Codec
+	gone  chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// Dial connects to the unix socket at path, returning
+// a Client whose latch, with a backing channel of size
+// sz, follows the remote one.
+func Dial(path string, sz int, codec latch.Codec) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn, sz, codec), nil
+}
+
+// NewClient mirrors the latch being published on conn.
+func NewClient(conn net.Conn, sz int, codec latch.Codec) *Client {
+	c := &Client{
+		Latch: latch.NewLatch(sz),
+		conn:  conn,
+		codec: codec,
+		gone:  make(chan struct{}),
+	}
+	latch.Go(context.Background(), latch.RoleMirror, "",
+		func(context.Context) { c.follow() })
+	return c
+}
+
+func (c *Client) follow() {
+	defer close(c.gone)
+	r := bufio.NewReader(c.conn)
+	for {
+		pak, closed, err := readFrame(r, c.codec)
+		if err != nil {
+			c.mu.Lock()
+			c.err = err
+			c.mu.Unlock()
+			c.conn.Close()
+			return
+		}
+		if !closed {
+			c.Clear()
+			continue
+		}
+		c.Bcast(pak)
+	}
+}
+
+// Hangup disconnects from the server. The local
+// latch keeps its last state.
+func (c *Client) Hangup() error {
+	return c.conn.Close()
+}
+
+// Disconnected is closed once the connection to the
+// server is lost (or Hangup is called).
+func (c *Client) Disconnected() <-chan struct{} {
+	return c.gone
+}
+
+// Err returns the error that ended the connection,
+// if it has ended.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
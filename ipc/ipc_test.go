@@ -0,0 +1,69 @@
+package ipc
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glycerine/latch"
+)
+
+func TestMirror(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "latch.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	src := latch.NewLatch(1)
+	src.Bcast(&latch.Packet{Item: "config-1"})
+	go Serve(ln, src, latch.GobCodec{})
+
+	c, err := Dial(path, 1, latch.GobCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Hangup()
+
+	waitItem(t, c, "config-1")
+	src.Bcast(&latch.Packet{Item: "shutdown"})
+	waitItem(t, c, "shutdown")
+
+	src.Clear()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.IsClosed() {
+		if time.Now().After(deadline) {
+			t.Fatal("mirror never opened")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// a close with nil is a close, too.
+	src.Bcast(nil)
+	for !c.IsClosed() {
+		if time.Now().After(deadline) {
+			t.Fatal("mirror never closed on Bcast(nil)")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if c.Peek() != nil {
+		t.Fatalf("expected a nil value, got %v", c.Peek())
+	}
+}
+
+func waitItem(t *testing.T, c *Client, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if pak := c.Peek(); pak != nil && pak.Item == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("mirror never saw %q", want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
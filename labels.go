@@ -0,0 +1,79 @@
+package latch
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// Every goroutine this package starts carries pprof
+// labels, so that CPU profiles and goroutine dumps
+// (including latchtest.NoLeaks reports) say whose it
+// is: LabelRole says what the goroutine does, and
+// LabelName names the latch it works for, if it was
+// given one by WithName.
+//
+// TaskGroup and Phases workers run the caller's own
+// code, and are not labeled.
+const (
+	LabelRole = "latch.role"
+	LabelName = "latch.name"
+)
+
+// The roles of the package's goroutines. Callers may
+// use their own roles with Go.
+const (
+	RoleRefresher  = "refresher"  // Latch, LatchArray and RefresherPool refreshing
+	RoleServer     = "server"     // feeding Ch() past MaxBufferedSz
+	RoleHub        = "hub"        // Hub slow-subscriber checks
+	RoleWatcher    = "watcher"    // WatchFile polling, Registry.WatchAll
+	RoleFollower   = "follower"   // Follow, NotifySignals and CloseOnClose
+	RoleSampler    = "sampler"    // Sampler
+	RoleFanIn      = "fanin"      // Selector and Splitter members
+	RoleTimer      = "timer"      // TimerWheel ticking
+	RoleSupervisor = "supervisor" // waiting on workers and Orchestrator components
+	RoleFaults     = "faults"     // WithFaults delays
+	RoleMirror     = "mirror"     // following a remote latch
+	RolePublisher  = "publisher"  // sending a latch to a remote mirror
+)
+
+// WithName names a latch, for the LabelName of its
+// goroutines.
+func WithName(name string) Option {
+	return func(r *Latch) {
+		r.name = name
+	}
+}
+
+// Name returns the name given by WithName.
+func (r *Latch) Name() string {
+	return r.name
+}
+
+// Labels returns the labels for a goroutine of the given
+// role, working for the latch called name. An empty
+// name is left out.
+func Labels(role, name string) pprof.LabelSet {
+	if name == "" {
+		return pprof.Labels(LabelRole, role)
+	}
+	return pprof.Labels(LabelRole, role, LabelName, name)
+}
+
+// Go runs f in a new goroutine labeled with
+// Labels(role, name), in addition to any labels ctx
+// carries; f's ctx has them all. Go returns once the
+// goroutine is running with its labels set, so that
+// it is never missed by a profile.
+func Go(ctx context.Context, role, name string, f func(ctx context.Context)) {
+	started := make(chan struct{})
+	go pprof.Do(ctx, Labels(role, name), func(ctx context.Context) {
+		close(started)
+		f(ctx)
+	})
+	<-started
+}
+
+// goLabeled is Go, for the package's own goroutines.
+func goLabeled(role, name string, f func()) {
+	Go(context.Background(), role, name, func(context.Context) { f() })
+}
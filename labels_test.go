@@ -0,0 +1,40 @@
+package latch
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+func TestGoroutineLabels(t *testing.T) {
+	l := NewLatch(1, WithName("shutdown"))
+	if l.Name() != "shutdown" {
+		t.Fatalf("Name() = %q", l.Name())
+	}
+	l.BackgroundRefresher()
+	defer l.Stop()
+
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 1)
+	if !strings.Contains(buf.String(), `"latch.name":"shutdown"`) ||
+		!strings.Contains(buf.String(), `"latch.role":"refresher"`) {
+		t.Fatalf("refresher not labeled:\n%s", buf.String())
+	}
+
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("app", "x"))
+	got := make(chan map[string]string)
+	Go(ctx, "custom", "", func(ctx context.Context) {
+		m := make(map[string]string)
+		pprof.ForLabels(ctx, func(k, v string) bool {
+			m[k] = v
+			return true
+		})
+		got <- m
+	})
+	m := <-got
+	if m["app"] != "x" || m[LabelRole] != "custom" || len(m) != 2 {
+		t.Fatalf("labels = %v", m)
+	}
+}
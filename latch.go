@@ -205,21 +205,162 @@ See the unlicense text in the LICENSE file.
 package latch
 
 import (
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
+	"weak"
 )
 
 // A latch channel is a channel that broadcasts a *Packet value,
 // without knowing who will receive it.
 // All readers will read the Bcast() value.
 type Latch struct {
-	sz    int
 	mut   sync.Mutex
 	cur   *Packet
-	ch    chan *Packet
 	avail bool // when avail==true, <- receives on Ch() will be given cur.
 
+	// chans is the channel and its size. Reset and
+	// Resize replace it, under mut; Ch and Refresh read
+	// it without the lock.
+	chans atomic.Pointer[chanState]
+
+	// closed mirrors avail, for lock-free reads on the
+	// hot paths (Refresh, IsClosed). It is only
+	// written while holding mut.
+	closed atomic.Bool
+
+	// version counts Bcast and Clear calls since the
+	// last Reset.
+	version uint64
+
+	// gen counts transitions, Resets included, and is
+	// never zeroed; so that deferred actions can tell if
+	// they've been pre-empted.
+	gen uint64
+
+	// chg is closed, and then replaced, on every
+	// Bcast and Clear; so watchers can wait for
+	// the next transition without polling.
+	chg chan struct{}
+
+	// done is closed while the latch is closed; see Done.
+	// Made lazily, and re-made after a Clear.
+	done       chan struct{}
+	doneClosed bool
+
+	// writeOnce, set by WithWriteOnce, makes the
+	// first Bcast stick; written records that it happened.
+	writeOnce bool
+	written   bool
+
+	// reducer, set by WithReducer, merges each
+	// Bcast value with the current one.
+	reducer func(old, new *Packet) *Packet
+
+	// persist, if set by WithPersistence, durably
+	// records each transition.
+	persist *persister
+
+	// tracing, if set by WithTracer, reports
+	// each transition to a Tracer.
+	tracing *tracing
+
+	// logging, if set by WithLogger, logs
+	// transitions and Refresh starvation.
+	logging *logging
+
+	// mutCheck, if set by WithMutationCheck, detects
+	// readers modifying a shared Packet.Item.
+	mutCheck *mutCheck
+
+	// cloner, if set by WithCloner, copies
+	// the Item for each reader.
+	cloner func(interface{}) interface{}
+
+	// clk, if set by WithClock, replaces the
+	// time package; see clock().
+	clk Clock
+
+	// ttl, if set by WithTTL, expires
+	// values that aren't refreshed.
+	ttl *ttl
+
+	// history, if set by WithHistory, records
+	// recent transitions.
+	history *history
+
+	// fair, if set by WithReaderQuota, limits
+	// what each Reader takes between refills.
+	fair *fairness
+
+	// low, if set by WithLowWater, signals
+	// when the channel runs low.
+	low *lowWater
+
+	// deadline is set by CloseWithDeadline, and
+	// cleared by any other transition.
+	deadline time.Time
+
+	// singleWriter, set by WithSingleWriter, limits
+	// Writer to one call; writerIssued records it.
+	singleWriter bool
+	writerIssued bool
+
+	// faults, if set by WithFaults, injects delays
+	// and starvation, for testing consumers.
+	faults Faults
+
+	// gcRefresher, set by WithRefresherCleanup, lets
+	// an abandoned latch's refresher be collected.
+	gcRefresher bool
+
+	// name, from WithName, labels r's goroutines.
+	name string
+
+	refreshLimit *tokenBucket
+
+	policy *writePolicy
+
+	// epochs are the Registry epochs r counts in.
+	epochs map[*epoch]int
+
+	audit *auditLog
+
+	vetoes []*vetoHook
+
+	// refusal is why set last refused a write.
+	refusal error
+
+	stats *statsAcc
+
+	// served latches are fed by a goroutine
+	// while closed. See MaxBufferedSz.
+	srvStop    chan struct{}
+	srvDone    chan struct{}
+	srvCleanup runtime.Cleanup
+
 	fillerStop chan struct{}
+
+	// refreshing is the Refresh in progress, if any;
+	// others wait for it rather than repeat it.
+	refreshing atomic.Pointer[refreshFlight]
+}
+
+// chanState is a latch's channel, and the size it was
+// made for; they are replaced together.
+type chanState struct {
+	ch chan *Packet
+	sz int
+
+	// served latches have a ch of MaxBufferedSz, kept
+	// topped up by a goroutine while closed. See
+	// MaxBufferedSz.
+	served bool
+}
+
+type refreshFlight struct {
+	done chan struct{}
 }
 
 // Packet conveys either a data Item,
@@ -227,15 +368,53 @@ type Latch struct {
 type Packet struct {
 	Item interface{}
 	Err  error
+
+	// Priority ranks competing writes under
+	// WithWritePolicy(HighestPriorityWins).
+	Priority int
 }
 
+// Option configures optional Latch behavior
+// at construction; see NewLatch.
+type Option func(r *Latch)
+
 // NewLatch makes a new latch with
 // backing channel of size sz.
-func NewLatch(sz int) *Latch {
-	return &Latch{
-		ch: make(chan *Packet, sz),
-		sz: sz,
+//
+// If sz exceeds MaxBufferedSz, the latch is instead
+// served: see MaxBufferedSz.
+func NewLatch(sz int, opts ...Option) *Latch {
+	r := &Latch{
+		chg: make(chan struct{}),
+	}
+	r.makeCh(sz)
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.stats != nil {
+		r.stats.advance(r.clock().Now())
 	}
+	if r.persist != nil {
+		r.persist.restore(r)
+	}
+	return r
+}
+
+// makeCh sizes the latch, making its channel.
+// Caller must hold r.mut, or be NewLatch.
+func (r *Latch) makeCh(sz int) {
+	c := &chanState{sz: sz, served: sz > MaxBufferedSz}
+	if c.served {
+		c.ch = make(chan *Packet, MaxBufferedSz)
+	} else {
+		c.ch = make(chan *Packet, sz)
+	}
+	r.chans.Store(c)
+}
+
+// c returns the latch's current channel state.
+func (r *Latch) c() *chanState {
+	return r.chans.Load()
 }
 
 // Ch returns a read-only channel. This is
@@ -244,13 +423,61 @@ func NewLatch(sz int) *Latch {
 // into the channel by means other than
 // calling Bcast().
 func (r *Latch) Ch() <-chan *Packet {
-	return r.ch
+	if r.low != nil {
+		r.low.check(r)
+	}
+	c := r.c()
+	if c.served && len(c.ch) < cap(c.ch)/2 && r.closed.Load() {
+		r.topUp(c)
+	}
+	return c.ch
+}
+
+// IsClosed reports whether the latch is closed, i.e.
+// whether Bcast has been called since the last Clear,
+// so that receives on Ch() will succeed (given
+// sufficient Refresh).
+func (r *Latch) IsClosed() bool {
+	return r.closed.Load()
+}
+
+// Peek returns the value most recently given to Bcast,
+// without consuming anything from Ch(). It returns nil
+// if the latch is open (Clear-ed, or never Bcast).
+func (r *Latch) Peek() *Packet {
+	cur, _ := r.peek()
+	return cur
+}
+
+// peek is Peek, also reporting whether the latch is
+// closed; so a Bcast(nil) can be told from open.
+func (r *Latch) peek() (cur *Packet, closed bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if !r.avail {
+		return nil, false
+	}
+	if r.mutCheck != nil {
+		r.mutCheck.verify(r)
+	}
+	return r.copyOf(r.cur), true
+}
+
+// Version returns the count of Bcast and Clear
+// calls made on the latch so far. It lets observers
+// notice that a transition happened, even if the
+// value is unchanged.
+func (r *Latch) Version() uint64 {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.version
 }
 
 // clients should call Clear(), not drain() directly.
 // Internal callers should be holding the r.mut already.
 func (r *Latch) drain() {
-	if len(r.ch) == 0 {
+	ch := r.c().ch
+	if len(ch) == 0 {
 		return
 	}
 	// safe for concurrent reads; in
@@ -258,7 +485,7 @@ func (r *Latch) drain() {
 	// now, we don't want to block inside drain().
 	for {
 		select {
-		case <-r.ch:
+		case <-ch:
 		default:
 			return
 		}
@@ -272,13 +499,214 @@ func (r *Latch) drain() {
 // The sz value was set during NewLatch(sz).
 func (r *Latch) Bcast(pak *Packet) {
 	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.bcast(pak)
+}
+
+// TryBcast is Bcast, but reports whether it took
+// effect. It returns false when a WithWriteOnce latch
+// has already been written, when the WithWritePolicy
+// refuses pak, or when a veto hook (see AddVeto) does;
+// BcastErr says which.
+func (r *Latch) TryBcast(pak *Packet) bool {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.bcast(pak)
+}
+
+// bcast does the work of Bcast, returning false if
+// refused by WithWriteOnce, the write policy or a veto,
+// with the reason in r.refusal. Caller must hold r.mut.
+func (r *Latch) bcast(pak *Packet) bool {
+	if !r.set(pak) {
+		return false
+	}
+	r.publish()
+	return true
+}
+
+// set makes pak the current value, recording the
+// transition, but doesn't yet hand it to readers of
+// Ch(); see publish. Caller must hold r.mut.
+func (r *Latch) set(pak *Packet) bool {
+	return r.setVetted(pak, false)
+}
+
+// setVetted is set, skipping the veto hooks if vetted:
+// the caller has already run them on pak, as Tx.Commit
+// does, and they must not run twice.
+// Caller must hold r.mut.
+func (r *Latch) setVetted(pak *Packet, vetted bool) bool {
+	if r.mutCheck != nil {
+		r.mutCheck.verify(r)
+	}
+	if !vetted {
+		if err := r.vet(pak); err != nil {
+			r.refusal = err
+			return false
+		}
+	}
+	if r.policy != nil && !r.policy.decide(r, pak) {
+		r.refusal = ErrPolicyRefused
+		return false
+	}
+	if r.writeOnce {
+		if r.written {
+			r.refusal = ErrWriteOnceViolated
+			return false
+		}
+		r.written = true
+	}
+	if r.reducer != nil {
+		var old *Packet
+		if r.avail {
+			old = r.cur
+		}
+		pak = r.reducer(old, pak)
+	}
+	r.install(pak)
+	return true
+}
+
+// install makes pak the current value, closing the
+// latch, with none of set's checks: no veto hooks, write
+// policy, WithWriteOnce or reducer. Caller must hold r.mut.
+func (r *Latch) install(pak *Packet) {
 	r.cur = pak
 	r.drain() // drop any old values.
 	r.avail = true
-	for i := 0; i < r.sz; i++ {
-		r.ch <- r.cur
+	r.closed.Store(true)
+	if r.done != nil && !r.doneClosed {
+		close(r.done)
+		r.doneClosed = true
+	}
+	r.transition()
+}
+
+// publish hands the current value to readers of Ch().
+// Caller must hold r.mut.
+func (r *Latch) publish() {
+	c := r.c()
+	if c.served {
+		r.stopServer()
+		r.startServer()
+		return
+	}
+	for i := 0; i < c.sz; i++ {
+		c.ch <- r.copyOf(r.cur)
+	}
+	if r.fair != nil {
+		r.fair.refilled()
+	}
+	if r.low != nil {
+		r.low.refilled()
+	}
+}
+
+// bcastIfOpen is bcast, but only if we are not
+// already closed. Caller must hold r.mut.
+func (r *Latch) bcastIfOpen(pak *Packet) bool {
+	if r.avail {
+		return false
+	}
+	return r.bcast(pak)
+}
+
+// transition records a Bcast or Clear, waking
+// anyone waiting on the old chg channel.
+// Caller must hold r.mut.
+func (r *Latch) transition() {
+	r.version++
+	r.gen++
+	r.deadline = time.Time{}
+	close(r.chg)
+	r.chg = make(chan struct{})
+	if r.persist != nil {
+		r.persist.save(r)
+	}
+	if r.tracing != nil {
+		r.tracing.record(r)
+	}
+	if r.logging != nil {
+		r.logging.logTransition(r)
+	}
+	if r.mutCheck != nil {
+		r.mutCheck.record(r)
+	}
+	if r.ttl != nil {
+		r.ttl.rearm(r)
+	}
+	if r.history != nil {
+		r.history.record(r)
+	}
+	if r.audit != nil {
+		r.audit.record(r)
+	}
+	if r.stats != nil {
+		r.stats.record(r)
+	}
+	r.bumpEpochs()
+}
+
+// Observe returns, atomically: the current value (nil if
+// the latch is open), the current Version, and a channel
+// that will be closed at the next Bcast or Clear. It lets
+// observers wait for transitions without polling, and
+// without consuming anything from Ch().
+func (r *Latch) Observe() (cur *Packet, version uint64, next <-chan struct{}) {
+	cur, _, version, next = r.observe()
+	return cur, version, next
+}
+
+// ObserveClosed is Observe, also reporting whether the
+// latch is closed; cur alone can't tell a latch closed
+// with Bcast(nil) from an open one.
+func (r *Latch) ObserveClosed() (cur *Packet, closed bool, version uint64, next <-chan struct{}) {
+	return r.observe()
+}
+
+// observe is Observe, also reporting whether r is
+// closed, which cur can't tell after a Bcast(nil).
+func (r *Latch) observe() (cur *Packet, closed bool, version uint64, next <-chan struct{}) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.avail {
+		cur, closed = r.copyOf(r.cur), true
+	}
+	if r.faults != nil {
+		if d := r.faults.NotifyDelay(); d > 0 {
+			return cur, closed, r.version, delayed(r.chg, d)
+		}
+	}
+	return cur, closed, r.version, r.chg
+}
+
+// Done returns a channel that is closed (in the Go sense)
+// while the latch is closed, and stays open while the
+// latch is open. After a Clear, Done returns a fresh
+// channel. It suits APIs that only need select-friendly
+// notification, and needn't pay for Packet buffering;
+// Done channels never need Refresh.
+func (r *Latch) Done() <-chan struct{} {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.done == nil {
+		r.done = make(chan struct{})
+		if r.avail {
+			close(r.done)
+			r.doneClosed = true
+		}
+	}
+	return r.done
+}
+
+// reopenDone arranges for the next Done()
+// to get a fresh channel. Caller must hold r.mut.
+func (r *Latch) reopenDone() {
+	if r.doneClosed {
+		r.done = nil
+		r.doneClosed = false
 	}
-	r.mut.Unlock()
 }
 
 // Refresh "tops-up" a available channel. Since
@@ -295,19 +723,66 @@ func (r *Latch) Bcast(pak *Packet) {
 // regularly to service your available channel,
 // call BackgroundRefresher() once instead.
 //
+// Refresh does not take the lock when there is nothing
+// to do (the latch is open, or the channel is already
+// full), so it is cheap to call from many goroutines.
+// Concurrent Refreshes that do find work coalesce: one
+// does the fill, and the rest wait for it to finish,
+// rather than queueing on the lock to redo it.
 func (r *Latch) Refresh() {
+	if c := r.c(); c.served || !r.closed.Load() || len(c.ch) >= c.sz {
+		return
+	}
+	if r.refreshLimit != nil && !r.refreshLimit.take(r.clock().Now()) {
+		return
+	}
+	if r.faults != nil {
+		if r.faults.Starve() {
+			return
+		}
+		time.Sleep(r.faults.RefillDelay())
+	}
+	flight := &refreshFlight{done: make(chan struct{})}
+	if !r.refreshing.CompareAndSwap(nil, flight) {
+		if f := r.refreshing.Load(); f != nil {
+			<-f.done
+			return
+		}
+		// it just finished; we'll do our own.
+	}
+	defer func() {
+		r.refreshing.CompareAndSwap(flight, nil)
+		close(flight.done)
+	}()
 	r.mut.Lock()
-	if r.avail {
-		for len(r.ch) < r.sz {
-			r.ch <- r.cur
+	// re-read under the lock, in case of Reset or Resize.
+	if c := r.c(); r.avail && !c.served {
+		if len(c.ch) == 0 && r.logging != nil {
+			r.logging.logStarved(r)
+		}
+		if r.mutCheck != nil {
+			r.mutCheck.verify(r)
+		}
+		for len(c.ch) < c.sz {
+			c.ch <- r.copyOf(r.cur)
+		}
+		if r.fair != nil {
+			r.fair.refilled()
+		}
+		if r.low != nil {
+			r.low.refilled()
 		}
 	}
 	r.mut.Unlock()
 }
 
 // BackgroundRefresher starts a goroutine
-// that tops-up your available channel
-// every 500msec. It will prevent
+// that tops-up your available channel,
+// initially every 500msec. The interval then
+// adapts to how fast readers drain the channel:
+// it halves (down to 10msec) while more than
+// half the copies go between top-ups, and
+// doubles (up to 2sec) while none do. It will prevent
 // starvation if you have lots of consumers;
 // at the cost of using a goroutine and
 // possibly slowing down your whole program.
@@ -329,18 +804,80 @@ func (r *Latch) BackgroundRefresher() {
 	r.mut.Lock()
 	defer r.mut.Unlock()
 	if r.fillerStop == nil {
-		r.fillerStop = make(chan struct{})
-		go func() {
+		// capture stop, as Reset may replace r.fillerStop.
+		stop := make(chan struct{})
+		r.fillerStop = stop
+		clk := r.clock()
+		interval := refreshInterval
+		tick := clk.NewTicker(interval)
+
+		// with WithRefresherCleanup, hold r only weakly,
+		// so that it can be collected; see there.
+		strong, wp := r, weak.Make(r)
+		if r.gcRefresher {
+			strong = nil
+			runtime.AddCleanup(r, closeStop, stop)
+		}
+		activeRefreshers.Add(1)
+		goLabeled(RoleRefresher, r.name, func() {
+			defer activeRefreshers.Add(-1)
+			defer func() { tick.Stop() }()
 			for {
 				select {
-				case <-r.fillerStop:
+				case <-stop:
 					return
-				case <-time.After(500 * time.Millisecond):
-					r.Refresh()
+				case <-tick.C():
+					l := strong
+					if l == nil {
+						if l = wp.Value(); l == nil {
+							return
+						}
+					}
+					next := adaptInterval(interval, l.drained())
+					l.Refresh()
+					l = nil
+					if next != interval {
+						interval = next
+						tick.Stop()
+						tick = clk.NewTicker(interval)
+					}
 				}
 			}
-		}()
+		})
+	}
+}
+
+// BackgroundRefresher's bounds on its interval.
+const (
+	refreshInterval    = 500 * time.Millisecond
+	minRefreshInterval = 10 * time.Millisecond
+	maxRefreshInterval = 2 * time.Second
+)
+
+// adaptInterval picks the next BackgroundRefresher
+// interval, given the fraction of the channel drained
+// during the last one.
+func adaptInterval(interval time.Duration, drained float64) time.Duration {
+	switch {
+	case drained > 0.5:
+		interval /= 2
+	case drained == 0:
+		interval *= 2
+	}
+	return min(max(interval, minRefreshInterval), maxRefreshInterval)
+}
+
+// drained returns the fraction of the channel's copies
+// that readers have taken: 0 if the latch is open, or
+// served.
+func (r *Latch) drained() float64 {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	c := r.c()
+	if !r.avail || c.served || c.sz == 0 {
+		return 0
 	}
+	return float64(c.sz-len(c.ch)) / float64(c.sz)
 }
 
 // Stop tells any BackgroundRefresher goroutine
@@ -365,7 +902,99 @@ func (r *Latch) Stop() {
 // calls Bcast().
 func (r *Latch) Clear() {
 	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.clear()
+}
+
+// TryClear is Clear, but reports whether it took
+// effect. It returns false only when a WithWriteOnce
+// latch has already been written: write policies and
+// veto hooks judge closes, not Clear. ClearErr returns
+// the error instead.
+func (r *Latch) TryClear() bool {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.clear()
+}
+
+// clear does the work of Clear, returning false if
+// refused by WithWriteOnce. Caller must hold r.mut.
+func (r *Latch) clear() bool {
+	if r.mutCheck != nil {
+		r.mutCheck.verify(r)
+	}
+	if r.writeOnce && r.written {
+		return false
+	}
+	r.open()
+	return true
+}
+
+// open reopens the latch, with no WithWriteOnce check.
+// Caller must hold r.mut.
+func (r *Latch) open() {
+	r.stopServer()
 	r.drain()
 	r.avail = false
-	r.mut.Unlock()
+	r.closed.Store(false)
+	r.reopenDone()
+	r.transition()
+}
+
+// Reset returns the latch to the pristine, open, state
+// of a NewLatch(sz): any BackgroundRefresher is stopped,
+// the channel is drained (or re-made, if sz differs),
+// and the Version goes back to zero. Options given to
+// NewLatch remain in effect.
+//
+// Reset lets pools of latches be reused, across
+// connection lifecycles say, instead of being re-allocated
+// and left for the garbage collector. Only Reset a latch
+// nobody is still using: a reader holding the old Ch()
+// of a re-sized latch will never hear from it again.
+func (r *Latch) Reset(sz int) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.fillerStop != nil {
+		select {
+		case <-r.fillerStop:
+		default:
+			close(r.fillerStop)
+		}
+		r.fillerStop = nil
+	}
+	r.stopServer()
+	r.drain()
+	if sz != r.c().sz {
+		r.makeCh(sz)
+	}
+	r.cur = nil
+	r.avail = false
+	r.closed.Store(false)
+	r.reopenDone()
+	r.written = false
+	r.version = 0
+	r.gen++
+	r.deadline = time.Time{}
+	if r.ttl != nil {
+		r.ttl.stop()
+	}
+	if r.history != nil {
+		r.history.next = 0
+	}
+	if r.audit != nil {
+		r.audit.closed = false
+	}
+	if r.stats != nil {
+		r.stats = &statsAcc{}
+		r.stats.advance(r.clock().Now())
+	}
+
+	// wake any watchers; they'll find us open.
+	close(r.chg)
+	r.chg = make(chan struct{})
+	r.bumpEpochs()
+	if r.persist != nil {
+		r.persist.save(r)
+	}
 }
@@ -1,6 +1,11 @@
 package latch
 
-import "testing"
+import (
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+)
 
 func TestLatch(t *testing.T) {
 
@@ -63,3 +68,224 @@ func TestLatch(t *testing.T) {
 	}
 
 }
+
+func BenchmarkRefreshParallel(b *testing.B) {
+	latch := NewLatch(8)
+	latch.Bcast(&Packet{Item: "go"})
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			latch.Refresh()
+		}
+	})
+}
+
+func TestReset(t *testing.T) {
+
+	latch := NewLatch(2)
+	latch.BackgroundRefresher()
+	latch.Bcast(&Packet{Item: "conn-1"})
+
+	latch.Reset(4)
+	if latch.IsClosed() || latch.Version() != 0 {
+		t.Fatal("Reset should leave the latch open, at version zero")
+	}
+	if cap(latch.Ch()) != 4 {
+		t.Fatal("Reset should re-size the channel")
+	}
+	select {
+	case <-latch.Ch():
+		t.Fatal("Reset should have drained the latch")
+	default:
+	}
+
+	// and it is usable again, refresher included.
+	latch.BackgroundRefresher()
+	defer latch.Stop()
+	pak := &Packet{Item: "conn-2"}
+	latch.Bcast(pak)
+	if b := <-latch.Ch(); b != pak {
+		t.Fatal("reused latch should broadcast normally")
+	}
+}
+
+func TestResetDuringRefresh(t *testing.T) {
+
+	// run with -race: Refresh and Ch read the channel
+	// without the lock, while Reset and Resize replace it.
+	l := NewLatch(4)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			l.Refresh()
+			select {
+			case <-l.Ch():
+			default:
+			}
+		}
+	}()
+	for i := 0; i < 5000; i++ {
+		l.Bcast(&Packet{Item: i})
+		l.Reset(1 + i%8)
+		l.Resize(1 + i%5)
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestDone(t *testing.T) {
+
+	latch := NewLatch(1)
+	done := latch.Done()
+	select {
+	case <-done:
+		t.Fatal("Done should block while the latch is open")
+	default:
+	}
+
+	latch.Bcast(&Packet{Item: "stop"})
+	select {
+	case <-done:
+	default:
+		t.Fatal("Bcast should close the Done channel handed out earlier")
+	}
+
+	latch.Clear()
+	select {
+	case <-latch.Done():
+		t.Fatal("after Clear, Done should be a fresh, open, channel")
+	default:
+	}
+}
+
+func TestWriteOnce(t *testing.T) {
+
+	latch := NewLatch(1, WithWriteOnce())
+	first := &Packet{Item: "first error"}
+	if !latch.TryBcast(first) {
+		t.Fatal("the first write should take effect")
+	}
+	if latch.TryBcast(&Packet{Item: "second error"}) {
+		t.Fatal("later writes should be refused")
+	}
+	latch.Clear()
+	if latch.TryClear() {
+		t.Fatal("Clear should be refused too")
+	}
+	if b := <-latch.Ch(); b != first {
+		t.Fatal("the first write should stick")
+	}
+}
+
+func TestReducer(t *testing.T) {
+
+	count := func(old, new *Packet) *Packet {
+		n := 0
+		if old != nil {
+			n = old.Item.(int)
+		}
+		return &Packet{Item: n + new.Item.(int)}
+	}
+	latch := NewLatch(1, WithReducer(count))
+	for i := 0; i < 10; i++ {
+		latch.Bcast(&Packet{Item: 1})
+	}
+	if b := <-latch.Ch(); b.Item != 10 {
+		t.Fatalf("reducer should have summed to 10, got %v", b.Item)
+	}
+
+	latch.Clear()
+	latch.Bcast(&Packet{Item: 1})
+	if b := latch.Peek(); b.Item != 1 {
+		t.Fatalf("after Clear the reducer starts over, got %v", b.Item)
+	}
+}
+
+func TestBackgroundRefresherSynctest(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		l := NewLatch(1)
+		l.Bcast(&Packet{Item: 1})
+		l.BackgroundRefresher()
+		defer l.Stop()
+
+		<-l.Ch()
+		// no real time passes in the bubble.
+		time.Sleep(500 * time.Millisecond)
+		synctest.Wait()
+		select {
+		case <-l.Ch():
+		default:
+			t.Fatal("the refresher should have topped up Ch")
+		}
+	})
+}
+
+func TestRefreshCoalesces(t *testing.T) {
+
+	l := NewLatch(64)
+	l.Bcast(&Packet{Item: 1})
+	for i := 0; i < 64; i++ {
+		<-l.Ch()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Refresh()
+			// whether we filled, or waited on whoever
+			// did, the channel is topped up on return.
+			if n := len(l.Ch()); n != 64 {
+				t.Errorf("expected a full channel after Refresh, has %v", n)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAdaptInterval(t *testing.T) {
+
+	d := refreshInterval
+	for i := 0; i < 10; i++ {
+		d = adaptInterval(d, 1)
+	}
+	if d != minRefreshInterval {
+		t.Fatalf("heavy draining should tighten to the minimum, got %v", d)
+	}
+	if d = adaptInterval(d, 0.25); d != minRefreshInterval {
+		t.Fatalf("moderate draining should hold steady, got %v", d)
+	}
+	for i := 0; i < 10; i++ {
+		d = adaptInterval(d, 0)
+	}
+	if d != maxRefreshInterval {
+		t.Fatalf("idle readers should loosen to the maximum, got %v", d)
+	}
+}
+
+func TestBackgroundRefresherAdapts(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		l := NewLatch(4)
+		l.Bcast(&Packet{Item: 1})
+		l.BackgroundRefresher()
+		defer l.Stop()
+
+		// a hungry reader, draining everything, is kept
+		// fed by ever more frequent top-ups.
+		start := time.Now()
+		for i := 0; i < 100; i++ {
+			<-l.Ch()
+		}
+		if took := time.Since(start); took > 5*time.Second {
+			t.Fatalf("refresher did not speed up; 100 reads took %v", took)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package latch
+
+import "sync"
+
+// LatchArray is an indexed set of n latches, for
+// per-shard shutdown and the like. The shards share one
+// mutex, and one BackgroundRefresher goroutine, rather
+// than having n of each; and bulk operations close many
+// shards under a single lock acquisition.
+//
+// Each shard behaves like a plain Latch of size sz.
+type LatchArray struct {
+	mu     sync.Mutex
+	sz     int
+	shards []shard
+	closed int // count of closed shards.
+
+	clk        Clock
+	fillerStop chan struct{}
+}
+
+type shard struct {
+	ch     chan *Packet
+	cur    *Packet
+	closed bool // cur may be nil, after Bcast(i, nil).
+	done   chan struct{}
+}
+
+// NewLatchArray makes n open latches, each with a
+// backing channel of size sz.
+func NewLatchArray(n, sz int) *LatchArray {
+	a := &LatchArray{sz: sz, shards: make([]shard, n)}
+	for i := range a.shards {
+		a.shards[i].ch = make(chan *Packet, sz)
+		a.shards[i].done = make(chan struct{})
+	}
+	return a
+}
+
+// Len returns the number of shards.
+func (a *LatchArray) Len() int {
+	return len(a.shards)
+}
+
+// Ch returns shard i's channel.
+func (a *LatchArray) Ch(i int) <-chan *Packet {
+	return a.shards[i].ch
+}
+
+// Done returns a channel that is closed while
+// shard i is closed; see Latch.Done.
+func (a *LatchArray) Done(i int) <-chan struct{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.shards[i].done
+}
+
+// Peek returns shard i's value, or nil if it is open.
+func (a *LatchArray) Peek(i int) *Packet {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.shards[i].cur
+}
+
+// IsClosed reports whether shard i is closed.
+func (a *LatchArray) IsClosed(i int) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.shards[i].closed
+}
+
+// Bcast closes shard i with pak.
+func (a *LatchArray) Bcast(i int, pak *Packet) {
+	a.CloseRange(i, i+1, pak)
+}
+
+// Clear opens shard i.
+func (a *LatchArray) Clear(i int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := &a.shards[i]
+	if !s.closed {
+		return
+	}
+	drainCh(s.ch)
+	s.cur, s.closed = nil, false
+	s.done = make(chan struct{})
+	a.closed--
+}
+
+// CloseAll closes every shard with pak.
+func (a *LatchArray) CloseAll(pak *Packet) {
+	a.CloseRange(0, len(a.shards), pak)
+}
+
+// CloseRange closes shards lo through hi-1 with pak.
+func (a *LatchArray) CloseRange(lo, hi int, pak *Packet) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i := lo; i < hi; i++ {
+		s := &a.shards[i]
+		if !s.closed {
+			close(s.done)
+			a.closed++
+		}
+		drainCh(s.ch)
+		s.cur, s.closed = pak, true
+		for j := 0; j < a.sz; j++ {
+			s.ch <- pak
+		}
+	}
+}
+
+// AnyClosed reports whether any shard is closed.
+func (a *LatchArray) AnyClosed() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.closed > 0
+}
+
+// AllClosed reports whether every shard is closed.
+func (a *LatchArray) AllClosed() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.closed == len(a.shards)
+}
+
+// Refresh tops up the channels of all closed shards.
+func (a *LatchArray) Refresh() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i := range a.shards {
+		s := &a.shards[i]
+		if !s.closed {
+			continue
+		}
+		for len(s.ch) < a.sz {
+			s.ch <- s.cur
+		}
+	}
+}
+
+// SetClock makes the BackgroundRefresher use c, as
+// WithClock does for a Latch. Call it before
+// BackgroundRefresher.
+func (a *LatchArray) SetClock(c Clock) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.clk = c
+}
+
+// BackgroundRefresher starts one goroutine that
+// Refreshes every shard each 500msec; see
+// Latch.BackgroundRefresher.
+func (a *LatchArray) BackgroundRefresher() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.fillerStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	a.fillerStop = stop
+	tick := orReal(a.clk).NewTicker(refreshInterval)
+	activeRefreshers.Add(1)
+	goLabeled(RoleRefresher, "", func() {
+		defer activeRefreshers.Add(-1)
+		defer tick.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-tick.C():
+				a.Refresh()
+			}
+		}
+	})
+}
+
+// Stop ends any BackgroundRefresher goroutine.
+func (a *LatchArray) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.fillerStop != nil {
+		close(a.fillerStop)
+		a.fillerStop = nil
+	}
+}
+
+// drainCh empties ch without blocking.
+func drainCh(ch chan *Packet) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package latch
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestLatchArray(t *testing.T) {
+
+	a := NewLatchArray(4, 1)
+	if a.Len() != 4 || a.AnyClosed() {
+		t.Fatal("a new array should be all open")
+	}
+
+	a.Bcast(2, &Packet{Item: "shard2"})
+	<-a.Done(2)
+	if !a.AnyClosed() || a.AllClosed() || a.IsClosed(1) {
+		t.Fatal("only shard 2 should be closed")
+	}
+	if (<-a.Ch(2)).Item != "shard2" {
+		t.Fatal("shard 2 should deliver its value")
+	}
+
+	stop := &Packet{Item: "stop"}
+	a.CloseRange(0, 2, stop)
+	if a.Peek(0) != stop || a.Peek(1) != stop || a.Peek(3) != nil {
+		t.Fatal("CloseRange should close just its range")
+	}
+	a.CloseAll(stop)
+	if !a.AllClosed() {
+		t.Fatal("CloseAll should close everything")
+	}
+
+	a.Clear(0)
+	select {
+	case <-a.Done(0):
+		t.Fatal("a cleared shard's Done should be fresh")
+	default:
+	}
+	if a.AllClosed() || len(a.Ch(0)) != 0 {
+		t.Fatal("Clear should open the shard, and drain it")
+	}
+}
+
+func TestLatchArrayRefresher(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		a := NewLatchArray(3, 1)
+		a.CloseAll(&Packet{Item: 1})
+		a.BackgroundRefresher()
+		defer a.Stop()
+		for i := 0; i < 3; i++ {
+			<-a.Ch(i)
+		}
+		time.Sleep(refreshInterval)
+		synctest.Wait()
+		for i := 0; i < 3; i++ {
+			if len(a.Ch(i)) != 1 {
+				t.Fatalf("shard %v not refreshed", i)
+			}
+		}
+	})
+}
+
+func TestLatchArrayBcastNil(t *testing.T) {
+
+	a := NewLatchArray(2, 1)
+	a.Bcast(0, nil)
+	a.Bcast(0, nil)
+	if !a.IsClosed(0) || !a.AnyClosed() || a.AllClosed() {
+		t.Fatal("Bcast(nil) should close just the shard")
+	}
+	a.Clear(0)
+	if a.IsClosed(0) || a.AnyClosed() {
+		t.Fatal("Clear should reopen a shard closed with nil")
+	}
+	select {
+	case <-a.Ch(0):
+		t.Fatal("a cleared shard's Ch should block")
+	default:
+	}
+}
+
+func TestLatchArrayClock(t *testing.T) {
+
+	w := newTimerWheel(time.Millisecond)
+	a := NewLatchArray(1, 1)
+	a.SetClock(w)
+	a.BackgroundRefresher()
+	defer a.Stop()
+
+	a.Bcast(0, &Packet{Item: 1})
+	<-a.Ch(0)
+	for i := 0; i < int(refreshInterval/time.Millisecond); i++ {
+		w.advance()
+	}
+	waitFor(t, func() bool { return len(a.Ch(0)) == 1 })
+}
@@ -0,0 +1,28 @@
+package latch
+
+import "context"
+
+// Latcher is the interface of a Latch, as used by code
+// that waits on, or signals, shutdown and config changes.
+// Depend on Latcher rather than *Latch where you want to
+// substitute a test double, such as latchtest.Fake.
+type Latcher interface {
+	// Bcast closes the latch with pak.
+	Bcast(pak *Packet)
+
+	// Clear opens the latch.
+	Clear()
+
+	// Ch returns the channel that delivers the
+	// value while the latch is closed.
+	Ch() <-chan *Packet
+
+	// Read waits for the latch to be closed,
+	// and returns its value.
+	Read(ctx context.Context) (*Packet, error)
+
+	// IsClosed reports whether the latch is closed.
+	IsClosed() bool
+}
+
+var _ Latcher = (*Latch)(nil)
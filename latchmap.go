@@ -0,0 +1,208 @@
+package latch
+
+import "sync"
+
+// LatchMap is a keyed store in which each key behaves as
+// an independent latch: per connection, per shard, per
+// resource readiness or shutdown. Setting a key closes
+// its latch with a Packet whose Item is the V; Deleting
+// it opens the latch.
+//
+// Latches are created lazily, and dropped once a key has
+// neither a value nor anyone Watching it, so the map
+// only holds what is in use.
+type LatchMap[K comparable, V any] struct {
+	mu sync.Mutex
+	sz int
+	m  map[K]*mapEntry[V]
+}
+
+type mapEntry[V any] struct {
+	l        *Latch
+	val      V
+	has      bool
+	watchers int
+}
+
+// NewLatchMap makes a new, empty, LatchMap whose
+// latches have backing channels of size sz.
+func NewLatchMap[K comparable, V any](sz int) *LatchMap[K, V] {
+	return &LatchMap[K, V]{
+		sz: sz,
+		m:  make(map[K]*mapEntry[V]),
+	}
+}
+
+// entry returns k's entry, creating it if need be.
+// Caller must hold m.mu.
+func (m *LatchMap[K, V]) entry(k K) *mapEntry[V] {
+	e, ok := m.m[k]
+	if !ok {
+		e = &mapEntry[V]{l: NewLatch(m.sz)}
+		m.m[k] = e
+	}
+	return e
+}
+
+// set is Set. Caller must hold m.mu.
+func (m *LatchMap[K, V]) set(k K, v V) {
+	e := m.entry(k)
+	e.val, e.has = v, true
+	e.l.Bcast(&Packet{Item: v})
+}
+
+// del is Delete. Caller must hold m.mu.
+func (m *LatchMap[K, V]) del(k K) {
+	e, ok := m.m[k]
+	if !ok {
+		return
+	}
+	var zero V
+	e.val, e.has = zero, false
+	e.l.Clear()
+	m.gc(k, e)
+}
+
+// gc drops k's entry if it is no longer in use.
+// Caller must hold m.mu.
+func (m *LatchMap[K, V]) gc(k K, e *mapEntry[V]) {
+	if !e.has && e.watchers == 0 {
+		delete(m.m, k)
+	}
+}
+
+// Set gives k the value v, closing its latch.
+func (m *LatchMap[K, V]) Set(k K, v V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(k, v)
+}
+
+// Get returns k's value, and whether it has one.
+func (m *LatchMap[K, V]) Get(k K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.m[k]
+	if !ok || !e.has {
+		var zero V
+		return zero, false
+	}
+	return e.val, true
+}
+
+// Delete removes k's value, opening its latch.
+func (m *LatchMap[K, V]) Delete(k K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.del(k)
+}
+
+// Watch returns the latch for k, which is closed while
+// k has a value. Call unwatch when done with it, so that
+// the latch can be dropped once k is also deleted.
+// Latches are reused: watching an in-use key again
+// returns the same latch.
+func (m *LatchMap[K, V]) Watch(k K) (l *Latch, unwatch func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.entry(k)
+	e.watchers++
+	var once sync.Once
+	return e.l, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			e.watchers--
+			if m.m[k] == e {
+				m.gc(k, e)
+			}
+		})
+	}
+}
+
+// Len returns the number of keys in use: those with
+// a value, or with watchers.
+func (m *LatchMap[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.m)
+}
+
+// Txn stages Set and Delete operations for
+// LatchMap.Update.
+type Txn[K comparable, V any] struct {
+	ops []txnOp[K, V]
+}
+
+type txnOp[K comparable, V any] struct {
+	k   K
+	v   V
+	del bool
+}
+
+// Set stages setting k to v.
+func (t *Txn[K, V]) Set(k K, v V) {
+	t.ops = append(t.ops, txnOp[K, V]{k: k, v: v})
+}
+
+// Delete stages deleting k.
+func (t *Txn[K, V]) Delete(k K) {
+	t.ops = append(t.ops, txnOp[K, V]{k: k, del: true})
+}
+
+// Update calls fn to stage operations on txn, then
+// applies them all, in order, in one critical section,
+// so a multi-key configuration is never observed half
+// applied: Get and Snapshot see either none or all of
+// them, and so do watchers, since the keys' latches
+// change together, as by a Tx. A watcher that sees
+// one key's new value through Peek, Observe, Read or
+// the like, then finds every other key of the Update
+// changed too. (As with Tx, receivers on Ch() are the
+// exception.) fn must not call other LatchMap methods.
+func (m *LatchMap[K, V]) Update(fn func(txn *Txn[K, V])) {
+	var txn Txn[K, V]
+	fn(&txn)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tx := Begin()
+	var deleted []K
+	for _, op := range txn.ops {
+		if op.del {
+			e, ok := m.m[op.k]
+			if !ok {
+				continue
+			}
+			var zero V
+			e.val, e.has = zero, false
+			tx.Open(e.l)
+			deleted = append(deleted, op.k)
+		} else {
+			e := m.entry(op.k)
+			e.val, e.has = op.v, true
+			tx.Set(e.l, &Packet{Item: op.v})
+		}
+	}
+	// our latches have no options to refuse with.
+	tx.Commit()
+	for _, k := range deleted {
+		if e, ok := m.m[k]; ok {
+			m.gc(k, e)
+		}
+	}
+}
+
+// Snapshot returns a copy of every key's value,
+// read atomically.
+func (m *LatchMap[K, V]) Snapshot() map[K]V {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snap := make(map[K]V, len(m.m))
+	for k, e := range m.m {
+		if e.has {
+			snap[k] = e.val
+		}
+	}
+	return snap
+}
@@ -0,0 +1,114 @@
+package latch
+
+import "testing"
+
+func TestLatchMap(t *testing.T) {
+
+	m := NewLatchMap[string, int](1)
+
+	l, unwatch := m.Watch("shard-1")
+	if l.IsClosed() {
+		t.Fatal("a key with no value should have an open latch")
+	}
+	m.Set("shard-1", 7)
+	if b := <-l.Ch(); b.Item != 7 {
+		t.Fatalf("watcher should see the Set, got %v", b.Item)
+	}
+	if v, ok := m.Get("shard-1"); !ok || v != 7 {
+		t.Fatal("Get should return the Set value")
+	}
+
+	m.Delete("shard-1")
+	if l.IsClosed() {
+		t.Fatal("Delete should open the key's latch")
+	}
+	if m.Len() != 1 {
+		t.Fatal("a watched key should be kept")
+	}
+	unwatch()
+	if m.Len() != 0 {
+		t.Fatal("an unwatched, deleted, key should be dropped")
+	}
+}
+
+func TestLatchMapUpdate(t *testing.T) {
+
+	m := NewLatchMap[string, string](1)
+	m.Set("db", "primary")
+
+	stop := make(chan struct{})
+	torn := make(chan map[string]string, 1)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			snap := m.Snapshot()
+			_, hasDB := snap["db"]
+			_, hasCache := snap["cache"]
+			if hasDB == hasCache {
+				// both or neither would mean a partial update.
+				torn <- snap
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		m.Update(func(txn *Txn[string, string]) {
+			txn.Delete("db")
+			txn.Set("cache", "warm")
+		})
+		m.Update(func(txn *Txn[string, string]) {
+			txn.Delete("cache")
+			txn.Set("db", "primary")
+		})
+	}
+	close(stop)
+
+	select {
+	case snap := <-torn:
+		t.Fatalf("observed a partially applied update: %v", snap)
+	default:
+	}
+}
+
+func TestLatchMapUpdateWatchers(t *testing.T) {
+
+	// a watcher of the first key checks the last.
+	const keys, n = 64, 2000
+	m := NewLatchMap[int, int](1)
+	first, unwatchFirst := m.Watch(0)
+	defer unwatchFirst()
+	last, unwatchLast := m.Watch(keys - 1)
+	defer unwatchLast()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= n; i++ {
+			m.Update(func(txn *Txn[int, int]) {
+				for k := 0; k < keys; k++ {
+					txn.Set(k, i)
+				}
+			})
+		}
+	}()
+
+	for {
+		pf, _, next := first.Observe()
+		if pf != nil {
+			pl := last.Peek()
+			if pl == nil || pl.Item.(int) < pf.Item.(int) {
+				t.Fatalf("saw key 0 = %v with key %v = %v: a partial Update", pf.Item, keys-1, pl)
+			}
+			if pf.Item.(int) == n {
+				break
+			}
+		}
+		<-next
+	}
+	<-done
+}
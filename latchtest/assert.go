@@ -0,0 +1,77 @@
+package latchtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/glycerine/latch"
+)
+
+// EventuallyClosed waits up to timeout for l to close, and
+// returns its value; failing t if it doesn't close in time.
+// It returns as soon as l closes, so unlike a sleep it
+// costs nothing when the code under test is quick.
+func EventuallyClosed(t testing.TB, l latch.Latcher, timeout time.Duration) *latch.Packet {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	pak, err := l.Read(ctx)
+	if err != nil {
+		t.Fatalf("latchtest: latch still open after %v", timeout)
+	}
+	return pak
+}
+
+// ClosedWith fails t unless l is closed now, with an Item
+// reflect.DeepEqual to want.Item, and an Err matching
+// want.Err by errors.Is (or both nil). A nil want expects
+// l closed with a nil Packet, as by Bcast(nil).
+func ClosedWith(t testing.TB, l latch.Latcher, want *latch.Packet) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // don't wait; Read still reports a closed latch.
+	got, err := l.Read(ctx)
+	if err != nil {
+		t.Fatalf("latchtest: expected latch closed with %v, but it is open", describe(want))
+		return
+	}
+	if !samePacket(got, want) {
+		t.Fatalf("latchtest: expected latch closed with %v, got %v", describe(want), describe(got))
+	}
+}
+
+// samePacket reports whether got matches want, as
+// ClosedWith describes.
+func samePacket(got, want *latch.Packet) bool {
+	if got == nil || want == nil {
+		return got == want
+	}
+	return reflect.DeepEqual(got.Item, want.Item) &&
+		(want.Err == nil) == (got.Err == nil) &&
+		errors.Is(got.Err, want.Err)
+}
+
+// RemainsOpen fails t if l is closed at any point in the
+// next d. It always takes d to pass, so keep d short.
+func RemainsOpen(t testing.TB, l latch.Latcher, d time.Duration) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	if pak, err := l.Read(ctx); err == nil {
+		t.Fatalf("latchtest: expected latch to remain open for %v, but it closed with %v", d, describe(pak))
+	}
+}
+
+func describe(pak *latch.Packet) string {
+	if pak == nil {
+		return "nil"
+	}
+	if pak.Err != nil {
+		return fmt.Sprintf("{Item: %#v, Err: %v}", pak.Item, pak.Err)
+	}
+	return fmt.Sprintf("{Item: %#v}", pak.Item)
+}
@@ -0,0 +1,79 @@
+package latchtest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glycerine/latch"
+)
+
+// recorder is a testing.TB that notes, rather than
+// acts on, a failure.
+type recorder struct {
+	testing.TB
+	failed string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failed = fmt.Sprintf(format, args...)
+}
+
+func TestAssertions(t *testing.T) {
+
+	boom := errors.New("boom")
+	l := latch.NewLatch(1)
+
+	r := &recorder{TB: t}
+	RemainsOpen(r, l, 10*time.Millisecond)
+	if r.failed != "" {
+		t.Fatalf("RemainsOpen failed on an open latch: %v", r.failed)
+	}
+	EventuallyClosed(r, l, 10*time.Millisecond)
+	if !strings.Contains(r.failed, "still open") {
+		t.Fatalf("EventuallyClosed should fail on an open latch, got %q", r.failed)
+	}
+
+	time.AfterFunc(5*time.Millisecond, func() {
+		l.Bcast(&latch.Packet{Item: []int{1, 2}, Err: boom})
+	})
+	if pak := EventuallyClosed(t, l, 5*time.Second); pak.Err != boom {
+		t.Fatalf("unexpected value %v", pak)
+	}
+	ClosedWith(t, l, &latch.Packet{Item: []int{1, 2}, Err: boom})
+
+	r = &recorder{TB: t}
+	ClosedWith(r, l, &latch.Packet{Item: []int{1, 3}})
+	if !strings.Contains(r.failed, "got {Item: []int{1, 2}, Err: boom}") {
+		t.Fatalf("ClosedWith should describe the mismatch, got %q", r.failed)
+	}
+	r = &recorder{TB: t}
+	RemainsOpen(r, l, time.Millisecond)
+	if !strings.Contains(r.failed, "but it closed") {
+		t.Fatalf("RemainsOpen should fail on a closed latch, got %q", r.failed)
+	}
+}
+
+func TestAssertionsBcastNil(t *testing.T) {
+
+	l := latch.NewLatch(1)
+	l.Bcast(nil)
+	ClosedWith(t, l, nil)
+
+	r := &recorder{TB: t}
+	ClosedWith(r, l, &latch.Packet{Item: "x"})
+	if !strings.Contains(r.failed, "got nil") {
+		t.Fatalf("ClosedWith should report a nil value, got %q", r.failed)
+	}
+
+	l.Bcast(&latch.Packet{Item: "x"})
+	r = &recorder{TB: t}
+	ClosedWith(r, l, nil)
+	if !strings.Contains(r.failed, "expected latch closed with nil") {
+		t.Fatalf("ClosedWith should report expecting nil, got %q", r.failed)
+	}
+}
@@ -0,0 +1,151 @@
+package latchtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/glycerine/latch"
+)
+
+// Clock is a fake latch.Clock, whose time only moves when
+// Advance is called. Pass it to latches with latch.WithClock
+// to test deadlines, watchdogs, and refreshers without
+// sleeping.
+type Clock struct {
+	mut    sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+var _ latch.Clock = (*Clock)(nil)
+
+// NewClock makes a Clock reading start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the Clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.now
+}
+
+// AfterFunc arranges for f to be called once
+// the Clock has been Advanced by d.
+func (c *Clock) AfterFunc(d time.Duration, f func()) latch.Timer {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	t := &fakeTimer{c: c, f: f}
+	t.arm(d)
+	return t
+}
+
+// NewTicker returns a Ticker that ticks every d
+// of Advanced time. Like a time.Ticker, it drops
+// ticks that its reader isn't ready for.
+func (c *Clock) NewTicker(d time.Duration) latch.Ticker {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	t := &fakeTimer{c: c, period: d, ch: make(chan time.Time, 1)}
+	t.arm(d)
+	return fakeTicker{t}
+}
+
+// Advance moves the Clock forward by d, firing any timers
+// and tickers that fall due, in order of their deadlines.
+// AfterFunc funcs run synchronously, before Advance returns.
+func (c *Clock) Advance(d time.Duration) {
+	c.mut.Lock()
+	end := c.now.Add(d)
+	for {
+		t := c.next(end)
+		if t == nil {
+			break
+		}
+		c.now = t.when
+		if t.period > 0 {
+			t.when = t.when.Add(t.period)
+			select {
+			case t.ch <- c.now:
+			default:
+			}
+			continue
+		}
+		t.active = false
+		c.mut.Unlock()
+		t.f()
+		c.mut.Lock()
+	}
+	c.now = end
+	c.mut.Unlock()
+}
+
+// next returns the earliest active timer due by end,
+// or nil. Caller must hold c.mut.
+func (c *Clock) next(end time.Time) *fakeTimer {
+	var first *fakeTimer
+	live := c.timers[:0]
+	for _, t := range c.timers {
+		if !t.active {
+			t.queued = false
+			continue
+		}
+		live = append(live, t)
+		if !t.when.After(end) && (first == nil || t.when.Before(first.when)) {
+			first = t
+		}
+	}
+	c.timers = live
+	return first
+}
+
+// fakeTimer is a Timer (f set), or
+// underlies a fakeTicker (period and ch set).
+type fakeTimer struct {
+	c      *Clock
+	when   time.Time
+	active bool
+	queued bool // in c.timers.
+	f      func()
+	period time.Duration
+	ch     chan time.Time
+}
+
+// arm schedules t for d from now. Caller must hold t.c.mut.
+func (t *fakeTimer) arm(d time.Duration) {
+	t.when = t.c.now.Add(d)
+	t.active = true
+	if !t.queued {
+		t.queued = true
+		t.c.timers = append(t.c.timers, t)
+	}
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.c.mut.Lock()
+	defer t.c.mut.Unlock()
+	was := t.active
+	t.active = false
+	return was
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.c.mut.Lock()
+	defer t.c.mut.Unlock()
+	was := t.active
+	t.arm(d)
+	return was
+}
+
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (t fakeTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
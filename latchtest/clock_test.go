@@ -0,0 +1,65 @@
+package latchtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glycerine/latch"
+)
+
+func TestClock(t *testing.T) {
+
+	clk := NewClock(time.Unix(0, 0))
+	opt := latch.WithClock(clk)
+
+	d := latch.NewDeadlineLatch(1, time.Minute, &latch.Packet{Item: "late"}, opt)
+	w := latch.NewWatchdog(1, 10*time.Second, opt)
+
+	clk.Advance(8 * time.Second)
+	w.Pet()
+	clk.Advance(8 * time.Second)
+	if w.IsClosed() {
+		t.Fatal("a petted watchdog should not fire")
+	}
+	clk.Advance(2 * time.Second)
+	ClosedWith(t, w, &latch.Packet{Err: latch.ErrWatchdogTimeout})
+
+	if d.IsClosed() {
+		t.Fatal("deadline should not have passed yet")
+	}
+	clk.Advance(time.Hour)
+	ClosedWith(t, d, &latch.Packet{Item: "late"})
+	if got := clk.Now(); !got.Equal(time.Unix(0, 0).Add(time.Hour + 18*time.Second)) {
+		t.Fatalf("unexpected time %v", got)
+	}
+
+	// BackgroundRefresher runs off a ticker.
+	l := latch.NewLatch(1, opt)
+	l.Bcast(&latch.Packet{Item: 1})
+	l.BackgroundRefresher()
+	defer l.Stop()
+	<-l.Ch()
+	clk.Advance(500 * time.Millisecond)
+	EventuallyClosed(t, l, time.Second)
+	select {
+	case <-l.Ch():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the refresher should have topped up Ch")
+	}
+}
+
+func TestClockCloseAt(t *testing.T) {
+
+	clk := NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := latch.NewLatch(1, latch.WithClock(clk))
+	l.CloseAt(time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC), &latch.Packet{Item: "read-only"})
+
+	clk.Advance(2*time.Hour - time.Nanosecond)
+	if l.IsClosed() {
+		t.Fatal("closed before 02:00")
+	}
+	clk.Advance(time.Nanosecond)
+	if !l.IsClosed() {
+		t.Fatal("should close at 02:00")
+	}
+}
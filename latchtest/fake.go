@@ -0,0 +1,148 @@
+/*
+Package latchtest helps test code that uses latches.
+
+Fake is a latch.Latcher whose transitions a test
+scripts, and steps through, one at a time; and which
+records the Bcast and Clear calls made on it by the
+code under test, so those can be asserted on.
+*/
+package latchtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/glycerine/latch"
+)
+
+// Op names a call made on a Fake.
+type Op string
+
+const (
+	OpBcast Op = "Bcast"
+	OpClear Op = "Clear"
+)
+
+// Call records one Bcast or Clear made on a Fake.
+// Packet is nil for a Clear.
+type Call struct {
+	Op     Op
+	Packet *latch.Packet
+}
+
+// Fake is a controllable latch.Latcher. It behaves like
+// a real latch of the same size, and in addition:
+//
+//   - Script queues transitions, which Step applies one
+//     at a time, when the test is ready for them;
+//   - Calls reports the Bcast and Clear calls made by the
+//     code under test (scripted Steps are not recorded).
+type Fake struct {
+	l *latch.Latch
+
+	mut    sync.Mutex
+	script []*latch.Packet
+	calls  []Call
+}
+
+var _ latch.Latcher = (*Fake)(nil)
+
+// NewFake makes an open Fake of size sz.
+func NewFake(sz int) *Fake {
+	return &Fake{l: latch.NewLatch(sz)}
+}
+
+// Latch returns the real latch behind the Fake,
+// for code that needs a *latch.Latch.
+func (f *Fake) Latch() *latch.Latch {
+	return f.l
+}
+
+// Bcast closes the Fake with pak, and records the call.
+func (f *Fake) Bcast(pak *latch.Packet) {
+	f.record(Call{Op: OpBcast, Packet: pak})
+	f.l.Bcast(pak)
+}
+
+// Clear opens the Fake, and records the call.
+func (f *Fake) Clear() {
+	f.record(Call{Op: OpClear})
+	f.l.Clear()
+}
+
+// Ch is the Latcher Ch.
+func (f *Fake) Ch() <-chan *latch.Packet {
+	return f.l.Ch()
+}
+
+// Read is the Latcher Read.
+func (f *Fake) Read(ctx context.Context) (*latch.Packet, error) {
+	return f.l.Read(ctx)
+}
+
+// IsClosed is the Latcher IsClosed.
+func (f *Fake) IsClosed() bool {
+	return f.l.IsClosed()
+}
+
+func (f *Fake) record(c Call) {
+	f.mut.Lock()
+	f.calls = append(f.calls, c)
+	f.mut.Unlock()
+}
+
+// Script queues transitions for Step to apply: a
+// non-nil Packet closes the Fake with it, and a nil
+// Packet opens it.
+func (f *Fake) Script(steps ...*latch.Packet) {
+	f.mut.Lock()
+	f.script = append(f.script, steps...)
+	f.mut.Unlock()
+}
+
+// Step applies the next scripted transition, returning
+// false if the script is exhausted.
+func (f *Fake) Step() bool {
+	f.mut.Lock()
+	if len(f.script) == 0 {
+		f.mut.Unlock()
+		return false
+	}
+	pak := f.script[0]
+	f.script = f.script[1:]
+	f.mut.Unlock()
+
+	if pak == nil {
+		f.l.Clear()
+	} else {
+		f.l.Bcast(pak)
+	}
+	return true
+}
+
+// Calls returns the Bcast and Clear calls made so far.
+func (f *Fake) Calls() []Call {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// AssertOps fails t unless the calls made on f so
+// far were exactly the given ops, in order.
+func (f *Fake) AssertOps(t testing.TB, ops ...Op) {
+	t.Helper()
+	calls := f.Calls()
+	got := make([]Op, len(calls))
+	for i, c := range calls {
+		got[i] = c.Op
+	}
+	if len(got) != len(ops) {
+		t.Fatalf("latchtest: expected calls %v, got %v", ops, got)
+	}
+	for i := range ops {
+		if got[i] != ops[i] {
+			t.Fatalf("latchtest: expected calls %v, got %v", ops, got)
+		}
+	}
+}
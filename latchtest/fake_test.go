@@ -0,0 +1,50 @@
+package latchtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glycerine/latch"
+)
+
+// worker is code under test: it runs until
+// stop closes, then acknowledges on done.
+func worker(stop, done latch.Latcher, ran chan<- bool) {
+	for {
+		select {
+		case <-stop.Ch():
+			done.Bcast(&latch.Packet{Item: "stopped"})
+			return
+		case ran <- true:
+		}
+	}
+}
+
+func TestFake(t *testing.T) {
+
+	stop := NewFake(1)
+	done := NewFake(1)
+	stop.Script(&latch.Packet{Item: "shutdown"})
+
+	ran := make(chan bool)
+	go worker(stop, done, ran)
+	<-ran
+	<-ran
+
+	if !stop.Step() {
+		t.Fatal("expected a scripted step")
+	}
+	if stop.Step() {
+		t.Fatal("the script should be exhausted")
+	}
+
+	pak, err := done.Read(context.Background())
+	if err != nil || pak.Item != "stopped" {
+		t.Fatalf("worker should have acknowledged, got %v, %v", pak, err)
+	}
+	done.AssertOps(t, OpBcast)
+	stop.AssertOps(t) // scripted steps are not calls.
+	if !stop.IsClosed() {
+		t.Fatal("stop should be closed")
+	}
+}
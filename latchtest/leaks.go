@@ -0,0 +1,138 @@
+package latchtest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glycerine/latch"
+)
+
+// leakGrace is how long NoLeaks waits for goroutines
+// that are on their way out.
+const leakGrace = 2 * time.Second
+
+// NoLeaks fails t if, when the test ends, more of the
+// latch package's own goroutines are running than when
+// NoLeaks was called: refreshers (Latch, LatchArray and
+// RefresherPool), Hub slow-subscriber checkers, and the
+// rest; a Watchdog runs on a timer, and needs no
+// goroutine. Those are the goroutines labeled with a
+// latch.LabelRole, including any that callers start with
+// latch.Go. Each leaked goroutine is reported by its
+// role, with its stack. Call it first thing in a test:
+//
+//	func TestX(t *testing.T) {
+//		latchtest.NoLeaks(t)
+//		...
+//	}
+//
+// Goroutines are told apart by their pprof labels, so
+// NoLeaks is not confused by tests running in parallel
+// with unrelated goroutines; but it can't tell latch
+// goroutines of parallel tests apart from this test's.
+func NoLeaks(t testing.TB) {
+	t.Helper()
+	before := countRoles(labeled())
+	t.Cleanup(func() {
+		var leaked []goroutines
+		deadline := time.Now().Add(leakGrace)
+		for {
+			leaked = leaked[:0]
+			now := labeled()
+			after := countRoles(now)
+			for _, g := range now {
+				if after[g.role] > before[g.role] {
+					leaked = append(leaked, g)
+				}
+			}
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if len(leaked) == 0 {
+			return
+		}
+		var b strings.Builder
+		for _, g := range leaked {
+			fmt.Fprintf(&b, "\n%d x %s goroutine:\n%s", g.n, g.role, g.stack)
+		}
+		t.Errorf("latchtest: leaked goroutines:%s", b.String())
+	})
+}
+
+// goroutines is a group of n goroutines with the same
+// role and stack.
+type goroutines struct {
+	n     int
+	role  string
+	stack string
+}
+
+func countRoles(gs []goroutines) map[string]int {
+	m := make(map[string]int)
+	for _, g := range gs {
+		m[g.role] += g.n
+	}
+	return m
+}
+
+// labeled returns the groups of running goroutines that
+// carry a latch role label, from the debug=1 goroutine
+// profile, which groups goroutines by stack and labels.
+func labeled() []goroutines {
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 1)
+
+	var gs []goroutines
+	var cur *goroutines
+	sc := bufio.NewScanner(&buf)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			cur = nil
+		case strings.Contains(line, " @ 0x"):
+			n, _ := strconv.Atoi(line[:strings.Index(line, " ")])
+			gs = append(gs, goroutines{n: n})
+			cur = &gs[len(gs)-1]
+		case cur == nil:
+		case strings.HasPrefix(line, "# labels: "):
+			cur.role = labelValue(line, latch.LabelRole)
+		case strings.HasPrefix(line, "#\t"):
+			cur.stack += "\t" + strings.TrimSpace(line[2:]) + "\n"
+		}
+	}
+	out := gs[:0]
+	for _, g := range gs {
+		if g.role != "" {
+			out = append(out, g)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].role < out[j].role })
+	return out
+}
+
+// labelValue extracts key's value from a profile's
+// `# labels: {"k":"v", ...}` line.
+func labelValue(line, key string) string {
+	q := strconv.Quote(key) + ":"
+	i := strings.Index(line, q)
+	if i < 0 {
+		return ""
+	}
+	v, err := strconv.QuotedPrefix(line[i+len(q):])
+	if err != nil {
+		return ""
+	}
+	s, _ := strconv.Unquote(v)
+	return s
+}
@@ -0,0 +1,59 @@
+package latchtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glycerine/latch"
+)
+
+// leakRecorder captures Errorf, and runs Cleanups on demand.
+type leakRecorder struct {
+	testing.TB
+	errs     []string
+	cleanups []func()
+}
+
+func (r *leakRecorder) Helper()          {}
+func (r *leakRecorder) Cleanup(f func()) { r.cleanups = append(r.cleanups, f) }
+func (r *leakRecorder) Errorf(f string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(f, args...))
+}
+
+func (r *leakRecorder) end() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+func TestNoLeaks(t *testing.T) {
+	l := latch.NewLatch(1)
+
+	rec := &leakRecorder{TB: t}
+	NoLeaks(rec)
+	l.BackgroundRefresher()
+	l.Stop()
+	rec.end()
+	if len(rec.errs) != 0 {
+		t.Fatalf("unexpected leak report: %v", rec.errs)
+	}
+
+	rec = &leakRecorder{TB: t}
+	NoLeaks(rec)
+	l = latch.NewLatch(1)
+	l.BackgroundRefresher()
+	defer l.Stop()
+	start := time.Now()
+	rec.end()
+	if len(rec.errs) == 0 {
+		t.Fatal("leaked refresher not reported")
+	}
+	if !strings.Contains(rec.errs[len(rec.errs)-1], "refresher goroutine") {
+		t.Fatalf("report doesn't name the refresher: %v", rec.errs)
+	}
+	if time.Since(start) < leakGrace {
+		t.Fatal("NoLeaks didn't wait for stragglers")
+	}
+}
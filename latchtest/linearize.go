@@ -0,0 +1,171 @@
+package latchtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/glycerine/latch"
+	"github.com/glycerine/latch/internal/model"
+)
+
+// Kind is the kind of an Event.
+type Kind = model.Kind
+
+const (
+	Close = model.Close // Bcast(Packet).
+	Open  = model.Open  // Clear().
+	Read  = model.Read  // non-blocking read: Packet, or nil if open.
+)
+
+// Event is one operation in a recorded history.
+// Call and Return are logical timestamps: the
+// operation took effect somewhere between them.
+type Event struct {
+	Kind   Kind
+	Packet *latch.Packet // Close's argument, or Read's result.
+	Call   int64
+	Return int64
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%v(%p)@[%d,%d]", e.Kind, e.Packet, e.Call, e.Return)
+}
+
+// Recorder wraps a latch.Latcher, recording the Close,
+// Open, and Read operations made through it, from any
+// number of goroutines, for CheckLinearizable. Wrap
+// alternative latch implementations to check that they
+// behave as a latch should.
+type Recorder struct {
+	l     latch.Latcher
+	clock atomic.Int64
+
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewRecorder records operations on l.
+func NewRecorder(l latch.Latcher) *Recorder {
+	return &Recorder{l: l}
+}
+
+func (r *Recorder) record(k Kind, do func() *latch.Packet) *latch.Packet {
+	call := r.clock.Add(1)
+	pak := do()
+	ret := r.clock.Add(1)
+	r.mu.Lock()
+	r.events = append(r.events, Event{Kind: k, Packet: pak, Call: call, Return: ret})
+	r.mu.Unlock()
+	return pak
+}
+
+// Close does, and records, Bcast(pak).
+func (r *Recorder) Close(pak *latch.Packet) {
+	r.record(Close, func() *latch.Packet {
+		r.l.Bcast(pak)
+		return pak
+	})
+}
+
+// Open does, and records, Clear().
+func (r *Recorder) Open() {
+	r.record(Open, func() *latch.Packet {
+		r.l.Clear()
+		return nil
+	})
+}
+
+// Read does, and records, a non-blocking read:
+// the latch's value, or nil if it is open.
+func (r *Recorder) Read() *latch.Packet {
+	return r.record(Read, func() *latch.Packet {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		pak, _ := r.l.Read(ctx)
+		return pak
+	})
+}
+
+// History returns the events recorded so far.
+func (r *Recorder) History() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// CheckLinearizable reports whether history, starting
+// from an open latch, is linearizable: whether every
+// operation can be assigned a single instant, between
+// its Call and Return, at which it took effect, such
+// that the operations in that order are a legal
+// sequential history of a latch. If not, the error
+// describes the history.
+//
+// The search is exponential in the worst case, so
+// keep histories to a few dozen operations.
+func CheckLinearizable(history []Event) error {
+	c := &checker{
+		events: history,
+		seen:   make(map[string]bool),
+	}
+	if c.search(make([]bool, len(history)), len(history), model.State{}) {
+		return nil
+	}
+	return fmt.Errorf("latchtest: history is not linearizable: %v", history)
+}
+
+type checker struct {
+	events []Event
+	seen   map[string]bool // (done set, state) already explored.
+}
+
+// search tries to linearize the events not yet done,
+// from state s.
+func (c *checker) search(done []bool, left int, s model.State) bool {
+	if left == 0 {
+		return true
+	}
+	key := c.key(done, s)
+	if c.seen[key] {
+		return false
+	}
+	c.seen[key] = true
+
+	// an event may go next if it was called before
+	// every other remaining event returned.
+	minReturn := int64(1<<63 - 1)
+	for i, e := range c.events {
+		if !done[i] && e.Return < minReturn {
+			minReturn = e.Return
+		}
+	}
+	for i, e := range c.events {
+		if done[i] || e.Call > minReturn {
+			continue
+		}
+		next, ok := model.Step(s, e.Kind, e.Packet)
+		if !ok {
+			continue
+		}
+		done[i] = true
+		if c.search(done, left-1, next) {
+			return true
+		}
+		done[i] = false
+	}
+	return false
+}
+
+func (c *checker) key(done []bool, s model.State) string {
+	b := make([]byte, len(done), len(done)+20)
+	for i, d := range done {
+		if d {
+			b[i] = '1'
+		} else {
+			b[i] = '0'
+		}
+	}
+	return fmt.Sprintf("%s/%p", b, s.Packet)
+}
@@ -0,0 +1,75 @@
+package latchtest
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/glycerine/latch"
+)
+
+func TestCheckLinearizable(t *testing.T) {
+
+	a, b := &latch.Packet{Item: "a"}, &latch.Packet{Item: "b"}
+
+	// a Read overlapping a Close may see either state.
+	ok := []Event{
+		{Kind: Close, Packet: a, Call: 1, Return: 4},
+		{Kind: Read, Packet: nil, Call: 2, Return: 3},
+		{Kind: Read, Packet: a, Call: 5, Return: 6},
+	}
+	if err := CheckLinearizable(ok); err != nil {
+		t.Fatal(err)
+	}
+
+	// but a Read after the Close returned must see it.
+	bad := []Event{
+		{Kind: Close, Packet: a, Call: 1, Return: 2},
+		{Kind: Read, Packet: nil, Call: 3, Return: 4},
+	}
+	if CheckLinearizable(bad) == nil {
+		t.Fatal("a stale read should not linearize")
+	}
+
+	// and nobody can read a value never written.
+	bad = []Event{
+		{Kind: Close, Packet: a, Call: 1, Return: 2},
+		{Kind: Read, Packet: b, Call: 3, Return: 4},
+	}
+	if CheckLinearizable(bad) == nil {
+		t.Fatal("a phantom read should not linearize")
+	}
+}
+
+// FuzzLatchLinearizable runs random concurrent
+// operations on a real latch, and checks them.
+func FuzzLatchLinearizable(f *testing.F) {
+	for _, seed := range []int64{1, 2, 3, 42} {
+		f.Add(seed, uint8(3), uint8(6))
+	}
+	f.Fuzz(func(t *testing.T, seed int64, goroutines, ops uint8) {
+		g, n := int(goroutines%4)+1, int(ops%8)+1
+		rec := NewRecorder(latch.NewLatch(2))
+		var wg sync.WaitGroup
+		for i := 0; i < g; i++ {
+			wg.Add(1)
+			go func(rng *rand.Rand) {
+				defer wg.Done()
+				for j := 0; j < n; j++ {
+					switch rng.Intn(3) {
+					case 0:
+						rec.Close(&latch.Packet{Item: j})
+					case 1:
+						rec.Open()
+					default:
+						rec.Read()
+					}
+				}
+			}(rand.New(rand.NewSource(seed + int64(i))))
+		}
+		wg.Wait()
+		if err := CheckLinearizable(rec.History()); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package latch
+
+import (
+	"sync"
+	"time"
+)
+
+// LeaseInfo is the Item of a Lease's latch while
+// the lease is held.
+type LeaseInfo struct {
+	ID      uint64 // distinguishes successive grants.
+	TTL     time.Duration
+	Expires time.Time
+}
+
+// Lease is a latch that is closed while a lease is held,
+// for leader election and resource ownership between
+// goroutines. Grant takes the lease for a ttl; Renew
+// extends it by another ttl; and Revoke, or failure to
+// Renew in time, releases it, opening the latch. Watchers
+// see each grant, renewal, and release as a transition.
+type Lease struct {
+	l   *Latch
+	clk Clock
+
+	mu    sync.Mutex
+	info  *LeaseInfo // nil unless held.
+	next  uint64
+	timer Timer
+}
+
+// NewLease makes an unheld Lease, whose latch has a
+// backing channel of size sz, and opts as for NewLatch.
+func NewLease(sz int, opts ...Option) *Lease {
+	l := NewLatch(sz, opts...)
+	return &Lease{l: l, clk: l.clock()}
+}
+
+// Latch returns the Lease's latch.
+func (s *Lease) Latch() *Latch {
+	return s.l
+}
+
+// Grant takes the lease for ttl, and returns its
+// details; or returns false if it is already held.
+func (s *Lease) Grant(ttl time.Duration) (LeaseInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.info != nil {
+		return LeaseInfo{}, false
+	}
+	s.next++
+	s.info = &LeaseInfo{ID: s.next, TTL: ttl}
+	s.extend()
+	return *s.info, true
+}
+
+// Renew extends the held lease by another ttl, from
+// now. It returns false if the lease has expired, or
+// been revoked.
+func (s *Lease) Renew() (LeaseInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.info == nil {
+		return LeaseInfo{}, false
+	}
+	info := *s.info // readers may hold the old one.
+	s.info = &info
+	s.extend()
+	return info, true
+}
+
+// Revoke releases the lease early. It returns
+// false if it wasn't held.
+func (s *Lease) Revoke() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.info == nil {
+		return false
+	}
+	s.release()
+	return true
+}
+
+// Held returns the current lease, if any.
+func (s *Lease) Held() (LeaseInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.info == nil {
+		return LeaseInfo{}, false
+	}
+	return *s.info, true
+}
+
+// extend sets the expiry a ttl from now, publishes
+// the lease, and arms its timer. Caller must hold s.mu.
+func (s *Lease) extend() {
+	s.info.Expires = s.clk.Now().Add(s.info.TTL)
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.l.Bcast(&Packet{Item: *s.info})
+	id, expires := s.info.ID, s.info.Expires
+	s.timer = s.clk.AfterFunc(s.info.TTL, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// ignore a timer that raced a Renew or Revoke.
+		if s.info != nil && s.info.ID == id && s.info.Expires.Equal(expires) {
+			s.release()
+		}
+	})
+}
+
+// release opens the latch. Caller must hold s.mu.
+func (s *Lease) release() {
+	s.info = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.l.Clear()
+}
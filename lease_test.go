@@ -0,0 +1,56 @@
+package latch
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestLease(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		s := NewLease(1)
+		_, _, next := s.Latch().Observe()
+
+		info, ok := s.Grant(10 * time.Second)
+		if !ok || info.ID != 1 {
+			t.Fatalf("Grant got %v, %v", info, ok)
+		}
+		if _, ok := s.Grant(time.Second); ok {
+			t.Fatal("a held lease should not be granted again")
+		}
+		<-next // watchers see the grant.
+
+		time.Sleep(8 * time.Second)
+		if renewed, ok := s.Renew(); !ok || !renewed.Expires.After(info.Expires) {
+			t.Fatalf("Renew got %v, %v", renewed, ok)
+		}
+		time.Sleep(8 * time.Second)
+		synctest.Wait()
+		if !s.Latch().IsClosed() {
+			t.Fatal("a renewed lease should still be held")
+		}
+
+		time.Sleep(3 * time.Second)
+		synctest.Wait()
+		if s.Latch().IsClosed() {
+			t.Fatal("the lease should have expired")
+		}
+		if _, ok := s.Renew(); ok {
+			t.Fatal("an expired lease can't be renewed")
+		}
+
+		info, _ = s.Grant(time.Minute)
+		if info.ID != 2 {
+			t.Fatalf("expected a fresh grant, got %v", info)
+		}
+		if got := s.Latch().Peek().Item.(LeaseInfo); got != info {
+			t.Fatalf("the latch should carry the lease, got %v", got)
+		}
+		if !s.Revoke() || s.Revoke() {
+			t.Fatal("Revoke should succeed exactly once")
+		}
+		if s.Latch().IsClosed() {
+			t.Fatal("a revoked lease should open the latch")
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package latch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// WithLogger makes a latch log its transitions to logger,
+// with the new version, the Item's type, and any Err.
+// To tell latches apart, give each its own logger, such as
+// logger.With("latch", name).
+//
+// Refresh also logs when it finds Ch() drained dry: readers
+// may have blocked since the last Refresh, and sz, or the
+// Refresh rate, may be too low.
+//
+// Transitions log at slog.LevelDebug, and starvation at
+// slog.LevelWarn, unless changed by WithLogLevels.
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *Latch) {
+		r.logs().logger = logger
+	}
+}
+
+// WithLogLevels sets the levels WithLogger uses
+// for transitions, and for Refresh starvation.
+func WithLogLevels(transition, starvation slog.Level) Option {
+	return func(r *Latch) {
+		lg := r.logs()
+		lg.transition = transition
+		lg.starvation = starvation
+	}
+}
+
+type logging struct {
+	logger     *slog.Logger
+	transition slog.Level
+	starvation slog.Level
+	closed     bool
+}
+
+// logs returns r's logging config, making it if need be.
+// Only called by options, before r is shared.
+func (r *Latch) logs() *logging {
+	if r.logging == nil {
+		r.logging = &logging{
+			transition: slog.LevelDebug,
+			starvation: slog.LevelWarn,
+		}
+	}
+	return r.logging
+}
+
+// logTransition logs the transition r just made.
+// Caller must hold r.mut.
+func (lg *logging) logTransition(r *Latch) {
+	msg := "latch opened"
+	switch {
+	case r.avail && lg.closed:
+		msg = "latch set"
+	case r.avail:
+		msg = "latch closed"
+	}
+	lg.closed = r.avail
+	if lg.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{slog.Uint64("version", r.version)}
+	if r.avail && r.cur != nil {
+		attrs = append(attrs, slog.String("type", fmt.Sprintf("%T", r.cur.Item)))
+		if r.cur.Err != nil {
+			attrs = append(attrs, slog.Any("err", r.cur.Err))
+		}
+	}
+	lg.logger.LogAttrs(context.Background(), lg.transition, msg, attrs...)
+}
+
+// logStarved notes a Refresh that found Ch() empty.
+// Caller must hold r.mut.
+func (lg *logging) logStarved(r *Latch) {
+	if lg.logger == nil {
+		return
+	}
+	lg.logger.LogAttrs(context.Background(), lg.starvation,
+		"latch refresh found channel drained",
+		slog.Uint64("version", r.version), slog.Int("sz", r.c().sz))
+}
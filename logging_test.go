@@ -0,0 +1,52 @@
+package latch
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	l := NewLatch(2, WithLogger(logger.With("latch", "cfg")),
+		WithLogLevels(slog.LevelInfo, slog.LevelError))
+
+	l.Bcast(&Packet{Item: 7})
+	l.Bcast(&Packet{Err: errors.New("boom")})
+	<-l.Ch()
+	<-l.Ch()
+	l.Refresh()
+	l.Clear()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`level=INFO msg="latch closed" latch=cfg version=1 type=int`,
+		`level=INFO msg="latch set" latch=cfg version=2 type=<nil> err=boom`,
+		`level=ERROR msg="latch refresh found channel drained" latch=cfg version=2 sz=2`,
+		`level=INFO msg="latch opened" latch=cfg version=3`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %v log lines, got:\n%v", len(want), buf.String())
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(lines[i], w) {
+			t.Fatalf("line %v: expected suffix %q, got %q", i, w, lines[i])
+		}
+	}
+}
+
+func TestLoggerBcastNil(t *testing.T) {
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	l := NewLatch(1, WithLogger(logger))
+
+	l.Bcast(nil)
+	if !strings.HasSuffix(strings.TrimSpace(buf.String()), `msg="latch closed" version=1`) {
+		t.Fatalf("unexpected log:\n%v", buf.String())
+	}
+}
@@ -0,0 +1,64 @@
+package latch
+
+import "sync/atomic"
+
+// WithLowWater arms LowWater: while the latch is closed,
+// its LowWater channel fires once fewer than n copies
+// remain in Ch(), so applications that won't run a
+// BackgroundRefresher can drive Refresh from their own
+// event loop, just when it is needed.
+//
+// A latch can't see receives on Ch() as they happen, so
+// the check is best-effort: the level is checked
+// whenever Ch() is called, which is before the receive
+// in the usual `<-l.Ch()` idiom, so the receive that
+// takes the level below n is noticed at the next call.
+// TryRecv checks again after its receive, so it is
+// noticed at once. Read takes nothing from Ch(). Served
+// latches (see MaxBufferedSz) never run low.
+func WithLowWater(n int) Option {
+	return func(r *Latch) {
+		r.low = &lowWater{n: n}
+		r.low.sig.Store(&lowSignal{ch: make(chan struct{})})
+	}
+}
+
+type lowWater struct {
+	n   int
+	sig atomic.Pointer[lowSignal]
+}
+
+type lowSignal struct {
+	ch    chan struct{}
+	fired atomic.Bool
+}
+
+// LowWater returns a channel that is closed when the
+// latch, while closed, runs low on copies; see
+// WithLowWater. After a refill (Refresh, or Bcast),
+// LowWater returns a fresh channel. Without
+// WithLowWater, it returns nil, which never fires.
+func (r *Latch) LowWater() <-chan struct{} {
+	if r.low == nil {
+		return nil
+	}
+	return r.low.sig.Load().ch
+}
+
+// check fires the signal if r is running low.
+func (lw *lowWater) check(r *Latch) {
+	if c := r.c(); !r.closed.Load() || c.served || len(c.ch) >= lw.n {
+		return
+	}
+	if s := lw.sig.Load(); s.fired.CompareAndSwap(false, true) {
+		close(s.ch)
+	}
+}
+
+// refilled re-arms the signal, if it has fired.
+// Caller must hold r.mut.
+func (lw *lowWater) refilled() {
+	if lw.sig.Load().fired.Load() {
+		lw.sig.Store(&lowSignal{ch: make(chan struct{})})
+	}
+}
@@ -0,0 +1,58 @@
+package latch
+
+import "testing"
+
+func TestLowWater(t *testing.T) {
+
+	l := NewLatch(4, WithLowWater(2))
+	if NewLatch(1).LowWater() != nil {
+		t.Fatal("without WithLowWater, LowWater should be nil")
+	}
+	low := l.LowWater()
+	l.Bcast(&Packet{Item: 1})
+
+	<-l.Ch()
+	<-l.Ch()
+	select {
+	case <-low:
+		t.Fatal("two copies left is not yet low")
+	default:
+	}
+	<-l.Ch()
+	l.Ch() // the next read notices.
+	<-low
+
+	l.Refresh()
+	low = l.LowWater()
+	select {
+	case <-low:
+		t.Fatal("a refill should re-arm LowWater")
+	default:
+	}
+	if len(l.Ch()) != 4 {
+		t.Fatal("Refresh should have refilled")
+	}
+}
+
+func TestLowWaterTryRecv(t *testing.T) {
+
+	l := NewLatch(3, WithLowWater(2))
+	low := l.LowWater()
+	l.Bcast(&Packet{Item: 1})
+	if _, err := l.TryRecv(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-low:
+		t.Fatal("two copies left is not yet low")
+	default:
+	}
+	if _, err := l.TryRecv(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-low:
+	default:
+		t.Fatal("TryRecv should notice it took the level low")
+	}
+}
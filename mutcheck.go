@@ -0,0 +1,140 @@
+package latch
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"reflect"
+)
+
+// WithMutationCheck is a debugging aid. Every reader of
+// a latch receives the same *Packet, so a reader that
+// modifies the Item (or anything it points to) silently
+// corrupts what all the others see.
+//
+// With this option the latch checksums the Item, deeply,
+// when it is broadcast; and checks the sum again at the
+// next Bcast, Clear, Peek, or refilling Refresh, panicking
+// if the Item has changed underneath it; such a panic
+// reports a bug, and is not meant to be recovered from.
+// The checks cost
+// a walk of the whole Item each time, so enable this in
+// tests and race-detector builds, not in production.
+func WithMutationCheck() Option {
+	return func(r *Latch) {
+		r.mutCheck = &mutCheck{}
+	}
+}
+
+type mutCheck struct {
+	sum uint64
+}
+
+// record remembers the checksum of r's current value.
+// Caller must hold r.mut.
+func (m *mutCheck) record(r *Latch) {
+	if r.avail {
+		m.sum = packetSum(r.cur)
+	}
+}
+
+// verify panics if r's current value has been
+// modified since record. Caller must hold r.mut.
+func (m *mutCheck) verify(r *Latch) {
+	if r.avail && packetSum(r.cur) != m.sum {
+		panic(fmt.Sprintf("latch: Packet.Item (%T) was modified "+
+			"after Bcast; all readers share it, so it must be "+
+			"treated as read-only. Copy it before changing it.",
+			r.cur.Item))
+	}
+}
+
+// packetSum is the checksum of p's Item; a nil p
+// sums as a nil Item, so a Bcast(nil) can't mismatch.
+func packetSum(p *Packet) uint64 {
+	if p == nil {
+		return checksum(nil)
+	}
+	return checksum(p.Item)
+}
+
+// checksum hashes x, following pointers,
+// slices, and maps, into a single sum.
+func checksum(x interface{}) uint64 {
+	h := fnv.New64a()
+	hashValue(h, reflect.ValueOf(x), make(map[uintptr]bool))
+	return h.Sum64()
+}
+
+func hashValue(h hash.Hash64, v reflect.Value, seen map[uintptr]bool) {
+	var buf [8]byte
+	put := func(u uint64) {
+		binary.LittleEndian.PutUint64(buf[:], u)
+		h.Write(buf[:])
+	}
+	if !v.IsValid() {
+		put(0)
+		return
+	}
+	put(uint64(v.Kind()))
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			put(1)
+		} else {
+			put(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		put(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		put(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		put(math.Float64bits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		put(math.Float64bits(real(c)))
+		put(math.Float64bits(imag(c)))
+	case reflect.String:
+		put(uint64(v.Len()))
+		h.Write([]byte(v.String()))
+	case reflect.Pointer:
+		if v.IsNil() {
+			put(0)
+			return
+		}
+		if seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		hashValue(h, v.Elem(), seen)
+	case reflect.Interface:
+		hashValue(h, v.Elem(), seen)
+	case reflect.Slice, reflect.Array:
+		put(uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i), seen)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hashValue(h, v.Field(i), seen)
+		}
+	case reflect.Map:
+		// map order is random, so sum the entries'
+		// hashes, which is order-independent.
+		var total uint64
+		iter := v.MapRange()
+		for iter.Next() {
+			eh := fnv.New64a()
+			hashValue(eh, iter.Key(), seen)
+			hashValue(eh, iter.Value(), seen)
+			total += eh.Sum64()
+		}
+		put(uint64(v.Len()))
+		put(total)
+	default:
+		// chans, funcs, unsafe pointers: by identity.
+		put(uint64(v.Pointer()))
+	}
+}
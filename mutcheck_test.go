@@ -0,0 +1,66 @@
+package latch
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMutationCheck(t *testing.T) {
+
+	type cfg struct {
+		Hosts []string
+		Opts  map[string]int
+	}
+	c := &cfg{Hosts: []string{"a", "b"}, Opts: map[string]int{"x": 1, "y": 2}}
+	l := NewLatch(2, WithMutationCheck())
+	l.Bcast(&Packet{Item: c})
+
+	// reading is fine.
+	pak := <-l.Ch()
+	_ = pak.Item.(*cfg).Hosts[0]
+	l.Peek()
+
+	// a reader writes through the shared pointer.
+	pak.Item.(*cfg).Opts["y"] = 3
+
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "was modified after Bcast") {
+			t.Fatalf("expected a mutation panic, got %q", msg)
+		}
+	}()
+	l.Peek()
+	t.Fatal("Peek should have panicked")
+}
+
+func TestChecksum(t *testing.T) {
+
+	type node struct {
+		V    int
+		Next *node
+	}
+	n := &node{V: 1}
+	n.Next = n // cycles terminate.
+	a := checksum(n)
+	if a != checksum(n) {
+		t.Fatal("checksum should be stable")
+	}
+	n.V = 2
+	if a == checksum(n) {
+		t.Fatal("checksum should see through pointers")
+	}
+	m := map[string]int{"a": 1, "b": 2, "c": 3}
+	if checksum(m) != checksum(map[string]int{"c": 3, "b": 2, "a": 1}) {
+		t.Fatal("checksum of a map should not depend on order")
+	}
+}
+
+func TestMutationCheckBcastNil(t *testing.T) {
+
+	l := NewLatch(1, WithMutationCheck())
+	l.Bcast(nil)
+	l.Peek()
+	l.Refresh()
+	l.Bcast(&Packet{Item: 1})
+	l.Clear()
+}
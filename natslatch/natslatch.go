@@ -0,0 +1,179 @@
+/*
+Package natslatch bridges a latch to a NATS JetStream
+subject, giving a lightweight multi-node broadcast:
+every Set or Open on any node's Bridge is published to
+the subject, and every node applies what it receives to
+its local latch.
+
+Because JetStream retains messages, a node that joins
+late starts from the last value published on the subject
+(the stream must be configured to capture the subject;
+MaxMsgsPerSubject of 1 is sufficient).
+
+Concurrent writers are reconciled by a Resolution
+policy. Packets travel encoded with a latch.Codec, as for
+persistence and the ipc package, so Items of types given
+to latch.RegisterType arrive as those types.
+*/
+package natslatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/glycerine/latch"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Resolution chooses which of two conflicting
+// writes wins.
+type Resolution int
+
+const (
+	// BySequence: the write stored later in the
+	// JetStream stream wins. All nodes agree,
+	// since there is one stream order.
+	BySequence Resolution = iota
+
+	// ByTimestamp: the write made later by the writer's
+	// wall clock wins, regardless of arrival order; ties
+	// go to the later stream sequence. Only as good as
+	// the writers' clock synchronization.
+	ByTimestamp
+)
+
+// record is the published form of a transition.
+type record struct {
+	Closed bool   `json:"closed"`
+	Packet []byte `json:"packet,omitempty"` // by the latch.Codec.
+	Time   int64  `json:"time"`             // writer's clock, unix nanoseconds.
+}
+
+// Bridge is a local latch kept in step with a
+// JetStream subject.
+type Bridge struct {
+	*latch.Latch
+
+	js      jetstream.JetStream
+	subject string
+	res     Resolution
+	codec   latch.Codec
+	cc      jetstream.ConsumeContext
+
+	mu       sync.Mutex
+	lastSeq  uint64
+	lastTime int64
+}
+
+// New bridges subject, captured by stream, to a new
+// local latch with a backing channel of size sz. Every
+// node on the subject must use the same codec.
+func New(ctx context.Context, js jetstream.JetStream, stream, subject string, sz int, res Resolution, codec latch.Codec) (*Bridge, error) {
+	b := &Bridge{
+		Latch:   latch.NewLatch(sz),
+		js:      js,
+		subject: subject,
+		res:     res,
+		codec:   codec,
+	}
+	cons, err := js.OrderedConsumer(ctx, stream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{subject},
+		DeliverPolicy:  jetstream.DeliverLastPerSubjectPolicy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.cc, err = cons.Consume(func(msg jetstream.Msg) {
+		meta, err := msg.Metadata()
+		if err != nil {
+			return
+		}
+		var rec record
+		if err := json.Unmarshal(msg.Data(), &rec); err != nil {
+			return
+		}
+		b.receive(meta.Sequence.Stream, rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// receive applies rec, stored at stream sequence seq,
+// if the Resolution policy says it wins.
+func (b *Bridge) receive(seq uint64, rec record) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.res {
+	case BySequence:
+		if seq <= b.lastSeq {
+			return
+		}
+	case ByTimestamp:
+		if rec.Time < b.lastTime || (rec.Time == b.lastTime && seq <= b.lastSeq) {
+			return
+		}
+	}
+	b.lastSeq = seq
+	b.lastTime = rec.Time
+
+	if !rec.Closed {
+		b.Clear()
+		return
+	}
+	pak, err := b.codec.Decode(rec.Packet)
+	if err != nil {
+		// close anyway, as the writer did, but
+		// with why we can't say what with.
+		pak = &latch.Packet{Err: fmt.Errorf("natslatch: cannot decode %v: %w", b.subject, err)}
+	}
+	b.Bcast(pak)
+}
+
+// Set closes the latch with pak on every bridged node.
+func (b *Bridge) Set(ctx context.Context, pak *latch.Packet) error {
+	if pak == nil {
+		pak = &latch.Packet{}
+	}
+	data, err := b.codec.Encode(pak)
+	if err != nil {
+		return err
+	}
+	return b.publish(ctx, record{Closed: true, Packet: data})
+}
+
+// Open clears the latch on every bridged node.
+func (b *Bridge) Open(ctx context.Context) error {
+	return b.publish(ctx, record{})
+}
+
+func (b *Bridge) publish(ctx context.Context, rec record) error {
+	rec.Time = time.Now().UnixNano()
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	ack, err := b.js.Publish(ctx, b.subject, data)
+	if err != nil {
+		return err
+	}
+	// apply locally now, rather than waiting for the
+	// echo, so Set is read-your-writes. Round-trip
+	// through the codec so we hold what other nodes will.
+	var echo record
+	if err := json.Unmarshal(data, &echo); err != nil {
+		return err
+	}
+	b.receive(ack.Sequence, echo)
+	return nil
+}
+
+// Detach stops following the subject. The
+// local latch keeps its last state.
+func (b *Bridge) Detach() {
+	b.cc.Stop()
+}
@@ -0,0 +1,101 @@
+package natslatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/glycerine/latch"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func TestBridge(t *testing.T) {
+
+	srv, err := server.NewServer(&server.Options{
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	defer srv.Shutdown()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:              "LATCHES",
+		Subjects:          []string{"latch.>"},
+		MaxMsgsPerSubject: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := New(ctx, js, "LATCHES", "latch.config", 1, BySequence, latch.JSONCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Detach()
+
+	if err := a.Set(ctx, &latch.Packet{Item: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	if pak := a.Peek(); pak == nil || pak.Item != "v1" {
+		t.Fatal("Set should be visible locally right away")
+	}
+
+	// a late joiner starts from the last value.
+	b, err := New(ctx, js, "LATCHES", "latch.config", 1, BySequence, latch.JSONCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Detach()
+	waitItem(t, b, "v1")
+
+	if err := b.Set(ctx, &latch.Packet{Item: "v2"}); err != nil {
+		t.Fatal(err)
+	}
+	waitItem(t, a, "v2")
+
+	// registered types arrive as themselves.
+	if err := a.Set(ctx, &latch.Packet{Item: version{Major: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	waitItem(t, b, version{Major: 2})
+}
+
+type version struct{ Major, Minor int }
+
+func init() {
+	latch.RegisterType("natslatch_test.version", version{})
+}
+
+func waitItem(t *testing.T, b *Bridge, want interface{}) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if pak := b.Peek(); pak != nil && pak.Item == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("bridge never saw %v", want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
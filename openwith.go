@@ -0,0 +1,40 @@
+package latch
+
+import "errors"
+
+// ErrReopened is a convenient Err for OpenWith sentinels.
+var ErrReopened = errors.New("latch: reopened")
+
+// OpenWith is Clear, except that readers currently
+// blocked receiving on Ch() are each released once,
+// with sentinel, rather than silently continuing to
+// block. Long-lived readers can thereby tell "the latch
+// was opened" from "nobody ever wrote". For instance:
+//
+//	l.OpenWith(&Packet{Err: ErrReopened})
+//
+// Readers that arrive after OpenWith block as usual.
+// Returns false if refused by WithWriteOnce.
+func (r *Latch) OpenWith(sentinel *Packet) bool {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if !r.clear() {
+		return false
+	}
+	// A send on a channel with a parked receiver hands
+	// the value straight to it. Once nobody is parked,
+	// a send lands in the buffer instead (or, if
+	// unbuffered, doesn't happen); then we are done.
+	ch := r.c().ch
+	for {
+		select {
+		case ch <- sentinel:
+			if len(ch) > 0 {
+				r.drain()
+				return true
+			}
+		default:
+			return true
+		}
+	}
+}
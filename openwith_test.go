@@ -0,0 +1,37 @@
+package latch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOpenWith(t *testing.T) {
+
+	latch := NewLatch(2)
+	got := make(chan *Packet)
+	for i := 0; i < 2; i++ {
+		go func() {
+			got <- <-latch.Ch()
+		}()
+	}
+	// let both readers park.
+	time.Sleep(20 * time.Millisecond)
+
+	reopened := &Packet{Err: ErrReopened}
+	latch.OpenWith(reopened)
+	for i := 0; i < 2; i++ {
+		select {
+		case b := <-got:
+			if b != reopened {
+				t.Fatal("parked readers should get the sentinel")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("parked reader was not released")
+		}
+	}
+	select {
+	case <-latch.Ch():
+		t.Fatal("later readers should block on the open latch")
+	default:
+	}
+}
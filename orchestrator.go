@@ -0,0 +1,417 @@
+package latch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Orchestrator starts the components of a program in
+// dependency order: each is launched only once every
+// component it depends on is ready, so the cache isn't
+// started until the database is up, nor the API until
+// both are. Every component has a ready latch, closed
+// when it signals readiness, and a done latch, closed
+// with its error when it returns. If any component
+// fails, the Failed latch closes, and components not
+// yet launched never are.
+type Orchestrator struct {
+	mu      sync.Mutex
+	comps   []*Component
+	started bool
+	dag     *DAG
+	cancel  context.CancelFunc
+
+	pending  int
+	errs     []error
+	allReady *Latch
+	failed   *Latch
+
+	stopping bool
+	// finished is closed, with the *Report,
+	// once Shutdown completes.
+	finished *Latch
+
+	// for StartupReport.
+	startedAt time.Time
+	upOrder   []*Component
+
+	// clk, if set by SetClock, times startup and Shutdown.
+	clk Clock
+}
+
+// Component is one component of an Orchestrator, as
+// handed to its ComponentFunc.
+type Component struct {
+	o    *Orchestrator
+	name string
+	deps []string
+	fn   ComponentFunc
+	ctx  context.Context
+	once sync.Once
+	tr   tracked
+
+	// for StartupReport; guarded by o.mu.
+	launched time.Time
+	finished time.Time
+	endedBy  string
+	err      error
+
+	ready *Latch
+	stop  *Latch
+	done  *Latch
+}
+
+// ComponentFunc is the body of a component. A component
+// that keeps running once up, a server say, should call
+// c.Ready once it can serve its dependents; one that
+// returns nil is ready when it returns, if it hadn't
+// said so already. It should return once c.Stopping()
+// closes. An error, or a panic, fails the Orchestrator.
+type ComponentFunc func(c *Component) error
+
+// ComponentError is a component's failure, as
+// reported by the Failed latch.
+type ComponentError struct {
+	Name string
+	Err  error
+}
+
+func (e *ComponentError) Error() string {
+	return fmt.Sprintf("latch: component %q: %v", e.Name, e.Err)
+}
+
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// NewOrchestrator makes an empty Orchestrator.
+func NewOrchestrator() *Orchestrator {
+	return &Orchestrator{
+		allReady: NewLatch(1),
+		failed:   NewLatch(1),
+		finished: NewLatch(1),
+	}
+}
+
+// SetClock makes the Orchestrator's reports, and its
+// Shutdown timeouts, use c, as WithClock does for a
+// Latch. Call it before Start.
+func (o *Orchestrator) SetClock(c Clock) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.clk = c
+}
+
+// Add registers component name, depending on deps, to
+// run fn. It returns false, and does nothing, if the
+// Orchestrator has started, or name is already added.
+func (o *Orchestrator) Add(name string, deps []string, fn ComponentFunc) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.started || o.component(name) != nil {
+		return false
+	}
+	o.comps = append(o.comps, &Component{
+		o:     o,
+		name:  name,
+		deps:  append([]string(nil), deps...),
+		fn:    fn,
+		ready: NewLatch(1, WithName(name+".ready")),
+		stop:  NewLatch(1, WithName(name+".stop")),
+		done:  NewLatch(1, WithName(name+".done")),
+	})
+	return true
+}
+
+// component returns the component called name, or nil.
+// Caller must hold o.mu, or have started o.
+func (o *Orchestrator) component(name string) *Component {
+	for _, c := range o.comps {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Start checks the dependencies, as NewDAG does,
+// returning its error; then runs every component in its
+// own goroutine, each waiting for its dependencies to be
+// ready. Components not yet launched give up if ctx is
+// done; those running see ctx, canceled too if any
+// component fails, through Context. Only the first call
+// does anything.
+func (o *Orchestrator) Start(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.started || o.stopping {
+		return nil
+	}
+	nodes := make([]DAGNode, len(o.comps))
+	for i, c := range o.comps {
+		nodes[i] = DAGNode{Name: c.name, Latch: c.ready, Deps: c.deps}
+	}
+	dag, err := NewDAG(nodes...)
+	if err != nil {
+		return err
+	}
+	o.started = true
+	o.startedAt = orReal(o.clk).Now()
+	o.dag = dag
+	ctx, o.cancel = context.WithCancel(ctx)
+	o.pending = len(o.comps)
+	if o.pending == 0 {
+		o.allReady.Bcast(&Packet{})
+	}
+	for _, c := range o.comps {
+		c.ctx = ctx
+		c.tr.name = c.name
+		go c.run()
+	}
+	return nil
+}
+
+func (c *Component) run() {
+	c.tr.begin()
+	defer c.tr.done.Store(true)
+
+	// a Shutdown before we launch means we never do.
+	wait, cancel := context.WithCancel(c.ctx)
+	goLabeled(RoleSupervisor, c.name, func() {
+		select {
+		case <-c.stop.Done():
+			cancel()
+		case <-wait.Done():
+		}
+	})
+	err := c.o.dag.WaitReady(wait, c.name)
+	cancel()
+	if err != nil {
+		// never launched; not a failure of its own.
+		c.done.Bcast(&Packet{Err: err})
+		return
+	}
+	c.o.mu.Lock()
+	c.launched = orReal(c.o.clk).Now()
+	c.o.mu.Unlock()
+	err = c.call()
+	if err != nil {
+		c.o.fail(c, &ComponentError{Name: c.name, Err: err})
+	} else {
+		c.Ready()
+	}
+	c.done.Bcast(&Packet{Err: err})
+}
+
+// call runs c.fn, turning a panic into a *PanicError.
+func (c *Component) call() (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return c.fn(c)
+}
+
+// fail records c's err, closing Failed with every error
+// so far, and stops launching components.
+func (o *Orchestrator) fail(c *Component, err error) {
+	o.mu.Lock()
+	o.finish(c, c.done, err)
+	o.errs = append(o.errs, err)
+	joined := errors.Join(o.errs...)
+	o.mu.Unlock()
+	o.failed.Bcast(&Packet{Err: joined})
+	o.cancel()
+}
+
+// finish records that c's startup step ended, with l
+// closing. Caller must hold o.mu.
+func (o *Orchestrator) finish(c *Component, l *Latch, err error) {
+	if !c.finished.IsZero() {
+		// failed after it was up; startup went fine.
+		return
+	}
+	c.finished = orReal(o.clk).Now()
+	c.endedBy = l.Name()
+	c.err = err
+	o.upOrder = append(o.upOrder, c)
+}
+
+// StartupReport reports the startup so far: a step for
+// each component that became ready, or failed, in that
+// order, ended by its ready or done latch, timed from
+// its launch; and those still Pending. It returns nil
+// before Start.
+func (o *Orchestrator) StartupReport() *Report {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.started {
+		return nil
+	}
+	rep := &Report{Kind: "startup", Start: o.startedAt}
+	var end time.Time
+	for _, c := range o.upOrder {
+		rep.Steps = append(rep.Steps, ReportStep{
+			Name:  c.name,
+			Start: c.launched,
+			Took:  c.finished.Sub(c.launched),
+			Latch: c.endedBy,
+			Err:   errText(c.err),
+		})
+		end = c.finished
+	}
+	for _, name := range o.dag.Order() {
+		if o.component(name).finished.IsZero() {
+			rep.Pending = append(rep.Pending, name)
+		}
+	}
+	if len(rep.Pending) > 0 || end.IsZero() {
+		end = orReal(o.clk).Now()
+	}
+	rep.Took = end.Sub(rep.Start)
+	return rep
+}
+
+// Name returns the component's name.
+func (c *Component) Name() string { return c.name }
+
+// Context returns the context given to Start, which is
+// also canceled if any component fails, and once
+// Shutdown has finished.
+func (c *Component) Context() context.Context { return c.ctx }
+
+// Stopping returns the latch that Shutdown closes when
+// it is the component's turn to stop.
+func (c *Component) Stopping() *ROLatch { return c.stop.ReadOnly() }
+
+// Ready closes the component's ready latch, letting its
+// dependents start. Calls after the first do nothing.
+func (c *Component) Ready() {
+	c.once.Do(func() {
+		c.ready.Bcast(&Packet{})
+		o := c.o
+		o.mu.Lock()
+		o.finish(c, c.ready, nil)
+		o.pending--
+		all := o.pending == 0
+		o.mu.Unlock()
+		if all {
+			o.allReady.Bcast(&Packet{})
+		}
+	})
+}
+
+// Ready returns the ready latch of component name, or
+// nil if there is none.
+func (o *Orchestrator) Ready(name string) *ROLatch {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if c := o.component(name); c != nil {
+		return c.ready.ReadOnly()
+	}
+	return nil
+}
+
+// Done returns the latch that component name closes,
+// with its error, when it returns; or when it gives up
+// without being launched, with the context's error. It
+// returns nil if there is no such component.
+func (o *Orchestrator) Done(name string) *ROLatch {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if c := o.component(name); c != nil {
+		return c.done.ReadOnly()
+	}
+	return nil
+}
+
+// AllReady returns the latch closed once every
+// component is ready.
+func (o *Orchestrator) AllReady() *ROLatch {
+	return o.allReady.ReadOnly()
+}
+
+// Failed returns the latch closed when a component
+// fails. Its Err joins the *ComponentErrors of every
+// failure so far.
+func (o *Orchestrator) Failed() *ROLatch {
+	return o.failed.ReadOnly()
+}
+
+// DAG returns the dependency graph of the components'
+// ready latches, or nil before Start.
+func (o *Orchestrator) DAG() *DAG {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.dag
+}
+
+// Shutdown stops the components in the reverse of their
+// startup order, dependents before what they depend on:
+// for each it closes the component's stop latch, then
+// waits up to perNode for it to return before going on
+// to the next. A component that overruns is left
+// running, and reported with its stack as a Straggler.
+// If ctx is done, the remaining components are told to
+// stop without waiting, and reported TimedOut. Each step
+// of the Report is ended by the component's done latch,
+// unless it timed out.
+//
+// Components not yet launched never are. Only the first
+// call runs Shutdown; any others wait for it to finish,
+// and return the same.
+func (o *Orchestrator) Shutdown(ctx context.Context, perNode time.Duration) *Report {
+	o.mu.Lock()
+	if o.stopping {
+		o.mu.Unlock()
+		<-o.finished.Done()
+		return o.finished.Peek().Item.(*Report)
+	}
+	o.stopping = true
+	var order []string
+	if o.dag != nil {
+		order = o.dag.Order()
+	}
+	cancel := o.cancel
+	clk := orReal(o.clk)
+	o.mu.Unlock()
+
+	rep := &Report{Kind: "shutdown", Start: clk.Now()}
+	for i := len(order) - 1; i >= 0; i-- {
+		c := o.component(order[i])
+		rep.Steps = append(rep.Steps, stopOne(ctx, clk, c, perNode))
+		if rep.Steps[len(rep.Steps)-1].TimedOut {
+			rep.Stragglers = append(rep.Stragglers, stragglers(0, []*tracked{&c.tr})...)
+		}
+	}
+	if cancel != nil {
+		cancel()
+	}
+	rep.Took = clk.Now().Sub(rep.Start)
+	o.finished.Bcast(&Packet{Item: rep})
+	return rep
+}
+
+// stopOne stops c, and waits for it, up to perNode on clk.
+func stopOne(ctx context.Context, clk Clock, c *Component, perNode time.Duration) ReportStep {
+	step := ReportStep{Name: c.name, Start: clk.Now()}
+	c.stop.Bcast(&Packet{})
+	expired, timer := after(clk, perNode)
+	defer timer.Stop()
+	select {
+	case <-c.done.Done():
+		step.Latch = c.done.Name()
+		step.Err = errText(c.done.Peek().Err)
+	case <-expired:
+		step.TimedOut = true
+	case <-ctx.Done():
+		step.TimedOut = true
+	}
+	step.Took = clk.Now().Sub(step.Start)
+	return step
+}
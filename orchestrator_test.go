@@ -0,0 +1,212 @@
+package latch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOrchestratorStartsInDependencyOrder(t *testing.T) {
+
+	var mu sync.Mutex
+	var order []string
+	record := func(c *Component) error {
+		mu.Lock()
+		order = append(order, c.Name())
+		mu.Unlock()
+		return nil
+	}
+	o := NewOrchestrator()
+	o.Add("api", []string{"cache", "db"}, record)
+	o.Add("cache", []string{"db"}, record)
+	server := make(chan struct{})
+	o.Add("db", nil, func(c *Component) error {
+		record(c)
+		c.Ready()
+		<-server // keeps serving, once ready.
+		return nil
+	})
+	if o.Add("db", nil, record) {
+		t.Fatal("a repeated name should be refused")
+	}
+	if err := o.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.AllReady().Read(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 3 || order[0] != "db" || order[1] != "cache" || order[2] != "api" {
+		t.Fatalf("started in order %v", order)
+	}
+	rep := o.StartupReport()
+	if rep.Kind != "startup" || len(rep.Steps) != 3 || len(rep.Pending) != 0 {
+		t.Fatalf("StartupReport() = %+v", rep)
+	}
+	if s := rep.Steps[0]; s.Name != "db" || s.Latch != "db.ready" || s.Took < 0 {
+		t.Fatalf("first step = %+v", s)
+	}
+	if o.Done("db").IsClosed() {
+		t.Fatal("db is still serving")
+	}
+	close(server)
+	<-o.Done("db").Done()
+	if o.Failed().IsClosed() {
+		t.Fatal("nothing failed")
+	}
+}
+
+func TestOrchestratorFailure(t *testing.T) {
+
+	boom := errors.New("boom")
+	o := NewOrchestrator()
+	o.Add("db", nil, func(c *Component) error { return boom })
+	o.Add("cache", []string{"db"}, func(c *Component) error {
+		t.Error("cache should not start after db failed")
+		return nil
+	})
+	o.Add("metrics", nil, func(c *Component) error { panic("oops") })
+	if err := o.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-o.Done("db").Done()
+	<-o.Done("metrics").Done()
+	<-o.Done("cache").Done()
+
+	err := o.Failed().Peek().Err
+	var ce *ComponentError
+	if !errors.Is(err, boom) || !errors.As(err, &ce) {
+		t.Fatalf("Failed() Err = %v", err)
+	}
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("the panic should be reported too: %v", err)
+	}
+	if !errors.Is(o.Done("cache").Peek().Err, context.Canceled) {
+		t.Fatalf("cache done with %v", o.Done("cache").Peek().Err)
+	}
+	if o.Ready("cache").IsClosed() || o.AllReady().IsClosed() {
+		t.Fatal("cache never became ready")
+	}
+	rep := o.StartupReport()
+	if len(rep.Failed()) != 2 || !reflect.DeepEqual(rep.Pending, []string{"cache"}) {
+		t.Fatalf("StartupReport() = %+v", rep)
+	}
+	for _, s := range rep.Failed() {
+		if s.Latch != s.Name+".done" {
+			t.Fatalf("failed step = %+v", s)
+		}
+	}
+}
+
+func TestOrchestratorCycle(t *testing.T) {
+
+	o := NewOrchestrator()
+	nop := func(c *Component) error { return nil }
+	o.Add("a", []string{"b"}, nop)
+	o.Add("b", []string{"a"}, nop)
+	var cycle *CycleError
+	if err := o.Start(context.Background()); !errors.As(err, &cycle) {
+		t.Fatalf("Start() = %v", err)
+	}
+}
+
+func TestOrchestratorShutdown(t *testing.T) {
+
+	var mu sync.Mutex
+	var stopped []string
+	serve := func(c *Component) error {
+		c.Ready()
+		<-c.Stopping().Done()
+		mu.Lock()
+		stopped = append(stopped, c.Name())
+		mu.Unlock()
+		return nil
+	}
+	hung := make(chan struct{})
+	defer close(hung)
+
+	o := NewOrchestrator()
+	o.Add("db", nil, serve)
+	o.Add("cache", []string{"db"}, func(c *Component) error {
+		c.Ready()
+		<-hung // ignores Stopping.
+		return nil
+	})
+	o.Add("api", []string{"cache"}, serve)
+	o.Add("late", []string{"api"}, func(c *Component) error {
+		<-c.Stopping().Done()
+		return nil
+	})
+	o.Add("never", []string{"late"}, func(c *Component) error {
+		t.Error("never should not be launched")
+		return nil
+	})
+	if err := o.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-o.Ready("api").Done()
+
+	rep := o.Shutdown(context.Background(), 50*time.Millisecond)
+	var names []string
+	for _, s := range rep.Steps {
+		names = append(names, s.Name)
+	}
+	if want := []string{"never", "late", "api", "cache", "db"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("stopped in order %v, want %v", names, want)
+	}
+	if rep.Steps[0].Err != context.Canceled.Error() || rep.Steps[0].Latch != "never.done" {
+		t.Fatalf("never: %+v", rep.Steps[0])
+	}
+	if rep.Kind != "shutdown" || rep.Steps[3].Latch != "" {
+		t.Fatalf("Report = %+v", rep)
+	}
+	if got := rep.TimedOut(); !reflect.DeepEqual(got, []string{"cache"}) {
+		t.Fatalf("TimedOut() = %v", got)
+	}
+	if len(rep.Stragglers) != 1 || rep.Stragglers[0].Name != "cache" ||
+		!strings.Contains(rep.Stragglers[0].Stack, "TestOrchestratorShutdown") {
+		t.Fatalf("Stragglers = %+v", rep.Stragglers)
+	}
+	if !reflect.DeepEqual(stopped, []string{"api", "db"}) {
+		t.Fatalf("stopped = %v", stopped)
+	}
+	if again := o.Shutdown(context.Background(), time.Second); again != rep {
+		t.Fatal("a second Shutdown should report the first")
+	}
+}
+
+func TestOrchestratorClock(t *testing.T) {
+
+	w := newTimerWheel(time.Millisecond)
+	hung := make(chan struct{})
+	defer close(hung)
+
+	o := NewOrchestrator()
+	o.SetClock(w)
+	o.Add("stuck", nil, func(c *Component) error {
+		c.Ready()
+		<-hung // ignores Stopping.
+		return nil
+	})
+	if err := o.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-o.AllReady().Done()
+
+	done := make(chan *Report, 1)
+	go func() { done <- o.Shutdown(context.Background(), 10*time.Millisecond) }()
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case rep := <-done:
+		t.Fatalf("timed out in real time, not on the Clock: %+v", rep)
+	default:
+	}
+	advanceBy(w, 10*time.Millisecond)
+	if rep := <-done; !reflect.DeepEqual(rep.TimedOut(), []string{"stuck"}) {
+		t.Fatalf("TimedOut() = %v", rep.TimedOut())
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Package otellatch instruments latches with OpenTelemetry,
+so a trace of a distributed shutdown shows who closed
+what, and who saw it.
+
+Each transition emits a short span: "latch.close" when an
+open latch is closed, "latch.set" when a closed latch gets
+a new value, and "latch.open" on Clear. Spans carry the
+latch name, the new Version, and the caller outside the
+latch package that caused the transition.
+
+Latch.Read records a "latch.read" span, a child of any
+span in its ctx, linked to the span of the close that it
+observed.
+*/
+package otellatch
+
+import (
+	"context"
+	"sync"
+
+	"github.com/glycerine/latch"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracing instruments a latch with OpenTelemetry,
+// naming it name in its spans.
+func WithTracing(name string, tp trace.TracerProvider) latch.Option {
+	return latch.WithTracer(New(name, tp))
+}
+
+// Tracer is a latch.Tracer that emits OpenTelemetry spans.
+// Each latch needs its own.
+type Tracer struct {
+	name   string
+	tracer trace.Tracer
+
+	mu sync.Mutex
+
+	// producer is the span context of the transition
+	// that closed the latch, at Version version, while
+	// it is closed.
+	producer trace.SpanContext
+	version  uint64
+}
+
+// New returns a Tracer for one latch, naming it name in
+// its spans; see WithTracing.
+func New(name string, tp trace.TracerProvider) *Tracer {
+	return &Tracer{
+		name:   name,
+		tracer: tp.Tracer("github.com/glycerine/latch"),
+	}
+}
+
+// Transition emits the span for a transition.
+func (t *Tracer) Transition(ev latch.TraceEvent) {
+	attrs := []attribute.KeyValue{
+		attribute.String("latch.name", t.name),
+		attribute.Int64("latch.version", int64(ev.Version)),
+	}
+	if ev.Function != "" {
+		attrs = append(attrs,
+			attribute.String("code.function", ev.Function),
+			attribute.String("code.filepath", ev.File),
+			attribute.Int("code.lineno", ev.Line),
+		)
+	}
+	if ev.Err != nil {
+		attrs = append(attrs, attribute.String("latch.err", ev.Err.Error()))
+	}
+	_, span := t.tracer.Start(context.Background(), "latch."+ev.Op,
+		trace.WithAttributes(attrs...))
+	span.End()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ev.Op == "open" {
+		t.producer = trace.SpanContext{}
+	} else {
+		t.producer = span.SpanContext()
+	}
+	t.version = ev.Version
+}
+
+// Read emits the "latch.read" span for a Read that
+// observed the close at version.
+func (t *Tracer) Read(ctx context.Context, version uint64) {
+	t.mu.Lock()
+	var opts []trace.SpanStartOption
+	if t.version == version && t.producer.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: t.producer}))
+	}
+	t.mu.Unlock()
+
+	opts = append(opts, trace.WithAttributes(attribute.String("latch.name", t.name)))
+	_, span := t.tracer.Start(ctx, "latch.read", opts...)
+	span.End()
+}
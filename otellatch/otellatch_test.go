@@ -0,0 +1,72 @@
+package otellatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glycerine/latch"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	l := latch.NewLatch(1, WithTracing("shutdown", tp))
+
+	l.Bcast(&latch.Packet{Item: 1})
+	l.Bcast(&latch.Packet{Item: 2})
+	l.Clear()
+	l.Bcast(&latch.Packet{Item: 3})
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "consumer")
+	pak, err := l.Read(ctx)
+	parent.End()
+	if err != nil || pak.Item != 3 {
+		t.Fatalf("Read got %v, %v", pak, err)
+	}
+
+	spans := rec.Ended()
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+	want := []string{"latch.close", "latch.set", "latch.open", "latch.close", "latch.read", "consumer"}
+	if len(names) != len(want) {
+		t.Fatalf("expected spans %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected spans %v, got %v", want, names)
+		}
+	}
+
+	for _, kv := range spans[0].Attributes() {
+		if kv.Key == "code.function" && kv.Value.AsString() != "github.com/glycerine/latch/otellatch.TestTracing" {
+			t.Fatalf("expected the caller to be the test, got %v", kv.Value.AsString())
+		}
+	}
+
+	read := spans[4]
+	if read.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("read span should be a child of the consumer's span")
+	}
+	links := read.Links()
+	if len(links) != 1 || links[0].SpanContext.SpanID() != spans[3].SpanContext().SpanID() {
+		t.Fatal("read span should link to the close it observed")
+	}
+}
+
+func TestTracingBcastNil(t *testing.T) {
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	l := latch.NewLatch(1, WithTracing("nil", tp))
+
+	l.Bcast(nil)
+	l.Bcast(&latch.Packet{Item: 1})
+	if !l.IsClosed() || l.Peek().Item != 1 || len(rec.Ended()) != 2 {
+		t.Fatalf("Bcast(nil) should trace like any other close")
+	}
+}
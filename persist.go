@@ -0,0 +1,112 @@
+package latch
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WithPersistence makes a latch durably record its state in
+// the file at path, using codec. While the latch is closed,
+// the file holds the latest Bcast value; Clear removes it.
+// On construction the latch restores itself from the file,
+// so a restarted process resumes with the last broadcast
+// config or shutdown value.
+//
+// Each write goes to a temporary file which is synced and
+// then renamed over path, so a crash never leaves a torn
+// record. Write failures cannot be reported by Bcast or
+// Clear; check PersistErr.
+//
+// The write, fsync and all, is done by the Bcast or Clear
+// itself, holding the latch's lock: each transition
+// costs a disk round trip, and Peek, Observe and the
+// like wait on it meanwhile. That suits latches that
+// change rarely, such as config or shutdown; it is
+// what makes the file current as soon as Bcast returns.
+func WithPersistence(path string, codec Codec) Option {
+	return func(r *Latch) {
+		r.persist = &persister{path: path, codec: codec}
+	}
+}
+
+// PersistErr returns the error from the most recent
+// attempt to save or restore the latch's state, if
+// it failed; or nil.
+func (r *Latch) PersistErr() error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.persist == nil {
+		return nil
+	}
+	return r.persist.err
+}
+
+type persister struct {
+	path  string
+	codec Codec
+	err   error
+}
+
+// save records r's current state.
+// Caller must hold r.mut.
+func (p *persister) save(r *Latch) {
+	if !r.avail {
+		p.err = os.Remove(p.path)
+		if os.IsNotExist(p.err) {
+			p.err = nil
+		}
+		return
+	}
+	data, err := p.codec.Encode(r.cur)
+	if err != nil {
+		p.err = err
+		return
+	}
+	p.err = writeFileAtomic(p.path, data)
+}
+
+// restore closes r with the saved value, if any.
+// Only called from NewLatch, before r is shared.
+func (p *persister) restore(r *Latch) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			p.err = err
+		}
+		return
+	}
+	pak, err := p.codec.Decode(data)
+	if err != nil {
+		p.err = err
+		return
+	}
+	// no need to write back what we just read.
+	r.persist = nil
+	r.bcast(pak)
+	r.persist = p
+}
+
+// writeFileAtomic replaces path with data, via a
+// synced temporary file in the same directory.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,47 @@
+package latch
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestPersistence(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "shutdown.latch")
+
+	latch := NewLatch(1, WithPersistence(path, JSONCodec{}))
+	if latch.IsClosed() {
+		t.Fatal("no saved state, so we should start open")
+	}
+	latch.Bcast(&Packet{Item: "draining", Err: errors.New("disk full")})
+	if err := latch.PersistErr(); err != nil {
+		t.Fatal(err)
+	}
+
+	// "restart"
+	again := NewLatch(1, WithPersistence(path, JSONCodec{}))
+	pak := again.Peek()
+	if pak == nil || pak.Item != "draining" || pak.Err == nil || pak.Err.Error() != "disk full" {
+		t.Fatalf("expected to resume with the saved packet, got %#v", pak)
+	}
+
+	// Clear is remembered too.
+	again.Clear()
+	third := NewLatch(1, WithPersistence(path, JSONCodec{}))
+	if third.IsClosed() {
+		t.Fatal("a cleared latch should restore as open")
+	}
+}
+
+func TestPersistEmptyErr(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "shutdown.latch")
+	latch := NewLatch(1, WithPersistence(path, JSONCodec{}))
+	latch.Bcast(&Packet{Err: errors.New("")})
+
+	again := NewLatch(1, WithPersistence(path, JSONCodec{}))
+	if pak := again.Peek(); pak == nil || pak.Err == nil {
+		t.Fatalf("an error with an empty message should survive, got %#v", pak)
+	}
+}
@@ -0,0 +1,195 @@
+package latch
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Phases choreographs a graceful shutdown in ordered
+// phases: stop accepting requests, say, then drain
+// in-flight work, then flush and close storage.
+//
+// Cleanup functions are added to numbered phases. On
+// Shutdown, phases run in ascending order. Each phase
+// closes its latch (see Latch), runs all its cleanups
+// concurrently, and waits for them to finish, for at
+// most the per-phase timeout, before the next phase
+// starts. Cleanups that overrun are reported as
+// Stragglers, with their stacks, and left running;
+// shutdown moves on.
+type Phases struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	phases  map[int]*phase
+	started bool
+	clk     Clock // see SetClock.
+
+	// finished is closed, with the []Straggler,
+	// once Shutdown completes.
+	finished *Latch
+}
+
+type phase struct {
+	start *Latch
+	tasks []*phaseTask
+}
+
+type phaseTask struct {
+	tr   tracked
+	fn   func()
+	done *Latch
+}
+
+// NewPhases makes a Phases that gives each phase
+// up to timeout to complete.
+func NewPhases(timeout time.Duration) *Phases {
+	return &Phases{
+		timeout:  timeout,
+		phases:   make(map[int]*phase),
+		finished: NewLatch(1),
+	}
+}
+
+// SetClock makes the per-phase timeout, and
+// WaitDoneTimeout, use c, as WithClock does for a Latch.
+// Call it before Shutdown.
+func (p *Phases) SetClock(c Clock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clk = c
+}
+
+// phase returns phase n, making it if need be.
+// Caller must hold p.mu.
+func (p *Phases) phase(n int) *phase {
+	ph := p.phases[n]
+	if ph == nil {
+		ph = &phase{start: NewLatch(1)}
+		p.phases[n] = ph
+	}
+	return ph
+}
+
+// Add registers cleanup, named name, to run in phase
+// n. It returns false, and does nothing, if Shutdown
+// has already started.
+func (p *Phases) Add(n int, name string, cleanup func()) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return false
+	}
+	ph := p.phase(n)
+	t := &phaseTask{fn: cleanup, done: NewLatch(1)}
+	t.tr.name = name
+	ph.tasks = append(ph.tasks, t)
+	return true
+}
+
+// Latch returns the latch that phase n closes when it
+// begins; long-running workers can watch it to learn
+// that their phase has come, instead of registering
+// a cleanup.
+func (p *Phases) Latch(n int) *Latch {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.phase(n).start
+}
+
+// Shutdown runs the phases in order, closing each
+// phase's latch with pak, and returns the cleanups
+// that overran. Only the first call runs them; any
+// others wait for it to finish, and return the same.
+func (p *Phases) Shutdown(pak *Packet) []Straggler {
+	p.mu.Lock()
+	if p.started {
+		p.mu.Unlock()
+		<-p.finished.Done()
+		return p.finished.Peek().Item.([]Straggler)
+	}
+	p.started = true
+	var order []int
+	for n := range p.phases {
+		order = append(order, n)
+	}
+	sort.Ints(order)
+	phases := make([]*phase, len(order))
+	for i, n := range order {
+		phases[i] = p.phases[n]
+	}
+	clk := p.clk
+	p.mu.Unlock()
+
+	// no more Adds once started, so each
+	// phase's tasks are fixed now.
+	var out []Straggler
+	for i, n := range order {
+		out = append(out, p.run(clk, n, phases[i], pak)...)
+	}
+	p.finished.Bcast(&Packet{Item: out})
+	return out
+}
+
+// run runs one phase, timed by clk, returning its
+// stragglers.
+func (p *Phases) run(clk Clock, n int, ph *phase, pak *Packet) []Straggler {
+	ph.start.Bcast(pak)
+	for _, t := range ph.tasks {
+		go func(t *phaseTask) {
+			t.tr.begin()
+			defer t.done.Bcast(&Packet{})
+			defer t.tr.done.Store(true)
+			t.fn()
+		}(t)
+	}
+
+	expired, timer := after(clk, p.timeout)
+	defer timer.Stop()
+	for _, t := range ph.tasks {
+		select {
+		case <-t.done.Done():
+			continue
+		case <-expired:
+		}
+		// out of time.
+		return stragglers(n, ph.trackers())
+	}
+	return nil
+}
+
+func (ph *phase) trackers() []*tracked {
+	ts := make([]*tracked, len(ph.tasks))
+	for i, t := range ph.tasks {
+		ts[i] = &t.tr
+	}
+	return ts
+}
+
+// WaitDoneTimeout waits up to d for a Shutdown to
+// complete, and returns what it reported. If d passes
+// first, it returns ErrDoneTimeout, and the cleanups
+// of the phases begun so far that are still running.
+func (p *Phases) WaitDoneTimeout(d time.Duration) ([]Straggler, error) {
+	p.mu.Lock()
+	clk := p.clk
+	p.mu.Unlock()
+	if waitTimeout(clk, p.finished.Done(), d) {
+		return p.finished.Peek().Item.([]Straggler), nil
+	}
+	p.mu.Lock()
+	var order []int
+	for n, ph := range p.phases {
+		if ph.start.IsClosed() {
+			order = append(order, n)
+		}
+	}
+	sort.Ints(order)
+	var out []Straggler
+	for _, n := range order {
+		out = append(out, stragglers(n, p.phases[n].trackers())...)
+	}
+	p.mu.Unlock()
+	return out, ErrDoneTimeout
+}
@@ -0,0 +1,84 @@
+package latch
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPhases(t *testing.T) {
+
+	p := NewPhases(50 * time.Millisecond)
+
+	var mu sync.Mutex
+	var ran []string
+	note := func(s string) func() {
+		return func() {
+			mu.Lock()
+			ran = append(ran, s)
+			mu.Unlock()
+		}
+	}
+
+	// a worker that watches its phase's latch.
+	drained := make(chan bool)
+	go func() {
+		<-p.Latch(2).Ch()
+		drained <- true
+	}()
+
+	hang := make(chan struct{})
+	defer close(hang)
+	p.Add(3, "flush", note("flush"))
+	p.Add(1, "listener", note("listener"))
+	p.Add(2, "stuck", func() { <-hang })
+	p.Add(2, "drain", note("drain"))
+
+	stragglers := p.Shutdown(&Packet{Item: "sigterm"})
+	<-drained
+
+	if len(stragglers) != 1 || stragglers[0].Phase != 2 || stragglers[0].Name != "stuck" {
+		t.Fatalf("expected the stuck cleanup as a straggler, got %v", stragglers)
+	}
+	if !strings.Contains(stragglers[0].Stack, "TestPhases") {
+		t.Fatalf("expected the straggler's stack, got %q", stragglers[0].Stack)
+	}
+	if s, err := p.WaitDoneTimeout(time.Second); err != nil || len(s) != 1 {
+		t.Fatalf("WaitDoneTimeout after Shutdown got %v, %v", s, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ran) != 3 || ran[0] != "listener" || ran[1] != "drain" || ran[2] != "flush" {
+		t.Fatalf("phases ran out of order: %v", ran)
+	}
+	if p.Add(4, "late", note("late")) {
+		t.Fatal("Add after Shutdown should be refused")
+	}
+	if again := p.Shutdown(nil); len(again) != 1 {
+		t.Fatalf("a second Shutdown should report the same, got %v", again)
+	}
+}
+
+func TestPhasesClock(t *testing.T) {
+
+	w := newTimerWheel(time.Millisecond)
+	p := NewPhases(10 * time.Millisecond)
+	p.SetClock(w)
+	hang := make(chan struct{})
+	defer close(hang)
+	p.Add(1, "stuck", func() { <-hang })
+
+	done := make(chan []Straggler, 1)
+	go func() { done <- p.Shutdown(nil) }()
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case s := <-done:
+		t.Fatalf("timed out in real time, not on the Clock: %v", s)
+	default:
+	}
+	advanceBy(w, 10*time.Millisecond)
+	if s := <-done; len(s) != 1 || s[0].Name != "stuck" {
+		t.Fatalf("expected the stuck cleanup as a straggler, got %v", s)
+	}
+}
@@ -0,0 +1,181 @@
+package latch
+
+import (
+	"context"
+	"sync"
+)
+
+// Pipeline coordinates the stages of a stream processor,
+// upstream first, through latches. The stages pass data
+// among themselves however they like; the Pipeline only
+// tells them when to pause, flush and stop, and in what
+// order, so that nothing is lost in between: Drain stops
+// the first stage and waits for it to finish, then the
+// second, and so on, so that each stage has consumed all
+// its upstream's output before it is told to stop.
+type Pipeline struct {
+	mu      sync.Mutex
+	stages  []*Stage
+	started bool
+}
+
+// Stage is one stage of a Pipeline, as handed to its
+// StageFunc.
+type Stage struct {
+	name  string
+	fn    StageFunc
+	pause *Latch
+	flush *Latch
+	acked *Latch
+	stop  *Latch
+	done  *Latch
+}
+
+// StageFunc is the body of a stage. It should wait while
+// s.Paused() is closed; when s.Flushing() closes, write
+// out anything it is holding (a stage holding nothing
+// still must), then call s.Flushed; and
+// return once s.Stopping() closes and its input is used
+// up. Its error closes the stage's done latch.
+type StageFunc func(s *Stage) error
+
+// NewPipeline makes an empty Pipeline.
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// Add appends a stage, downstream of those already
+// added. It returns false if the Pipeline has started.
+func (p *Pipeline) Add(name string, fn StageFunc) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return false
+	}
+	p.stages = append(p.stages, &Stage{
+		name:  name,
+		fn:    fn,
+		pause: NewLatch(1),
+		flush: NewLatch(1),
+		acked: NewLatch(1),
+		stop:  NewLatch(1),
+		done:  NewLatch(1),
+	})
+	return true
+}
+
+// Start runs every stage in its own goroutine. Only the
+// first call does anything.
+func (p *Pipeline) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return
+	}
+	p.started = true
+	for _, s := range p.stages {
+		go func(s *Stage) {
+			s.done.Bcast(&Packet{Err: s.fn(s)})
+		}(s)
+	}
+}
+
+// snapshot returns the stages, upstream first.
+func (p *Pipeline) snapshot() []*Stage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*Stage(nil), p.stages...)
+}
+
+// Pause pauses the stages, upstream first.
+func (p *Pipeline) Pause() {
+	for _, s := range p.snapshot() {
+		s.pause.Bcast(&Packet{})
+	}
+}
+
+// Resume resumes the stages, downstream first, so that
+// none resumes with nowhere to send its output.
+func (p *Pipeline) Resume() {
+	stages := p.snapshot()
+	for i := len(stages) - 1; i >= 0; i-- {
+		stages[i].pause.Clear()
+	}
+}
+
+// Flush asks each stage in turn, upstream first, to
+// flush, waiting for it to call Flushed (or finish)
+// before asking the next. It returns ctx.Err() if ctx
+// is done first.
+func (p *Pipeline) Flush(ctx context.Context) error {
+	for _, s := range p.snapshot() {
+		s.acked.Clear()
+		s.flush.Bcast(&Packet{})
+		select {
+		case <-s.acked.Done():
+		case <-s.done.Done():
+		case <-ctx.Done():
+			s.flush.Clear()
+			return ctx.Err()
+		}
+		s.flush.Clear()
+	}
+	return nil
+}
+
+// Drain stops each stage in turn, upstream first,
+// waiting for it to finish before stopping the next. It
+// returns the first stage error, or ctx.Err() if ctx is
+// done first, leaving the remaining stages running.
+func (p *Pipeline) Drain(ctx context.Context) error {
+	var first error
+	for _, s := range p.snapshot() {
+		s.stop.Bcast(&Packet{})
+		pak, err := s.done.Read(ctx)
+		if err != nil {
+			return err
+		}
+		if first == nil {
+			first = pak.Err
+		}
+	}
+	return first
+}
+
+// Stop tells every stage to stop at once, without
+// waiting; see Done.
+func (p *Pipeline) Stop() {
+	for _, s := range p.snapshot() {
+		s.stop.Bcast(&Packet{})
+	}
+}
+
+// Done returns the done latch of stage i, closed with
+// the stage's error once it returns; or nil if there is
+// no stage i.
+func (p *Pipeline) Done(i int) *ROLatch {
+	stages := p.snapshot()
+	if i < 0 || i >= len(stages) {
+		return nil
+	}
+	return stages[i].done.ReadOnly()
+}
+
+// Name returns the stage's name.
+func (s *Stage) Name() string { return s.name }
+
+// Paused is closed while the stage should pause.
+func (s *Stage) Paused() *ROLatch { return s.pause.ReadOnly() }
+
+// Flushing is closed while the stage is asked to
+// flush, until it calls Flushed.
+func (s *Stage) Flushing() *ROLatch { return s.flush.ReadOnly() }
+
+// Stopping is closed once the stage should stop.
+func (s *Stage) Stopping() *ROLatch { return s.stop.ReadOnly() }
+
+// Flushed tells Flush that the stage has flushed.
+func (s *Stage) Flushed() {
+	s.flush.Clear()
+	s.acked.Bcast(&Packet{})
+}
@@ -0,0 +1,90 @@
+package latch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPipeline(t *testing.T) {
+
+	src := make(chan int)
+	mid := make(chan int, 100)
+	var got []int
+	var buffered []int
+	var order []string
+	orderCh := make(chan string, 10)
+
+	p := NewPipeline()
+	p.Add("source", func(s *Stage) error {
+		defer close(mid)
+		for {
+			select {
+			case n := <-src:
+				mid <- n
+			case <-s.Flushing().Done():
+				s.Flushed()
+			case <-s.Stopping().Done():
+				orderCh <- s.Name()
+				return nil
+			}
+		}
+	})
+	p.Add("sink", func(s *Stage) error {
+		for {
+			select {
+			case n, ok := <-mid:
+				if !ok {
+					got = append(got, buffered...)
+					orderCh <- s.Name()
+					return errors.New("sink done")
+				}
+				buffered = append(buffered, n)
+			case <-s.Flushing().Done():
+				got = append(got, buffered...)
+				buffered = nil
+				s.Flushed()
+			}
+		}
+	})
+	if p.Done(2) != nil {
+		t.Fatal("no stage 2")
+	}
+	p.Start()
+	if p.Add("late", nil) {
+		t.Fatal("Add after Start")
+	}
+
+	ctx := context.Background()
+	src <- 1
+	src <- 2
+	for len(mid) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("flush should have written 2, got %v", got)
+	}
+	p.Pause()
+	if !p.stages[0].Paused().IsClosed() || !p.stages[1].Paused().IsClosed() {
+		t.Fatal("Pause should pause every stage")
+	}
+	p.Resume()
+	src <- 3
+	if err := p.Drain(ctx); err == nil || err.Error() != "sink done" {
+		t.Fatalf("Drain should return the stage's error, got %v", err)
+	}
+	close(orderCh)
+	for s := range orderCh {
+		order = append(order, s)
+	}
+	if len(got) != 3 || len(order) != 2 || order[0] != "source" {
+		t.Fatalf("got %v, order %v", got, order)
+	}
+	if !p.Done(1).IsClosed() {
+		t.Fatal("sink should be done")
+	}
+}
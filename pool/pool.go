@@ -0,0 +1,300 @@
+/*
+Package pool is a worker pool controlled entirely by
+latches. Pausing, draining and stopping the pool close
+its Paused, Draining and Stopping latches, and workers
+act on those; shrinking closes the quit latches of the
+workers to go. Each worker has a done latch, closed
+when it exits, and the pool has AllDone, closed once
+every worker has exited after a Drain or Stop.
+
+A pool is fixed at the size given to New, and Resize;
+or elastic, WithElastic, adding workers up to a maximum
+while tasks are waiting, who leave again once idle.
+*/
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/glycerine/latch"
+)
+
+// ErrDraining is returned by Submit once the pool is
+// draining or stopping.
+var ErrDraining = errors.New("pool: draining")
+
+// Option configures a Pool.
+type Option func(*Pool)
+
+// WithQueue lets n tasks wait for a worker, rather than
+// Submit waiting for one to be free.
+func WithQueue(n int) Option {
+	return func(p *Pool) {
+		p.tasks = make(chan func(), n)
+	}
+}
+
+// WithElastic lets the pool grow to max workers while
+// tasks are waiting; the extra workers exit after idle
+// with nothing to do.
+func WithElastic(max int, idle time.Duration) Option {
+	return func(p *Pool) {
+		p.max, p.idle = max, idle
+	}
+}
+
+// Pool runs submitted tasks on its workers.
+type Pool struct {
+	tasks chan func()
+	max   int
+	idle  time.Duration
+
+	pause *latch.Latch
+	drain *latch.Latch
+	stop  *latch.Latch
+	all   *latch.Latch
+
+	// sub is read-held by Submit from its draining check
+	// to its send; a draining worker takes it to know no
+	// task can arrive behind it.
+	sub sync.RWMutex
+
+	mu      sync.Mutex
+	size    int // the fixed workers wanted.
+	workers map[*worker]struct{}
+}
+
+// worker is one worker goroutine; quit asks it to
+// exit, and done is closed once it has.
+type worker struct {
+	quit    *latch.Latch
+	done    *latch.Latch
+	elastic bool
+}
+
+// New starts a pool of n workers.
+func New(n int, opts ...Option) *Pool {
+	p := &Pool{
+		tasks:   make(chan func()),
+		pause:   latch.NewLatch(1),
+		drain:   latch.NewLatch(1),
+		stop:    latch.NewLatch(1),
+		all:     latch.NewLatch(1),
+		workers: make(map[*worker]struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.Resize(n)
+	return p
+}
+
+// Submit queues fn to run on a worker, waiting for room
+// if need be. It returns ErrDraining if the pool is
+// draining or stopping, or ctx.Err() if ctx is done first.
+func (p *Pool) Submit(ctx context.Context, fn func()) error {
+	p.sub.RLock()
+	defer p.sub.RUnlock()
+	if p.drain.IsClosed() || p.stop.IsClosed() {
+		return ErrDraining
+	}
+	select {
+	case p.tasks <- fn:
+		return nil
+	default:
+	}
+	if p.max > 0 {
+		p.mu.Lock()
+		if len(p.workers) < p.max {
+			p.start(true)
+		}
+		p.mu.Unlock()
+	}
+	select {
+	case p.tasks <- fn:
+		return nil
+	case <-p.drain.Done():
+		return ErrDraining
+	case <-p.stop.Done():
+		return ErrDraining
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pause stops workers taking new tasks; those running
+// carry on.
+func (p *Pool) Pause() {
+	p.pause.Bcast(&latch.Packet{})
+}
+
+// Resume undoes Pause.
+func (p *Pool) Resume() {
+	p.pause.Clear()
+}
+
+// Resize sets the number of fixed workers to n, starting
+// new ones, or asking the surplus to exit after their
+// current task.
+func (p *Pool) Resize(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.size < n {
+		p.start(false)
+		p.size++
+	}
+	for w := range p.workers {
+		if p.size <= n {
+			break
+		}
+		if !w.elastic && !w.quit.IsClosed() {
+			w.quit.Bcast(&latch.Packet{})
+			p.size--
+		}
+	}
+}
+
+// Drain stops Submit accepting tasks; the workers
+// finish those already submitted, and exit. A paused
+// pool is drained too.
+func (p *Pool) Drain() {
+	p.drain.Bcast(&latch.Packet{})
+	p.checkAllDone()
+}
+
+// Stop stops Submit accepting tasks, and has the
+// workers exit after their current task; tasks still
+// queued are dropped.
+func (p *Pool) Stop() {
+	p.stop.Bcast(&latch.Packet{})
+	p.checkAllDone()
+}
+
+// Paused is closed while the pool is paused.
+func (p *Pool) Paused() *latch.ROLatch { return p.pause.ReadOnly() }
+
+// Draining is closed once Drain is called.
+func (p *Pool) Draining() *latch.ROLatch { return p.drain.ReadOnly() }
+
+// Stopping is closed once Stop is called.
+func (p *Pool) Stopping() *latch.ROLatch { return p.stop.ReadOnly() }
+
+// AllDone is closed once the pool is draining or
+// stopping, and every worker has exited.
+func (p *Pool) AllDone() *latch.ROLatch { return p.all.ReadOnly() }
+
+// Workers returns the done latches of the running
+// workers.
+func (p *Pool) Workers() []*latch.ROLatch {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []*latch.ROLatch
+	for w := range p.workers {
+		out = append(out, w.done.ReadOnly())
+	}
+	return out
+}
+
+// Len returns the number of running workers.
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.workers)
+}
+
+// start starts a worker. Caller must hold p.mu.
+func (p *Pool) start(elastic bool) {
+	w := &worker{
+		quit:    latch.NewLatch(1),
+		done:    latch.NewLatch(1),
+		elastic: elastic,
+	}
+	p.workers[w] = struct{}{}
+	go p.run(w)
+}
+
+func (p *Pool) run(w *worker) {
+	defer p.exit(w)
+	var idle <-chan time.Time
+	for {
+		// wait out any pause.
+		for {
+			cur, _, next := p.pause.Observe()
+			if cur == nil {
+				break
+			}
+			select {
+			case <-next:
+			case <-w.quit.Done():
+				return
+			case <-p.stop.Done():
+				return
+			case <-p.drain.Done():
+				p.finish()
+				return
+			}
+		}
+		if w.elastic {
+			idle = time.After(p.idle)
+		}
+		select {
+		case fn := <-p.tasks:
+			if p.stop.IsClosed() {
+				return
+			}
+			fn()
+		case <-p.pause.Done():
+		case <-w.quit.Done():
+			return
+		case <-p.stop.Done():
+			return
+		case <-idle:
+			return
+		case <-p.drain.Done():
+			p.finish()
+			return
+		}
+	}
+}
+
+// finish runs the tasks queued when the pool drains.
+func (p *Pool) finish() {
+	// wait out any Submit that got past its draining
+	// check; every later one returns ErrDraining.
+	p.sub.Lock()
+	p.sub.Unlock()
+	for {
+		select {
+		case fn := <-p.tasks:
+			if p.stop.IsClosed() {
+				return
+			}
+			fn()
+		default:
+			return
+		}
+	}
+}
+
+// exit retires w.
+func (p *Pool) exit(w *worker) {
+	p.mu.Lock()
+	delete(p.workers, w)
+	if !w.elastic && !w.quit.IsClosed() {
+		p.size--
+	}
+	p.mu.Unlock()
+	w.done.Bcast(&latch.Packet{})
+	p.checkAllDone()
+}
+
+func (p *Pool) checkAllDone() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.workers) == 0 && (p.drain.IsClosed() || p.stop.IsClosed()) {
+		p.all.Bcast(&latch.Packet{})
+	}
+}
@@ -0,0 +1,146 @@
+package pool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPoolDrain(t *testing.T) {
+
+	p := New(2, WithQueue(10))
+	ctx := context.Background()
+	var ran atomic.Int64
+	for i := 0; i < 10; i++ {
+		if err := p.Submit(ctx, func() { ran.Add(1) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Drain()
+	if err := p.Submit(ctx, func() {}); err != ErrDraining {
+		t.Fatalf("Submit after Drain got %v", err)
+	}
+	if _, err := p.AllDone().Read(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ran.Load() != 10 {
+		t.Fatalf("drain should finish queued tasks, ran %d", ran.Load())
+	}
+	if p.Len() != 0 {
+		t.Fatal("workers left after AllDone")
+	}
+}
+
+func TestPoolPauseResize(t *testing.T) {
+
+	p := New(1, WithQueue(1))
+	ctx := context.Background()
+	workers := p.Workers()
+	if len(workers) != 1 {
+		t.Fatalf("expected 1 worker, got %d", len(workers))
+	}
+
+	p.Pause()
+	ran := make(chan struct{}, 10)
+	p.Submit(ctx, func() { ran <- struct{}{} })
+	select {
+	case <-ran:
+		t.Fatal("a paused pool shouldn't run tasks")
+	case <-time.After(20 * time.Millisecond):
+	}
+	p.Resume()
+	<-ran
+
+	p.Resize(3)
+	waitFor(t, func() bool { return p.Len() == 3 })
+	p.Resize(1)
+	waitFor(t, func() bool { return p.Len() == 1 })
+
+	p.Stop()
+	if _, err := p.AllDone().Read(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !workers[0].IsClosed() && p.Len() != 0 {
+		t.Fatal("workers should be done")
+	}
+}
+
+func TestPoolElastic(t *testing.T) {
+
+	p := New(1, WithElastic(4, 10*time.Millisecond))
+	ctx := context.Background()
+	release := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		if err := p.Submit(ctx, func() { <-release }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.Len() != 4 {
+		t.Fatalf("elastic pool should have grown to 4, has %d", p.Len())
+	}
+	close(release)
+	waitFor(t, func() bool { return p.Len() == 1 })
+	p.Stop()
+}
+
+func TestPoolDrainPaused(t *testing.T) {
+
+	p := New(2, WithQueue(4))
+	ctx := context.Background()
+	p.Pause()
+	var ran atomic.Int64
+	for i := 0; i < 4; i++ {
+		if err := p.Submit(ctx, func() { ran.Add(1) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Drain()
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := p.AllDone().Read(ctx); err != nil {
+		t.Fatal("a paused pool never drained")
+	}
+	if ran.Load() != 4 {
+		t.Fatalf("drain should finish queued tasks, ran %d", ran.Load())
+	}
+}
+
+func TestPoolSubmitDuringDrain(t *testing.T) {
+
+	for i := 0; i < 200; i++ {
+		p := New(1, WithQueue(8))
+		ctx := context.Background()
+		var accepted, ran atomic.Int64
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if p.Submit(ctx, func() { ran.Add(1) }) != nil {
+					return
+				}
+				accepted.Add(1)
+			}
+		}()
+		waitFor(t, func() bool { return accepted.Load() > 0 })
+		p.Drain()
+		<-done
+		if _, err := p.AllDone().Read(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if ran.Load() != accepted.Load() {
+			t.Fatalf("accepted %d tasks but ran %d", accepted.Load(), ran.Load())
+		}
+	}
+}
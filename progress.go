@@ -0,0 +1,39 @@
+package latch
+
+import (
+	"context"
+	"time"
+)
+
+// ReadWithProgress is Read, calling onWait every `every`
+// while it waits, with the time waited so far; so a long
+// wait can log "still waiting for db-ready after 30s"
+// without the caller writing the ticker loop. onWait runs
+// on the calling goroutine, and is not called at all if
+// the latch is already closed. Timing uses the latch's
+// Clock.
+func (r *Latch) ReadWithProgress(ctx context.Context, every time.Duration, onWait func(elapsed time.Duration)) (*Packet, error) {
+	cur, closed, _, next := r.observe()
+	if closed {
+		return cur, nil
+	}
+	clk := r.clock()
+	began := clk.Now()
+	start := r.waitStart()
+	defer func() { r.waited(start) }()
+	tick := clk.NewTicker(every)
+	defer tick.Stop()
+	for {
+		select {
+		case <-next:
+		case now := <-tick.C():
+			onWait(now.Sub(began))
+			continue
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if cur, closed, _, next = r.observe(); closed {
+			return cur, nil
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package latch
+
+import (
+	"context"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestReadWithProgress(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		l := NewLatch(1)
+		go func() {
+			time.Sleep(95 * time.Second)
+			l.Bcast(&Packet{Item: "db-ready"})
+		}()
+		var waits []time.Duration
+		pak, err := l.ReadWithProgress(context.Background(), 30*time.Second, func(elapsed time.Duration) {
+			waits = append(waits, elapsed)
+		})
+		if err != nil || pak.Item != "db-ready" {
+			t.Fatalf("got %v, %v", pak, err)
+		}
+		if len(waits) != 3 || waits[0] != 30*time.Second || waits[2] != 90*time.Second {
+			t.Fatalf("progress calls at %v", waits)
+		}
+
+		pak, _ = l.ReadWithProgress(context.Background(), time.Second, func(time.Duration) {
+			t.Fatal("no progress calls on a closed latch")
+		})
+		if pak.Item != "db-ready" {
+			t.Fatalf("got %v", pak)
+		}
+	})
+}
+
+func TestReadWithProgressBcastNil(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		l := NewLatch(1)
+		go func() {
+			time.Sleep(4500 * time.Millisecond)
+			l.Bcast(nil)
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		var waits int
+		pak, err := l.ReadWithProgress(ctx, time.Second, func(time.Duration) { waits++ })
+		if err != nil || pak != nil || waits != 4 {
+			t.Fatalf("got %v, %v after %v progress calls", pak, err, waits)
+		}
+
+		_, err = l.ReadWithProgress(ctx, time.Second, func(time.Duration) {
+			t.Fatal("no progress calls on a latch closed with nil")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package latch
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the Err with which Protect, and
+// TaskGroup, close a latch after recovering a panic.
+type PanicError struct {
+	Value interface{} // as passed to panic.
+	Stack []byte      // of the panicking goroutine.
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("latch: recovered panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value, if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Protect calls fn. If fn panics, Protect recovers,
+// closes r with a Packet whose Err is a *PanicError
+// holding the panic value and stack, and returns that
+// error; so whoever coordinates fn's goroutine sees
+// the crash as it would any other failure, rather than
+// the whole process going down. Otherwise it
+// returns nil, leaving r alone.
+//
+//	go stop.Protect(worker)
+func (r *Latch) Protect(fn func()) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			perr := &PanicError{Value: v, Stack: debug.Stack()}
+			r.Bcast(&Packet{Err: perr})
+			err = perr
+		}
+	}()
+	fn()
+	return nil
+}
@@ -0,0 +1,44 @@
+package latch
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestProtect(t *testing.T) {
+
+	l := NewLatch(1)
+	if err := l.Protect(func() {}); err != nil || l.IsClosed() {
+		t.Fatal("a clean fn should leave the latch alone")
+	}
+
+	err := l.Protect(func() { panic(io.EOF) })
+	var perr *PanicError
+	if !errors.As(err, &perr) || !errors.Is(err, io.EOF) {
+		t.Fatalf("expected a PanicError wrapping io.EOF, got %v", err)
+	}
+	if !strings.Contains(string(perr.Stack), "TestProtect") {
+		t.Fatal("the PanicError should hold the panicking stack")
+	}
+	if pak := l.Peek(); pak == nil || pak.Err != err {
+		t.Fatal("the latch should be closed with the PanicError")
+	}
+}
+
+func TestTaskGroupPanic(t *testing.T) {
+
+	g := NewTaskGroup(1)
+	g.Go(func(stop *Latch) error {
+		<-stop.Done()
+		return nil
+	})
+	g.Go(func(stop *Latch) error {
+		panic("worker crashed")
+	})
+	var perr *PanicError
+	if err := g.Wait(); !errors.As(err, &perr) || perr.Value != "worker crashed" {
+		t.Fatalf("expected the panic as Wait's error, got %v", err)
+	}
+}
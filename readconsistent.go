@@ -0,0 +1,18 @@
+package latch
+
+// ReadConsistent returns the current values of latches,
+// in order, with nil for those that are open, as one
+// snapshot: all the latches are locked together while
+// it reads, so it never sees part of a Tx Commit, and
+// a consumer of, say, "shutdown" and "config" latches
+// can't see a torn combination of the two.
+func ReadConsistent(latches ...*Latch) []*Packet {
+	defer lockAll(latches)()
+	out := make([]*Packet, len(latches))
+	for i, l := range latches {
+		if l.avail {
+			out[i] = l.copyOf(l.cur)
+		}
+	}
+	return out
+}
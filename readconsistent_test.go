@@ -0,0 +1,35 @@
+package latch
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestReadConsistent(t *testing.T) {
+
+	shutdown, config := NewLatch(1), NewLatch(1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			tx := Begin()
+			tx.Set(shutdown, &Packet{Item: i})
+			tx.Set(config, &Packet{Item: i})
+			tx.Commit()
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		paks := ReadConsistent(shutdown, config, shutdown)
+		if paks[0] == nil {
+			if paks[1] != nil {
+				t.Fatal("torn read: config without shutdown")
+			}
+			continue
+		}
+		if paks[1].Item != paks[0].Item || paks[2].Item != paks[0].Item {
+			t.Fatalf("torn read: %v, %v, %v", paks[0].Item, paks[1].Item, paks[2].Item)
+		}
+	}
+	wg.Wait()
+}
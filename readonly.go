@@ -0,0 +1,70 @@
+package latch
+
+import (
+	"context"
+	"iter"
+)
+
+// ROLatch is a read-only view of a Latch. It carries
+// the capability to observe the latch, but not to
+// close or open it; so a subsystem can hand out
+// ROLatches to its consumers, and know that it alone
+// controls the latch.
+type ROLatch struct {
+	l *Latch
+}
+
+// ReadOnly returns a read-only view of r.
+func (r *Latch) ReadOnly() *ROLatch {
+	return &ROLatch{l: r}
+}
+
+// Ch is the Latch Ch.
+func (v *ROLatch) Ch() <-chan *Packet {
+	return v.l.Ch()
+}
+
+// Refresh is the Latch Refresh.
+func (v *ROLatch) Refresh() {
+	v.l.Refresh()
+}
+
+// Read is the Latch Read.
+func (v *ROLatch) Read(ctx context.Context) (*Packet, error) {
+	return v.l.Read(ctx)
+}
+
+// IsClosed is the Latch IsClosed.
+func (v *ROLatch) IsClosed() bool {
+	return v.l.IsClosed()
+}
+
+// Peek is the Latch Peek.
+func (v *ROLatch) Peek() *Packet {
+	return v.l.Peek()
+}
+
+// Version is the Latch Version.
+func (v *ROLatch) Version() uint64 {
+	return v.l.Version()
+}
+
+// Observe is the Latch Observe.
+func (v *ROLatch) Observe() (cur *Packet, version uint64, next <-chan struct{}) {
+	return v.l.Observe()
+}
+
+// Done is the Latch Done.
+func (v *ROLatch) Done() <-chan struct{} {
+	return v.l.Done()
+}
+
+// WaitFor is the Latch WaitFor.
+func (v *ROLatch) WaitFor(ctx context.Context, pred func(*Packet) bool) (*Packet, error) {
+	return v.l.WaitFor(ctx, pred)
+}
+
+// Transitions is the Latch Transitions.
+func (v *ROLatch) Transitions(ctx context.Context) iter.Seq2[uint64, *Packet] {
+	return v.l.Transitions(ctx)
+}
@@ -0,0 +1,31 @@
+package latch
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+
+	l := NewLatch(1)
+	ro := l.ReadOnly()
+	if ro.IsClosed() {
+		t.Fatal("expected open")
+	}
+	_, _, next := ro.Observe()
+
+	l.Bcast(&Packet{Item: "v"})
+	<-next
+	<-ro.Done()
+	if ro.Peek().Item != "v" || ro.Version() != 1 {
+		t.Fatal("the view should track the latch")
+	}
+	if (<-ro.Ch()).Item != "v" {
+		t.Fatal("the view should share Ch")
+	}
+	ro.Refresh()
+	pak, err := ro.Read(context.Background())
+	if err != nil || pak.Item != "v" {
+		t.Fatalf("Read got %v, %v", pak, err)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Package redislatch shares one logical latch between
+many service instances through Redis; e.g. a
+cluster-wide maintenance-mode flag.
+
+The latch's state lives under a single Redis key, and
+every transition is also PUBLISHed on a channel of the
+same name. Each Mirror keeps a local *latch.Latch in step
+with the key. Transitions are applied by a Lua script that
+stamps them with a sequence number, so all instances agree
+on their order and the last write wins everywhere.
+
+Packets travel encoded with a latch.Codec, as for
+persistence and the ipc package, so Items of types given
+to latch.RegisterType arrive as those types.
+*/
+package redislatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/glycerine/latch"
+	"github.com/redis/go-redis/v9"
+)
+
+// record is the form of a transition written by
+// publish. It is stored, and published, as
+// "<seq>:<record JSON>".
+type record struct {
+	Closed bool   `json:"closed"`
+	Packet []byte `json:"packet,omitempty"` // by the latch.Codec.
+}
+
+// apply stamps a record with the next sequence number,
+// stores it, and publishes it, atomically. The record
+// is passed through verbatim, not decoded and
+// re-encoded by cjson, which would round its numbers
+// and mangle empty arrays.
+var apply = redis.NewScript(`
+local seq = redis.call('INCR', KEYS[2])
+local enc = seq .. ':' .. ARGV[1]
+redis.call('SET', KEYS[1], enc)
+redis.call('PUBLISH', KEYS[1], enc)
+return seq
+`)
+
+// stamped frames a record, as apply does.
+func stamped(seq int64, data []byte) []byte {
+	return append(strconv.AppendInt(nil, seq, 10), append([]byte{':'}, data...)...)
+}
+
+// Mirror is a local latch that follows, and can
+// update, a latch stored in Redis.
+type Mirror struct {
+	*latch.Latch
+
+	rdb   redis.UniversalClient
+	key   string
+	codec latch.Codec
+	sub   *redis.PubSub
+
+	mu   sync.Mutex
+	seen int64 // highest seq applied locally.
+}
+
+// New subscribes to the shared latch stored at key,
+// returning a Mirror whose local latch, with a backing
+// channel of size sz, reflects the current shared state
+// and tracks it from then on. Every instance sharing
+// key must use the same codec.
+func New(ctx context.Context, rdb redis.UniversalClient, key string, sz int, codec latch.Codec) (*Mirror, error) {
+	m := &Mirror{
+		Latch: latch.NewLatch(sz),
+		rdb:   rdb,
+		key:   key,
+		codec: codec,
+	}
+
+	// subscribe before reading the key, so that
+	// nothing published in between is missed.
+	m.sub = rdb.Subscribe(ctx, key)
+	if _, err := m.sub.Receive(ctx); err != nil {
+		m.sub.Close()
+		return nil, err
+	}
+
+	data, err := rdb.Get(ctx, key).Bytes()
+	switch {
+	case err == redis.Nil:
+	case err != nil:
+		m.sub.Close()
+		return nil, err
+	default:
+		m.receive(data)
+	}
+
+	latch.Go(ctx, latch.RoleMirror, key, func(context.Context) {
+		for msg := range m.sub.Channel() {
+			m.receive([]byte(msg.Payload))
+		}
+	})
+	return m, nil
+}
+
+// receive applies a record to the local latch,
+// unless we have already seen a later one.
+func (m *Mirror) receive(data []byte) {
+	prefix, body, ok := bytes.Cut(data, []byte{':'})
+	if !ok {
+		return
+	}
+	seq, err := strconv.ParseInt(string(prefix), 10, 64)
+	if err != nil {
+		return
+	}
+	var rec record
+	if err := json.Unmarshal(body, &rec); err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if seq <= m.seen {
+		return
+	}
+	m.seen = seq
+	if !rec.Closed {
+		m.Clear()
+		return
+	}
+	pak, err := m.codec.Decode(rec.Packet)
+	if err != nil {
+		// close anyway, as the writer did, but
+		// with why we can't say what with.
+		pak = &latch.Packet{Err: fmt.Errorf("redislatch: cannot decode %v: %w", m.key, err)}
+	}
+	m.Bcast(pak)
+}
+
+// Set closes the shared latch with pak, on every instance.
+func (m *Mirror) Set(ctx context.Context, pak *latch.Packet) error {
+	if pak == nil {
+		pak = &latch.Packet{}
+	}
+	data, err := m.codec.Encode(pak)
+	if err != nil {
+		return err
+	}
+	return m.publish(ctx, record{Closed: true, Packet: data})
+}
+
+// Open clears the shared latch, on every instance.
+func (m *Mirror) Open(ctx context.Context) error {
+	return m.publish(ctx, record{})
+}
+
+func (m *Mirror) publish(ctx context.Context, rec record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	seq, err := apply.Run(ctx, m.rdb, []string{m.key, m.key + ":seq"}, data).Int64()
+	if err != nil {
+		return err
+	}
+	// apply locally now, rather than waiting for the
+	// echo, so Set is read-your-writes.
+	m.receive(stamped(seq, data))
+	return nil
+}
+
+// Unsubscribe stops following the shared latch.
+// The local latch keeps its last state.
+func (m *Mirror) Unsubscribe() error {
+	return m.sub.Close()
+}
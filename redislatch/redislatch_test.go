@@ -0,0 +1,101 @@
+package redislatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glycerine/latch"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSharedLatch(t *testing.T) {
+
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+
+	rdbA := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdbA.Close()
+	rdbB := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdbB.Close()
+
+	a, err := New(ctx, rdbA, "maintenance", 1, latch.JSONCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Unsubscribe()
+
+	if err := a.Set(ctx, &latch.Packet{Item: "read-only"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a late joiner picks up the stored state.
+	b, err := New(ctx, rdbB, "maintenance", 1, latch.JSONCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Unsubscribe()
+	if pak := b.Peek(); pak == nil || pak.Item != "read-only" {
+		t.Fatalf("late joiner should see the stored value, got %v", pak)
+	}
+
+	// and follows later transitions.
+	if err := a.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for b.IsClosed() {
+		if time.Now().After(deadline) {
+			t.Fatal("b never saw the Open")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// registered types arrive as themselves.
+	if err := a.Set(ctx, &latch.Packet{Item: window{Until: 7}}); err != nil {
+		t.Fatal(err)
+	}
+	ctx5, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := b.WaitFor(ctx5, func(p *latch.Packet) bool {
+		return p != nil && p.Item == window{Until: 7}
+	}); err != nil {
+		t.Fatalf("b never saw the typed Item: %v", err)
+	}
+}
+
+type window struct{ Until int }
+
+func init() {
+	latch.RegisterType("redislatch_test.window", window{})
+}
+
+func TestStoredVerbatim(t *testing.T) {
+
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	m, err := New(ctx, rdb, "cfg", 1, latch.JSONCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Unsubscribe()
+	big := map[string]interface{}{"n": 1234567890123456789.0, "list": []interface{}{}}
+	if err := m.Set(ctx, &latch.Packet{Item: big}); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := latch.JSONCodec{}.Encode(&latch.Packet{Item: big})
+	rec, _ := json.Marshal(record{Closed: true, Packet: want})
+	got, err := rdb.Get(ctx, "cfg").Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, stamped(1, rec)) {
+		t.Fatalf("stored %s, want %s", got, stamped(1, rec))
+	}
+}
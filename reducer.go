@@ -0,0 +1,14 @@
+package latch
+
+// WithReducer makes successive Bcast calls merge values
+// instead of replacing them: the latch is closed with
+// reduce(old, new), where old is the current value, or
+// nil if the latch is open. reduce runs under the latch's
+// lock, so counters, max/min trackers, and error
+// accumulators need no locking of their own; for the
+// same reason, it must not call back into the latch.
+func WithReducer(reduce func(old, new *Packet) *Packet) Option {
+	return func(r *Latch) {
+		r.reducer = reduce
+	}
+}
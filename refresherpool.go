@@ -0,0 +1,86 @@
+package latch
+
+import (
+	"sync"
+	"time"
+)
+
+// RefresherPool keeps any number of latches topped up
+// from one goroutine and one timer, where giving each a
+// BackgroundRefresher would cost a goroutine and a timer
+// apiece. Every interval it Refreshes each registered
+// latch; Refresh is cheap for latches that are open, or
+// full.
+type RefresherPool struct {
+	mu      sync.Mutex
+	latches map[*Latch]struct{}
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewRefresherPool starts a pool that refreshes its
+// latches every interval, timed by clk; or by the time
+// package, if clk is nil.
+func NewRefresherPool(interval time.Duration, clk Clock) *RefresherPool {
+	p := &RefresherPool{
+		latches: make(map[*Latch]struct{}),
+		stop:    make(chan struct{}),
+	}
+	if clk == nil {
+		clk = realClock{}
+	}
+	tick := clk.NewTicker(interval)
+	activeRefreshers.Add(1)
+	goLabeled(RoleRefresher, "", func() {
+		defer activeRefreshers.Add(-1)
+		defer tick.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-tick.C():
+				p.refresh()
+			}
+		}
+	})
+	return p
+}
+
+// Add registers l with the pool.
+func (p *RefresherPool) Add(l *Latch) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latches[l] = struct{}{}
+}
+
+// Remove unregisters l.
+func (p *RefresherPool) Remove(l *Latch) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.latches, l)
+}
+
+// Len returns the number of registered latches.
+func (p *RefresherPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.latches)
+}
+
+// Stop ends the pool's goroutine.
+func (p *RefresherPool) Stop() {
+	p.once.Do(func() { close(p.stop) })
+}
+
+func (p *RefresherPool) refresh() {
+	p.mu.Lock()
+	ls := make([]*Latch, 0, len(p.latches))
+	for l := range p.latches {
+		ls = append(ls, l)
+	}
+	p.mu.Unlock()
+	for _, l := range ls {
+		l.Refresh()
+	}
+}
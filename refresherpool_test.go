@@ -0,0 +1,37 @@
+package latch
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestRefresherPool(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		p := NewRefresherPool(100*time.Millisecond, nil)
+		defer p.Stop()
+
+		ls := make([]*Latch, 100)
+		for i := range ls {
+			ls[i] = NewLatch(1)
+			ls[i].Bcast(&Packet{Item: i})
+			p.Add(ls[i])
+			<-ls[i].Ch()
+		}
+		p.Remove(ls[0])
+		if p.Len() != 99 {
+			t.Fatalf("expected 99 latches, got %v", p.Len())
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		synctest.Wait()
+		if len(ls[0].Ch()) != 0 {
+			t.Fatal("a removed latch should not be refreshed")
+		}
+		for _, l := range ls[1:] {
+			if len(l.Ch()) != 1 {
+				t.Fatal("every pooled latch should be refreshed")
+			}
+		}
+	})
+}
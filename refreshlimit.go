@@ -0,0 +1,63 @@
+package latch
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithRefreshLimit bounds how often Refresh actually
+// refills the channel, with a token bucket: at most burst
+// refills at once, replenished at rate per second. A
+// Refresh finding the bucket empty returns at once,
+// without taking the latch's mutex, and counts in
+// RefreshesLimited; so a caller refreshing in a tight
+// loop can't starve writers and other readers of the
+// mutex. The limit applies to BackgroundRefresher too.
+func WithRefreshLimit(rate float64, burst int) Option {
+	return func(r *Latch) {
+		r.refreshLimit = &tokenBucket{
+			rate:   rate,
+			burst:  float64(burst),
+			tokens: float64(burst),
+		}
+	}
+}
+
+// RefreshesLimited returns the number of Refresh calls
+// made no-ops by WithRefreshLimit.
+func (r *Latch) RefreshesLimited() uint64 {
+	if r.refreshLimit == nil {
+		return 0
+	}
+	return r.refreshLimit.limited.Load()
+}
+
+type tokenBucket struct {
+	rate, burst float64
+	limited     atomic.Uint64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// take reports whether a token was available at now,
+// taking it if so.
+func (b *tokenBucket) take(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		b.limited.Add(1)
+		return false
+	}
+	b.tokens--
+	return true
+}
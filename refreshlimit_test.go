@@ -0,0 +1,40 @@
+package latch
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestRefreshLimit(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		l := NewLatch(1, WithRefreshLimit(10, 2))
+		l.Bcast(&Packet{})
+
+		refills := 0
+		refresh := func() {
+			<-l.Ch()
+			l.Refresh()
+			if len(l.c().ch) == 1 {
+				refills++
+				return
+			}
+			// put it back ourselves, for the next round.
+			l.mut.Lock()
+			l.c().ch <- l.cur
+			l.mut.Unlock()
+		}
+		for i := 0; i < 5; i++ {
+			refresh()
+		}
+		if refills != 2 || l.RefreshesLimited() != 3 {
+			t.Fatalf("burst of 2: got %d refills, %d limited", refills, l.RefreshesLimited())
+		}
+		time.Sleep(100 * time.Millisecond)
+		refresh()
+		refresh()
+		if refills != 3 || l.RefreshesLimited() != 4 {
+			t.Fatalf("one token per 100ms: got %d refills, %d limited", refills, l.RefreshesLimited())
+		}
+	})
+}
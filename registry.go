@@ -0,0 +1,177 @@
+package latch
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry holds latches by name, so that
+// tooling (admin endpoints, health checks,
+// snapshots) can find and describe them.
+type Registry struct {
+	mu    sync.Mutex
+	m     map[string]*Latch
+	epoch *epoch
+}
+
+// NewRegistry makes a new, empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		m:     make(map[string]*Latch),
+		epoch: &epoch{chg: make(chan struct{})},
+	}
+}
+
+// Register adds l under name, replacing any
+// latch previously registered under that name.
+func (g *Registry) Register(name string, l *Latch) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.set(name, l)
+}
+
+// Unregister removes name from the Registry.
+func (g *Registry) Unregister(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.set(name, nil)
+}
+
+// set registers l under name, or unregisters name if l
+// is nil, moving the epoch hook over; a membership
+// change is itself an epoch. Caller must hold g.mu.
+func (g *Registry) set(name string, l *Latch) {
+	old := g.m[name]
+	if old == l {
+		return
+	}
+	if old != nil {
+		old.unwatchEpoch(g.epoch, name)
+	}
+	if l == nil {
+		delete(g.m, name)
+	} else {
+		g.m[name] = l
+		l.watchEpoch(g.epoch, name)
+	}
+	g.epoch.bump()
+}
+
+// Get returns the latch registered under name,
+// or nil if there is none.
+func (g *Registry) Get(name string) *Latch {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.m[name]
+}
+
+// Names returns the registered names, sorted.
+func (g *Registry) Names() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	names := make([]string, 0, len(g.m))
+	for name := range g.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// snapshotEntry is one latch in a Registry snapshot.
+type snapshotEntry struct {
+	Sz     int             `json:"sz"`
+	Closed bool            `json:"closed"`
+	Packet json.RawMessage `json:"packet,omitempty"`
+}
+
+// Snapshot captures the closed/open state and value of
+// every registered latch, encoded with JSONCodec (so
+// register Item types with RegisterType to have them
+// restored exactly). Pass the result to Restore, perhaps
+// in another process, for warm restarts or to hand
+// state over during a zero-downtime deploy.
+//
+// Each latch is captured atomically, but the
+// snapshot as a whole is not.
+func (g *Registry) Snapshot() ([]byte, error) {
+	g.mu.Lock()
+	m := make(map[string]*Latch, len(g.m))
+	for name, l := range g.m {
+		m[name] = l
+	}
+	g.mu.Unlock()
+
+	snap := make(map[string]snapshotEntry, len(m))
+	for name, l := range m {
+		cur, closed, sz := l.snapshot()
+		e := snapshotEntry{Sz: sz}
+		e.Closed = closed
+		if cur != nil {
+			data, err := JSONCodec{}.Encode(cur)
+			if err != nil {
+				return nil, fmt.Errorf("latch: snapshot of %q: %v", name, err)
+			}
+			e.Packet = data
+		}
+		snap[name] = e
+	}
+	return json.Marshal(snap)
+}
+
+// snapshot returns, atomically, the current value, whether
+// l is closed, and the size of its channel.
+func (l *Latch) snapshot() (cur *Packet, closed bool, sz int) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	if l.avail {
+		cur, closed = l.copyOf(l.cur), true
+	}
+	return cur, closed, l.c().sz
+}
+
+// Restore applies a Snapshot: each latch named in it is
+// closed with its saved value, or opened, to match.
+// Names not yet registered get a new latch of the saved
+// size. Registered latches absent from the snapshot
+// are left alone.
+func (g *Registry) Restore(data []byte) error {
+	var snap map[string]snapshotEntry
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	paks := make(map[string]*Packet, len(snap))
+	for name, e := range snap {
+		if !e.Closed {
+			continue
+		}
+		if len(e.Packet) == 0 {
+			// closed by Bcast(nil).
+			paks[name] = nil
+			continue
+		}
+		pak, err := JSONCodec{}.Decode(e.Packet)
+		if err != nil {
+			return fmt.Errorf("latch: restore of %q: %v", name, err)
+		}
+		paks[name] = pak
+	}
+
+	for name, e := range snap {
+		g.mu.Lock()
+		l := g.m[name]
+		if l == nil {
+			l = NewLatch(e.Sz)
+			g.set(name, l)
+		}
+		g.mu.Unlock()
+
+		if pak, ok := paks[name]; ok {
+			l.Bcast(pak)
+		} else {
+			l.Clear()
+		}
+	}
+	return nil
+}
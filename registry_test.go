@@ -0,0 +1,171 @@
+package latch
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRegistrySnapshot(t *testing.T) {
+
+	old := NewRegistry()
+	cfg := NewLatch(2)
+	cfg.Bcast(&Packet{Item: testConfig{Name: "prod", Workers: 4}})
+	old.Register("config", cfg)
+	old.Register("shutdown", NewLatch(1))
+
+	snap, err := old.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the new process already has one of the latches.
+	fresh := NewRegistry()
+	shutdown := NewLatch(1)
+	shutdown.Bcast(&Packet{Item: "stale"})
+	fresh.Register("shutdown", shutdown)
+
+	if err := fresh.Restore(snap); err != nil {
+		t.Fatal(err)
+	}
+	if shutdown.IsClosed() {
+		t.Fatal("Restore should have opened the shutdown latch, as it was in the snapshot")
+	}
+	got := fresh.Get("config")
+	if got == nil {
+		t.Fatal("Restore should create latches missing from the registry")
+	}
+	pak := got.Peek()
+	if pak == nil || pak.Item != (testConfig{Name: "prod", Workers: 4}) {
+		t.Fatalf("config should be restored with its typed value, got %#v", pak)
+	}
+}
+
+func TestRegistrySnapshotBcastNil(t *testing.T) {
+
+	old := NewRegistry()
+	l := NewLatch(3)
+	l.Bcast(nil)
+	old.Register("ready", l)
+	snap, err := old.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := NewRegistry()
+	if err := fresh.Restore(snap); err != nil {
+		t.Fatal(err)
+	}
+	got := fresh.Get("ready")
+	if got == nil || !got.IsClosed() || got.Peek() != nil || got.c().sz != 3 {
+		t.Fatal("a latch closed with Bcast(nil) should be restored closed")
+	}
+}
+
+func TestRegistryEpoch(t *testing.T) {
+
+	g := NewRegistry()
+	a, b := NewLatch(1), NewLatch(1)
+	g.Register("a", a)
+	g.Register("b", b)
+	e := g.Epoch()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := g.WaitEpochAfter(ctx, e); err != context.DeadlineExceeded {
+		t.Fatalf("nothing changed, yet got %v", err)
+	}
+
+	got := make(chan uint64)
+	go func() {
+		n, _ := g.WaitEpochAfter(context.Background(), e)
+		got <- n
+	}()
+	b.Bcast(&Packet{})
+	if n := <-got; n <= e {
+		t.Fatalf("epoch should advance past %d, got %d", e, n)
+	}
+
+	g.Unregister("b")
+	e = g.Epoch()
+	b.Clear()
+	if g.Epoch() != e {
+		t.Fatal("an unregistered latch shouldn't bump the epoch")
+	}
+	a.Clear()
+	if g.Epoch() != e+1 {
+		t.Fatal("a member's transition should bump the epoch")
+	}
+}
+
+func TestRegistryWatchAll(t *testing.T) {
+
+	g := NewRegistry()
+	a := NewLatch(1)
+	a.Bcast(&Packet{Item: "a0"})
+	g.Register("a", a)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	evs := g.WatchAll(ctx)
+	next := func() RegistryEvent {
+		select {
+		case ev := <-evs:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+		}
+		return RegistryEvent{}
+	}
+
+	if ev := next(); ev.Name != "a" || ev.Change != Closed || ev.Packet.Item != "a0" {
+		t.Fatalf("initial state: %+v", ev)
+	}
+	b := NewLatch(1)
+	g.Register("b", b)
+	if ev := next(); ev.Name != "b" || ev.Change != Opened {
+		t.Fatalf("registered: %+v", ev)
+	}
+	a.Clear()
+	if ev := next(); ev.Name != "a" || ev.Change != Opened || ev.Version != a.Version() {
+		t.Fatalf("cleared: %+v", ev)
+	}
+	g.Unregister("b")
+	if ev := next(); ev.Name != "b" || ev.Change != Unregistered {
+		t.Fatalf("unregistered: %+v", ev)
+	}
+	cancel()
+	for range evs {
+	}
+}
+
+func TestRegistryWatchAllEvery(t *testing.T) {
+
+	g := NewRegistry()
+	a := NewLatch(1)
+	g.Register("a", a)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	evs := g.WatchAll(ctx)
+	if ev := <-evs; ev.Name != "a" || ev.Change != Opened {
+		t.Fatalf("initial state: %+v", ev)
+	}
+
+	// nobody reads while these happen.
+	a.CloseSeq([]*Packet{{Item: 1}, {Item: 2}, {Item: 3}})
+	a.Bcast(nil)
+	a.Clear()
+
+	for i := 1; i <= 3; i++ {
+		ev := <-evs
+		if ev.Change != Closed || ev.Packet.Item != i || ev.Version != uint64(i) {
+			t.Fatalf("value %d: %+v", i, ev)
+		}
+	}
+	if ev := <-evs; ev.Change != Closed || ev.Packet != nil || ev.Version != 4 {
+		t.Fatalf("Bcast(nil): %+v", ev)
+	}
+	if ev := <-evs; ev.Change != Opened || ev.Version != 5 {
+		t.Fatalf("cleared: %+v", ev)
+	}
+}
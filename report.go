@@ -0,0 +1,67 @@
+package latch
+
+import "time"
+
+// Report is the outcome of a coordinated run, a startup
+// or a shutdown, in a form that marshals to JSON as is,
+// for logs and admin endpoints. Errors are kept as
+// their text.
+type Report struct {
+	Kind  string        `json:"kind"` // "startup" or "shutdown".
+	Start time.Time     `json:"start"`
+	Took  time.Duration `json:"took_ns"`
+	Steps []ReportStep  `json:"steps"`
+
+	// Pending names those that had not finished their
+	// step when the Report was made.
+	Pending    []string    `json:"pending,omitempty"`
+	Stragglers []Straggler `json:"stragglers,omitempty"`
+}
+
+// ReportStep is one step of a Report, in the order
+// the steps finished.
+type ReportStep struct {
+	Name  string        `json:"name"`
+	Start time.Time     `json:"start"`
+	Took  time.Duration `json:"took_ns"`
+
+	// Latch names the latch whose close ended the
+	// step; empty if none did.
+	Latch string `json:"latch,omitempty"`
+
+	// TimedOut: the step hadn't finished when its time
+	// ran out, and the run moved on without it.
+	TimedOut bool   `json:"timed_out,omitempty"`
+	Err      string `json:"err,omitempty"`
+}
+
+// TimedOut returns the names of the steps that
+// overran.
+func (r *Report) TimedOut() []string {
+	var out []string
+	for _, s := range r.Steps {
+		if s.TimedOut {
+			out = append(out, s.Name)
+		}
+	}
+	return out
+}
+
+// Failed returns the steps that ended in error.
+func (r *Report) Failed() []ReportStep {
+	var out []ReportStep
+	for _, s := range r.Steps {
+		if s.Err != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// errText is err's text, or empty if err is nil.
+func errText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,41 @@
+package latch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReportJSON(t *testing.T) {
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rep := &Report{
+		Kind:  "shutdown",
+		Start: at,
+		Took:  3 * time.Second,
+		Steps: []ReportStep{
+			{Name: "api", Start: at, Took: time.Second, Latch: "api.done"},
+			{Name: "db", Start: at.Add(time.Second), Took: 2 * time.Second, TimedOut: true},
+			{Name: "cache", Start: at, Latch: "cache.done", Err: "boom"},
+		},
+		Stragglers: []Straggler{{Name: "db", Stack: "goroutine 7 [select]:"}},
+	}
+	b, err := json.Marshal(rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Report
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&back, rep) {
+		t.Fatalf("round trip:\n got %+v\nwant %+v", back, *rep)
+	}
+	if got := rep.TimedOut(); !reflect.DeepEqual(got, []string{"db"}) {
+		t.Fatalf("TimedOut() = %v", got)
+	}
+	if got := rep.Failed(); len(got) != 1 || got[0].Name != "cache" {
+		t.Fatalf("Failed() = %v", got)
+	}
+}
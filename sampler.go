@@ -0,0 +1,68 @@
+package latch
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler is sample-and-hold in front of a latch. Writes
+// may come as fast as they like, and cost only an atomic
+// store; once every tick, the most recent write (if there
+// was one since the last tick) is broadcast on the latch.
+// Telemetry producers can then write freely without
+// swamping the latch's consumers with transitions.
+type Sampler struct {
+	l       *Latch
+	pending atomic.Pointer[Packet] // nil if nothing new.
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewSampler starts sampling writes into l every tick,
+// using l's Clock.
+func NewSampler(l *Latch, tick time.Duration) *Sampler {
+	s := &Sampler{
+		l:    l,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	t := l.clock().NewTicker(tick)
+	goLabeled(RoleSampler, l.name, func() {
+		defer close(s.done)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C():
+				s.flush()
+			case <-s.stop:
+				s.flush()
+				return
+			}
+		}
+	})
+	return s
+}
+
+// Write records pak as the latest value, to be
+// broadcast at the next tick. Intervening writes
+// are dropped.
+func (s *Sampler) Write(pak *Packet) {
+	s.pending.Store(pak)
+}
+
+// flush broadcasts the latest write, if any.
+func (s *Sampler) flush() {
+	if pak := s.pending.Swap(nil); pak != nil {
+		s.l.Bcast(pak)
+	}
+}
+
+// Stop stops sampling, after broadcasting any
+// pending write. Writes after Stop are dropped.
+func (s *Sampler) Stop() {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+}
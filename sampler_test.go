@@ -0,0 +1,41 @@
+package latch
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		l := NewLatch(1)
+		s := NewSampler(l, time.Second)
+		defer s.Stop()
+
+		for i := 1; i <= 1000; i++ {
+			s.Write(&Packet{Item: i})
+		}
+		synctest.Wait()
+		if l.IsClosed() {
+			t.Fatal("nothing should be published before the tick")
+		}
+
+		time.Sleep(time.Second)
+		synctest.Wait()
+		if l.Peek().Item != 1000 || l.Version() != 1 {
+			t.Fatalf("expected only the latest write, got %v at version %v", l.Peek().Item, l.Version())
+		}
+
+		time.Sleep(5 * time.Second)
+		synctest.Wait()
+		if l.Version() != 1 {
+			t.Fatal("quiet ticks should not re-broadcast")
+		}
+
+		s.Write(&Packet{Item: "last"})
+		s.Stop()
+		if l.Peek().Item != "last" {
+			t.Fatal("Stop should flush the pending write")
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package latch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Window is a recurring stretch of time: from Start to
+// End, as offsets from midnight, on each of Days (every
+// day, if Days is empty). An End at or before Start runs
+// past midnight into the next day.
+type Window struct {
+	Days       []time.Weekday
+	Start, End time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday,
+	"wed": time.Wednesday, "thu": time.Thursday, "fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWindow parses a Window written as days, then
+// hours: "Mon-Fri 09:00-17:00", "Sat,Sun 10:00-14:00",
+// or "* 22:00-06:00" for every day.
+func ParseWindow(s string) (Window, error) {
+	var w Window
+	f := strings.Fields(s)
+	if len(f) != 2 {
+		return w, fmt.Errorf("latch: window %q: want \"days hh:mm-hh:mm\"", s)
+	}
+	if f[0] != "*" {
+		for _, part := range strings.Split(f[0], ",") {
+			from, to, isRange := strings.Cut(part, "-")
+			d0, ok0 := weekdays[strings.ToLower(from)]
+			d1, ok1 := d0, true
+			if isRange {
+				d1, ok1 = weekdays[strings.ToLower(to)]
+			}
+			if !ok0 || !ok1 {
+				return w, fmt.Errorf("latch: window %q: bad days %q", s, part)
+			}
+			for d := d0; ; d = (d + 1) % 7 {
+				w.Days = append(w.Days, d)
+				if d == d1 {
+					break
+				}
+			}
+		}
+	}
+	from, to, ok := strings.Cut(f[1], "-")
+	var err0, err1 error
+	w.Start, err0 = parseClock(from)
+	w.End, err1 = parseClock(to)
+	if !ok || err0 != nil || err1 != nil {
+		return w, fmt.Errorf("latch: window %q: bad hours %q", s, f[1])
+	}
+	return w, nil
+}
+
+// parseClock parses "hh:mm" as an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// on reports whether w applies to days starting on d.
+func (w Window) on(d time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, wd := range w.Days {
+		if wd == d {
+			return true
+		}
+	}
+	return false
+}
+
+// Schedule drives a latch from recurring Windows: the
+// latch is closed, with pak, while the time is inside
+// any of them, and open outside, so consumers block on
+// it to respect operational windows (business hours,
+// maintenance nights). Timing uses the latch's Clock.
+type Schedule struct {
+	l       *Latch
+	pak     *Packet
+	loc     *time.Location
+	windows []Window
+	clk     Clock
+
+	mu      sync.Mutex
+	timer   Timer
+	stopped bool
+}
+
+// NewSchedule starts driving l from windows, reckoned in
+// loc (or time.Local, if nil). l is set to match the
+// current time straight away.
+func NewSchedule(l *Latch, pak *Packet, loc *time.Location, windows ...Window) *Schedule {
+	if loc == nil {
+		loc = time.Local
+	}
+	s := &Schedule{
+		l:       l,
+		pak:     pak,
+		loc:     loc,
+		windows: windows,
+		clk:     l.clock(),
+	}
+	s.tick()
+	return s
+}
+
+// tick sets the latch for now, and arms the next change.
+func (s *Schedule) tick() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	now := s.clk.Now()
+	s.l.mut.Lock()
+	if s.in(now) {
+		s.l.bcastIfOpen(s.pak)
+	} else if s.l.avail {
+		s.l.clear()
+	}
+	s.l.mut.Unlock()
+	if at, _, ok := s.next(now); ok {
+		s.timer = s.clk.AfterFunc(at.Sub(now), s.tick)
+	}
+}
+
+// Stop stops driving the latch, leaving it as it is.
+func (s *Schedule) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+}
+
+// Next returns when the latch is next due to change, and
+// whether it will then close (or open); ok is false if
+// it never will.
+func (s *Schedule) Next() (at time.Time, closes bool, ok bool) {
+	return s.next(s.clk.Now())
+}
+
+// span is one occurrence of a Window.
+type span struct{ from, to time.Time }
+
+// spans returns the occurrences of the windows that
+// start within a week either side of t.
+func (s *Schedule) spans(t time.Time) []span {
+	t = t.In(s.loc)
+	var out []span
+	for day := -8; day <= 8; day++ {
+		y, m, d := t.Date()
+		midnight := time.Date(y, m, d+day, 0, 0, 0, 0, s.loc)
+		for _, w := range s.windows {
+			if !w.on(midnight.Weekday()) {
+				continue
+			}
+			end := w.End
+			if end <= w.Start {
+				end += 24 * time.Hour
+			}
+			out = append(out, span{
+				from: clockTime(midnight, w.Start),
+				to:   clockTime(midnight, end),
+			})
+		}
+	}
+	return out
+}
+
+// clockTime is the wall-clock time off past midnight,
+// reckoned in hours and minutes, so that a DST change
+// doesn't shift it.
+func clockTime(midnight time.Time, off time.Duration) time.Time {
+	y, m, d := midnight.Date()
+	days := int(off / (24 * time.Hour))
+	off %= 24 * time.Hour
+	return time.Date(y, m, d+days, int(off/time.Hour), int(off%time.Hour/time.Minute),
+		int(off%time.Minute/time.Second), int(off%time.Second), midnight.Location())
+}
+
+func (s *Schedule) in(t time.Time) bool {
+	for _, sp := range s.spans(t) {
+		if !t.Before(sp.from) && t.Before(sp.to) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schedule) next(t time.Time) (at time.Time, closes bool, ok bool) {
+	var bounds []time.Time
+	for _, sp := range s.spans(t) {
+		bounds = append(bounds, sp.from, sp.to)
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+	now := s.in(t)
+	for _, b := range bounds {
+		if b.After(t) && s.in(b) != now {
+			return b, !now, true
+		}
+	}
+	return time.Time{}, false, false
+}
@@ -0,0 +1,59 @@
+package latch
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("Fri-Mon 22:00-06:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Days) != 4 || w.Days[0] != time.Friday || w.Days[3] != time.Monday ||
+		w.Start != 22*time.Hour || w.End != 6*time.Hour+30*time.Minute {
+		t.Fatalf("got %+v", w)
+	}
+	for _, bad := range []string{"Mon", "Xyz 09:00-10:00", "Mon 9-10"} {
+		if _, err := ParseWindow(bad); err == nil {
+			t.Fatalf("%q should not parse", bad)
+		}
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		// the bubble starts at midnight UTC, 2000-01-01, a Saturday.
+		hours, err := ParseWindow("Mon-Fri 09:00-17:00")
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := NewLatch(1)
+		s := NewSchedule(l, &Packet{Item: "open for business"}, time.UTC, hours)
+		defer s.Stop()
+
+		if l.IsClosed() {
+			t.Fatal("closed on a Saturday")
+		}
+		at, closes, ok := s.Next()
+		monday9 := time.Date(2000, 1, 3, 9, 0, 0, 0, time.UTC)
+		if !ok || !closes || !at.Equal(monday9) {
+			t.Fatalf("Next() = %v, %v, %v", at, closes, ok)
+		}
+
+		time.Sleep(monday9.Sub(time.Now()))
+		synctest.Wait()
+		if pak := l.Peek(); pak == nil || pak.Item != "open for business" {
+			t.Fatalf("got %v at %v", pak, time.Now())
+		}
+		if at, closes, _ := s.Next(); closes || !at.Equal(monday9.Add(8*time.Hour)) {
+			t.Fatalf("Next() = %v, %v", at, closes)
+		}
+		time.Sleep(8 * time.Hour)
+		synctest.Wait()
+		if l.IsClosed() {
+			t.Fatal("still closed after hours")
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package latch
+
+import "sync"
+
+// Selection reports that the latch at Index changed.
+// Closed tells whether it is now closed; Packet is what
+// it was closed with, or nil if it was cleared (opened).
+type Selection struct {
+	Index  int
+	Packet *Packet
+	Closed bool
+}
+
+// Selector aggregates any number of latches behind a
+// single channel, so a caller can select on "any of
+// these changed" without writing its own fan-in, or
+// calling reflect.Select on every iteration.
+//
+// A member that is already closed when Added is reported
+// straight away; after that each member is reported on
+// every close or Clear. If the reader falls behind, a
+// member's intermediate transitions are coalesced and
+// only its latest state is delivered.
+type Selector struct {
+	mut     sync.Mutex
+	next    int
+	members map[int]chan struct{}
+	ch      chan Selection
+}
+
+// NewSelector makes an empty Selector.
+func NewSelector() *Selector {
+	return &Selector{
+		members: make(map[int]chan struct{}),
+		ch:      make(chan Selection),
+	}
+}
+
+// Ch returns the channel on which Selections arrive.
+// It is never closed.
+func (s *Selector) Ch() <-chan Selection {
+	return s.ch
+}
+
+// Add starts watching l, and returns the index under
+// which its Selections will arrive. Indexes are not
+// reused after Remove.
+func (s *Selector) Add(l *Latch) int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	i := s.next
+	s.next++
+	stop := make(chan struct{})
+	s.members[i] = stop
+	goLabeled(RoleFanIn, l.name, func() { s.watch(i, l, stop) })
+	return i
+}
+
+// Remove stops watching the latch at index i. A
+// Selection for i that was already in flight may still
+// arrive after Remove returns. It returns false if i
+// was not a member.
+func (s *Selector) Remove(i int) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	stop, ok := s.members[i]
+	if !ok {
+		return false
+	}
+	delete(s.members, i)
+	close(stop)
+	return true
+}
+
+// Len returns the number of member latches.
+func (s *Selector) Len() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return len(s.members)
+}
+
+func (s *Selector) watch(i int, l *Latch, stop chan struct{}) {
+	cur, closed, version, next := l.observe()
+	pending := closed
+	for {
+		if pending {
+			select {
+			case s.ch <- Selection{Index: i, Packet: cur, Closed: closed}:
+				pending = false
+			case <-next:
+				// deliver the latest state instead.
+			case <-stop:
+				return
+			}
+		} else {
+			select {
+			case <-next:
+			case <-stop:
+				return
+			}
+		}
+		var v uint64
+		cur, closed, v, next = l.observe()
+		if v != version {
+			version = v
+			pending = true
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package latch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelector(t *testing.T) {
+
+	s := NewSelector()
+	a := NewLatch(1)
+	b := NewLatch(1)
+	b.Bcast(&Packet{Item: "b"})
+
+	ia := s.Add(a)
+	ib := s.Add(b)
+	if s.Len() != 2 {
+		t.Fatalf("expected 2 members, got %v", s.Len())
+	}
+
+	// b was already closed when added.
+	sel := <-s.Ch()
+	if sel.Index != ib || sel.Packet.Item != "b" {
+		t.Fatalf("expected b's close, got %#v", sel)
+	}
+
+	a.Bcast(&Packet{Item: "a"})
+	sel = <-s.Ch()
+	if sel.Index != ia || sel.Packet.Item != "a" {
+		t.Fatalf("expected a's close, got %#v", sel)
+	}
+
+	a.Clear()
+	sel = <-s.Ch()
+	if sel.Index != ia || sel.Packet != nil || sel.Closed {
+		t.Fatalf("expected a's clear, got %#v", sel)
+	}
+
+	if !s.Remove(ia) || s.Remove(ia) {
+		t.Fatal("Remove should succeed exactly once")
+	}
+	a.Bcast(&Packet{Item: "a2"})
+	select {
+	case sel = <-s.Ch():
+		t.Fatalf("removed member should not be reported, got %#v", sel)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSelectorCoalesces(t *testing.T) {
+
+	s := NewSelector()
+	a := NewLatch(1)
+	s.Add(a)
+
+	// nobody is reading; only the latest state survives.
+	for i := 0; i < 5; i++ {
+		a.Bcast(&Packet{Item: i})
+		a.Clear()
+	}
+	a.Bcast(&Packet{Item: "last"})
+
+	waitFor(t, func() bool {
+		select {
+		case sel := <-s.Ch():
+			return sel.Packet != nil && sel.Packet.Item == "last"
+		default:
+			return false
+		}
+	})
+}
+
+func TestSelectorBcastNil(t *testing.T) {
+
+	s := NewSelector()
+	l := NewLatch(1)
+	l.Bcast(nil)
+
+	i := s.Add(l)
+	select {
+	case sel := <-s.Ch():
+		if sel.Index != i || sel.Packet != nil || !sel.Closed {
+			t.Fatalf("expected a close with nil, got %#v", sel)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a latch closed with nil was never reported")
+	}
+}
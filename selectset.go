@@ -0,0 +1,127 @@
+package latch
+
+import (
+	"context"
+	"reflect"
+)
+
+// SourceKind says what sort of source fired in a SelectSet.
+type SourceKind int
+
+const (
+	SourceChan SourceKind = iota
+	SourceContext
+	SourceLatch
+)
+
+// Fired describes the SelectSet source that fired.
+type Fired struct {
+	Index int // as returned when the source was added.
+	Kind  SourceKind
+
+	// For SourceChan: the value received, and false
+	// for OK if the channel was closed instead.
+	Value interface{}
+	OK    bool
+
+	// For SourceLatch: the latch's value; nil if
+	// it was closed with Bcast(nil).
+	Packet *Packet
+
+	// For SourceContext: context.Cause of the context.
+	Err error
+}
+
+// SelectSet waits on a mix of channels, contexts, and
+// latches at once; for supervisors whose hand-written
+// selects would otherwise grow unwieldy, or vary at
+// runtime. Add sources, then Wait for the first to fire.
+//
+// Latches are watched through Done, so waiting
+// consumes nothing from their Ch().
+type SelectSet struct {
+	srcs []selectSource
+}
+
+type selectSource struct {
+	kind SourceKind
+	ch   reflect.Value
+	ctx  context.Context
+	l    *Latch
+}
+
+// AddChan adds ch, which must be a channel that can be
+// received from, and returns its index. It panics
+// otherwise.
+func (s *SelectSet) AddChan(ch interface{}) int {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan || v.Type().ChanDir()&reflect.RecvDir == 0 {
+		panic("latch: SelectSet.AddChan needs a receivable channel")
+	}
+	return s.add(selectSource{kind: SourceChan, ch: v})
+}
+
+// AddContext adds ctx, which fires when done,
+// and returns its index.
+func (s *SelectSet) AddContext(ctx context.Context) int {
+	return s.add(selectSource{kind: SourceContext, ctx: ctx})
+}
+
+// AddLatch adds l, which fires while closed,
+// and returns its index.
+func (s *SelectSet) AddLatch(l *Latch) int {
+	return s.add(selectSource{kind: SourceLatch, l: l})
+}
+
+func (s *SelectSet) add(src selectSource) int {
+	s.srcs = append(s.srcs, src)
+	return len(s.srcs) - 1
+}
+
+// Wait blocks until one of the sources fires, and
+// describes it. If several are ready, one is chosen at
+// random, as by select. If ctx is done first, Wait
+// returns ctx.Err().
+func (s *SelectSet) Wait(ctx context.Context) (Fired, error) {
+	cases := make([]reflect.SelectCase, len(s.srcs)+1)
+	for i, src := range s.srcs {
+		var ch reflect.Value
+		switch src.kind {
+		case SourceChan:
+			ch = src.ch
+		case SourceContext:
+			ch = reflect.ValueOf(src.ctx.Done())
+		case SourceLatch:
+			ch = reflect.ValueOf(src.l.Done())
+		}
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: ch}
+	}
+	cases[len(s.srcs)] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+
+	for {
+		i, v, ok := reflect.Select(cases)
+		if i == len(s.srcs) {
+			return Fired{}, ctx.Err()
+		}
+		src := s.srcs[i]
+		f := Fired{Index: i, Kind: src.kind}
+		switch src.kind {
+		case SourceChan:
+			if ok {
+				f.Value = v.Interface()
+			}
+			f.OK = ok
+		case SourceContext:
+			f.Err = context.Cause(src.ctx)
+		case SourceLatch:
+			var closed bool
+			f.Packet, closed = src.l.peek()
+			if !closed {
+				// cleared since it fired; watch its new Done.
+				cases[i].Chan = reflect.ValueOf(src.l.Done())
+				continue
+			}
+		}
+		return f, nil
+	}
+}
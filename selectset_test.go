@@ -0,0 +1,73 @@
+package latch
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSelectSet(t *testing.T) {
+
+	var s SelectSet
+	jobs := make(chan int, 1)
+	l := NewLatch(1)
+	cctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ij := s.AddChan(jobs)
+	il := s.AddLatch(l)
+	ic := s.AddContext(cctx)
+	ctx := context.Background()
+
+	jobs <- 7
+	f, err := s.Wait(ctx)
+	if err != nil || f.Index != ij || f.Kind != SourceChan || f.Value != 7 || !f.OK {
+		t.Fatalf("expected the job, got %#v, %v", f, err)
+	}
+
+	l.Bcast(&Packet{Item: "stop"})
+	f, _ = s.Wait(ctx)
+	if f.Index != il || f.Packet.Item != "stop" {
+		t.Fatalf("expected the latch, got %#v", f)
+	}
+	if len(l.Ch()) != 1 {
+		t.Fatal("waiting should not consume from the latch")
+	}
+	l.Clear()
+
+	cancel()
+	f, _ = s.Wait(ctx)
+	if f.Index != ic || f.Err != context.Canceled {
+		t.Fatalf("expected the context, got %#v", f)
+	}
+
+	close(jobs)
+	var only SelectSet
+	only.AddChan(jobs)
+	if f, _ = only.Wait(ctx); f.OK {
+		t.Fatal("a closed channel should fire with OK false")
+	}
+
+	done, stop := context.WithCancel(ctx)
+	stop()
+	var none SelectSet
+	none.AddLatch(l)
+	if _, err := none.Wait(done); err != context.Canceled {
+		t.Fatalf("Wait should honor its ctx, got %v", err)
+	}
+}
+
+func TestSelectSetBcastNil(t *testing.T) {
+
+	var s SelectSet
+	l := NewLatch(1)
+	i := s.AddLatch(l)
+	l.Bcast(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	f, err := s.Wait(ctx)
+	if err != nil || f.Index != i || f.Kind != SourceLatch || f.Packet != nil {
+		t.Fatalf("Wait() = %+v, %v", f, err)
+	}
+}
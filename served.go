@@ -0,0 +1,101 @@
+package latch
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// MaxBufferedSz is the largest sz for which NewLatch
+// allocates a buffered channel of sz copies.
+//
+// Above it, memory is kept O(1) in the number of
+// readers: Ch() buffers only MaxBufferedSz copies, and
+// while the latch is closed a single goroutine tops the
+// buffer up as receivers take from it, however many
+// there are. There is then nothing to Refresh, and no
+// reader can ever be starved. The goroutine exits on
+// Clear or Reset, or once the latch is garbage: it
+// doesn't hold the latch itself, so a closed latch that
+// is dropped doesn't leak it.
+//
+// Bcast fills the buffer itself, and Ch() tops it up
+// when it finds it half empty, so the usual poll,
+//
+//	select {
+//	case <-l.Ch():
+//	default:
+//	}
+//
+// sees a closed latch as closed, even in a tight loop.
+// The one behavioral difference is that such a poll may
+// yet take the default, if other readers empty the
+// buffer between its Ch() and its receive: MaxBufferedSz
+// copies at once. Blocking receives are unaffected.
+const MaxBufferedSz = 1024
+
+// startServer launches the goroutine feeding the
+// current value to Ch(). Caller must hold r.mut.
+func (r *Latch) startServer() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	r.srvStop, r.srvDone = stop, done
+	r.srvCleanup = runtime.AddCleanup(r, closeStop, stop)
+
+	// nothing here may refer to r; see MaxBufferedSz.
+	ch, cur, clone := r.c().ch, r.cur, r.cloner
+	// we are the only sender, so this can't block.
+	for len(ch) < cap(ch) {
+		ch <- copyWith(clone, cur)
+	}
+	activeServers.Add(1)
+	goLabeled(RoleServer, r.name, func() {
+		defer activeServers.Add(-1)
+		defer close(done)
+		for {
+			select {
+			case ch <- copyWith(clone, cur):
+			case <-stop:
+				return
+			}
+		}
+	})
+}
+
+// topUp refills c, a served latch's buffer, for the
+// receivers that outrun the goroutine. It only tries the
+// lock, so Ch() never waits on it; whoever holds it is
+// about to refill c, or replace it.
+func (r *Latch) topUp(c *chanState) {
+	if !r.mut.TryLock() {
+		return
+	}
+	defer r.mut.Unlock()
+	if r.srvStop == nil || r.c() != c {
+		return
+	}
+	for len(c.ch) < cap(c.ch) {
+		select {
+		case c.ch <- r.copyOf(r.cur):
+		default:
+			return
+		}
+	}
+}
+
+// activeServers counts running served-latch goroutines.
+var activeServers atomic.Int64
+
+// stopServer ends any goroutine feeding Ch(), waits
+// for it to finish, and empties the buffer; so once we
+// return, no receiver can get the old value.
+// Caller must hold r.mut.
+func (r *Latch) stopServer() {
+	if r.srvStop == nil {
+		return
+	}
+	r.srvCleanup.Stop()
+	close(r.srvStop)
+	<-r.srvDone
+	r.srvStop, r.srvDone = nil, nil
+	r.drain()
+}
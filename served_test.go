@@ -0,0 +1,81 @@
+package latch
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestServedLatch(t *testing.T) {
+
+	latch := NewLatch(MaxBufferedSz + 1)
+	if cap(latch.Ch()) != MaxBufferedSz {
+		t.Fatal("a latch with sz beyond MaxBufferedSz should buffer only MaxBufferedSz copies")
+	}
+
+	pak := &Packet{Item: "many readers"}
+	latch.Bcast(pak)
+
+	// far more reads than sz, with no Refresh.
+	for i := 0; i < 3*(MaxBufferedSz+1); i++ {
+		if b := <-latch.Ch(); b != pak {
+			t.Fatal("served latch gave the wrong packet")
+		}
+	}
+
+	next := &Packet{Item: "next"}
+	latch.Bcast(next)
+	if b := <-latch.Ch(); b != next {
+		t.Fatal("after a second Bcast, readers must see only the new packet")
+	}
+
+	latch.Clear()
+	select {
+	case <-latch.Ch():
+		t.Fatal("after Clear returns, receives must block")
+	default:
+	}
+}
+
+func TestServedLatchDropped(t *testing.T) {
+
+	before := activeServers.Load()
+	func() {
+		l := NewLatch(MaxBufferedSz + 1)
+		l.Bcast(&Packet{Item: "dropped while closed"})
+		<-l.Ch()
+	}()
+	waitFor(t, func() bool {
+		runtime.GC()
+		return activeServers.Load() == before
+	})
+}
+
+func BenchmarkServedLatchRead(b *testing.B) {
+	latch := NewLatch(MaxBufferedSz + 1)
+	latch.Bcast(&Packet{Item: 1})
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			<-latch.Ch()
+		}
+	})
+	latch.Clear()
+}
+
+func TestServedLatchPoll(t *testing.T) {
+
+	latch := NewLatch(MaxBufferedSz + 1)
+	latch.Bcast(&Packet{Item: "closed"})
+	misses := 0
+	for i := 0; i < 100*MaxBufferedSz; i++ {
+		select {
+		case <-latch.Ch():
+		default:
+			misses++
+		}
+	}
+	if misses > 0 {
+		t.Fatalf("polls of a closed served latch missed %d times", misses)
+	}
+	latch.Clear()
+}
@@ -0,0 +1,42 @@
+package latch
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// NotifySignals closes l, with a Packet whose Item is the
+// os.Signal received, when the process receives any of sigs.
+// With no sigs, it listens for os.Interrupt and
+// SIGTERM: not every signal, as signal.Notify would,
+// since the runtime itself sends SIGURG to preempt busy
+// goroutines.
+//
+// If l is closed by some other means first, we stop
+// listening for sigs; the latch is then the single
+// source of truth for process shutdown.
+func NotifySignals(l *Latch, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sigs...)
+	goLabeled(RoleFollower, l.name, func() {
+		defer signal.Stop(c)
+		for {
+			_, closed, _, next := l.observe()
+			if closed {
+				return
+			}
+			select {
+			case sig := <-c:
+				l.mut.Lock()
+				l.bcastIfOpen(&Packet{Item: sig})
+				l.mut.Unlock()
+				return
+			case <-next:
+			}
+		}
+	})
+}
@@ -0,0 +1,83 @@
+//go:build !windows
+
+package latch
+
+import (
+	"bytes"
+	"os"
+	"runtime/pprof"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNotifySignals(t *testing.T) {
+
+	latch := NewLatch(1)
+	NotifySignals(latch, syscall.SIGUSR1)
+
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proc.Signal(syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case b := <-latch.Ch():
+		if b.Item != syscall.SIGUSR1 {
+			t.Fatalf("expected SIGUSR1 in the packet, got %v", b.Item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal never closed the latch")
+	}
+}
+
+func TestNotifySignalsStops(t *testing.T) {
+
+	latch := NewLatch(1, WithName("sigstop"))
+	NotifySignals(latch, syscall.SIGUSR2)
+	listening := func() bool {
+		var buf bytes.Buffer
+		pprof.Lookup("goroutine").WriteTo(&buf, 1)
+		return strings.Contains(buf.String(), `"latch.name":"sigstop"`)
+	}
+	if !listening() {
+		t.Fatal("NotifySignals should be listening")
+	}
+	latch.Bcast(nil)
+	waitFor(t, func() bool { return !listening() })
+}
+
+func TestNotifySignalsDefault(t *testing.T) {
+
+	latch := NewLatch(1)
+	NotifySignals(latch)
+	defer latch.Bcast(nil)
+
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// as the runtime does, to preempt.
+	if err := proc.Signal(syscall.SIGURG); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if latch.IsClosed() {
+		t.Fatalf("SIGURG should not close the latch, got %v", latch.Peek().Item)
+	}
+	if err := proc.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case b := <-latch.Ch():
+		if b.Item != syscall.SIGTERM {
+			t.Fatalf("expected SIGTERM in the packet, got %v", b.Item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGTERM never closed the latch")
+	}
+}
@@ -0,0 +1,90 @@
+package latch
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SignalLatch is a latch with no payload, for the common
+// case of a pure signal: "shut down", "ready". Its Ch()
+// is a chan struct{} that is closed while the latch is
+// set, so any number of receives succeed, with no
+// Packet, no allocation, and nothing to Refresh; while
+// Changed gives edge-triggered notice of each Set and
+// Clear. Clear allocates a new channel; Set allocates
+// nothing, unless Changed has been called since the last
+// transition, for a channel of its own.
+type SignalLatch struct {
+	set atomic.Bool
+
+	mu      sync.Mutex
+	ch      chan struct{}
+	version uint64
+	chg     chan struct{} // made by Changed, as needed.
+}
+
+// NewSignalLatch makes a new, clear, SignalLatch.
+func NewSignalLatch() *SignalLatch {
+	return &SignalLatch{ch: make(chan struct{})}
+}
+
+// Set sets the latch, if it is clear.
+func (s *SignalLatch) Set() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.set.Load() {
+		return
+	}
+	s.set.Store(true)
+	close(s.ch)
+	s.transition()
+}
+
+// Clear clears the latch, if it is set.
+func (s *SignalLatch) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.set.Load() {
+		return
+	}
+	s.set.Store(false)
+	s.ch = make(chan struct{})
+	s.transition()
+}
+
+// transition wakes Changed-watchers. Caller must
+// hold s.mu.
+func (s *SignalLatch) transition() {
+	s.version++
+	if s.chg != nil {
+		close(s.chg)
+		s.chg = nil
+	}
+}
+
+// Ch returns a channel that is closed while the latch
+// is set. A Clear replaces it; a reader that selects on
+// an old Ch() is woken by the next Set only if it gets
+// Ch() afresh, so re-get it after each Clear.
+func (s *SignalLatch) Ch() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ch
+}
+
+// IsSet reports whether the latch is set, without
+// locking.
+func (s *SignalLatch) IsSet() bool {
+	return s.set.Load()
+}
+
+// Changed returns the Version, and a channel that is
+// closed at the next Set or Clear.
+func (s *SignalLatch) Changed() (version uint64, next <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.chg == nil {
+		s.chg = make(chan struct{})
+	}
+	return s.version, s.chg
+}
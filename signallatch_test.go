@@ -0,0 +1,50 @@
+package latch
+
+import "testing"
+
+func TestSignalLatch(t *testing.T) {
+
+	s := NewSignalLatch()
+	ch := s.Ch()
+	v0, next := s.Changed()
+	select {
+	case <-ch:
+		t.Fatal("a clear latch should block")
+	default:
+	}
+
+	s.Set()
+	s.Set()
+	for i := 0; i < 3; i++ {
+		<-ch // level-triggered: every receive succeeds.
+	}
+	<-next
+	if v, _ := s.Changed(); v != v0+1 || !s.IsSet() {
+		t.Fatalf("one Set should be one transition, got version %d", v)
+	}
+
+	s.Clear()
+	select {
+	case <-s.Ch():
+		t.Fatal("Clear should block receives on Ch()")
+	default:
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		s.Set()
+		<-s.Ch()
+		s.IsSet()
+	})
+	if allocs != 0 {
+		t.Fatalf("reads of a set SignalLatch allocate %v times", allocs)
+	}
+
+	// only Clear's new channel, with nobody watching Changed.
+	allocs = testing.AllocsPerRun(100, func() {
+		s.Clear()
+		s.Set()
+	})
+	if allocs != 1 {
+		t.Fatalf("a Clear and Set allocate %v times", allocs)
+	}
+}
@@ -0,0 +1,82 @@
+package latch
+
+import "sync"
+
+// Splitter mirrors every transition of a source latch
+// into any number of output latches. Each output has its
+// own size and options (a WithCloner, say, or a
+// WithReducer), so subsystems can hold differently
+// shaped views of one signal without sharing a buffer,
+// or Refresh-ing each other's readers.
+type Splitter struct {
+	src *Latch
+
+	mut     sync.Mutex
+	cur     *Packet // as last mirrored.
+	closed  bool
+	outputs []*Latch
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewSplitter starts mirroring src.
+func NewSplitter(src *Latch) *Splitter {
+	s := &Splitter{
+		src:  src,
+		stop: make(chan struct{}),
+	}
+	cur, closed, version, next := src.observe()
+	s.cur, s.closed = cur, closed
+	goLabeled(RoleFanIn, src.name, func() { s.mirror(version, next) })
+	return s
+}
+
+// NewOutput makes a new output latch of size sz, with
+// opts as for NewLatch. It starts in the source's
+// current state, and follows it from then on.
+func (s *Splitter) NewOutput(sz int, opts ...Option) *Latch {
+	l := NewLatch(sz, opts...)
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.closed {
+		l.Bcast(s.cur)
+	}
+	s.outputs = append(s.outputs, l)
+	return l
+}
+
+// Stop stops mirroring. The outputs keep their
+// current states.
+func (s *Splitter) Stop() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+func (s *Splitter) mirror(version uint64, next <-chan struct{}) {
+	for {
+		select {
+		case <-next:
+		case <-s.stop:
+			return
+		}
+		select {
+		case <-s.stop:
+			// both were ready; Stop wins.
+			return
+		default:
+		}
+		s.mut.Lock()
+		var cur *Packet
+		var closed bool
+		cur, closed, version, next = s.src.observe()
+		s.cur, s.closed = cur, closed
+		for _, l := range s.outputs {
+			if closed {
+				l.Bcast(cur)
+			} else {
+				l.Clear()
+			}
+		}
+		s.mut.Unlock()
+	}
+}
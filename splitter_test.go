@@ -0,0 +1,55 @@
+package latch
+
+import "testing"
+
+func TestSplitter(t *testing.T) {
+
+	src := NewLatch(1)
+	src.Bcast(&Packet{Item: "v1"})
+
+	s := NewSplitter(src)
+	defer s.Stop()
+	small := s.NewOutput(1)
+	big := s.NewOutput(MaxBufferedSz + 1)
+
+	if small.Peek().Item != "v1" || big.Peek().Item != "v1" {
+		t.Fatal("outputs should start in the source's state")
+	}
+
+	src.Bcast(&Packet{Item: "v2"})
+	waitFor(t, func() bool {
+		return small.Peek().Item == "v2" && big.Peek().Item == "v2"
+	})
+	for i := 0; i < 3; i++ {
+		if (<-big.Ch()).Item != "v2" {
+			t.Fatal("big output should serve many readers")
+		}
+	}
+
+	src.Clear()
+	waitFor(t, func() bool { return !small.IsClosed() && !big.IsClosed() })
+
+	s.Stop()
+	src.Bcast(&Packet{Item: "v3"})
+	if small.IsClosed() {
+		t.Fatal("a stopped Splitter should leave outputs alone")
+	}
+}
+
+func TestSplitterBcastNil(t *testing.T) {
+
+	src := NewLatch(1)
+	src.Bcast(nil)
+
+	s := NewSplitter(src)
+	defer s.Stop()
+	out := s.NewOutput(1)
+	if !out.IsClosed() {
+		t.Fatal("an output of a source closed with nil should start closed")
+	}
+
+	src.Clear()
+	waitFor(t, func() bool { return !out.IsClosed() })
+	src.Bcast(nil)
+	waitFor(t, func() bool { return out.IsClosed() })
+}
@@ -0,0 +1,273 @@
+/*
+Package sqllatch drains a database/sql connection pool
+with latches, for graceful shutdown.
+
+A DB wraps a *sql.DB. Every query, transaction and
+dedicated connection taken through it counts as in
+flight until it is finished with: Rows closed (or read
+to the end), a Row Scanned, a Tx committed or rolled
+back, a Conn closed. Drain closes the Draining latch;
+from then on work already in flight may finish, but
+new acquisitions block until the drain is lifted, or
+their context is done. When nothing is left in flight,
+the Done latch closes, with the Packet given to Drain,
+and the pool can be closed safely:
+
+	db := sqllatch.New(sqlDB, 1)
+	...
+	db.Drain(&latch.Packet{Item: "shutdown"})
+	<-db.Done().Ch()
+	sqlDB.Close()
+*/
+package sqllatch
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/glycerine/latch"
+)
+
+// DB is a *sql.DB that can be drained.
+type DB struct {
+	db    *sql.DB
+	drain *latch.Latch
+	done  *latch.Latch
+
+	mu     sync.Mutex
+	active int
+}
+
+// New wraps db. Its Draining and Done latches have
+// backing channels of size sz.
+func New(db *sql.DB, sz int) *DB {
+	return &DB{
+		db:    db,
+		drain: latch.NewLatch(sz),
+		done:  latch.NewLatch(sz),
+	}
+}
+
+// DB returns the wrapped pool. Work done on it directly
+// isn't counted as in flight.
+func (d *DB) DB() *sql.DB {
+	return d.db
+}
+
+// Draining is closed, with the Packet given to Drain,
+// while the pool is draining.
+func (d *DB) Draining() *latch.ROLatch {
+	return d.drain.ReadOnly()
+}
+
+// Done is closed, with the Packet given to Drain, once
+// the pool is draining and nothing is in flight.
+func (d *DB) Done() *latch.ROLatch {
+	return d.done.ReadOnly()
+}
+
+// InFlight returns the number of queries, transactions
+// and connections in flight.
+func (d *DB) InFlight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.active
+}
+
+// Drain stops new acquisitions, and closes Done, with
+// pak, once those in flight have finished.
+func (d *DB) Drain(pak *latch.Packet) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.drain.Bcast(pak)
+	if d.active == 0 {
+		d.done.Bcast(pak)
+	}
+}
+
+// Resume lifts a drain: Draining and Done are opened,
+// and blocked acquisitions go ahead.
+func (d *DB) Resume() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.done.Clear()
+	d.drain.Clear()
+}
+
+// acquire counts one more in flight, first waiting
+// out any drain.
+func (d *DB) acquire(ctx context.Context) error {
+	for {
+		// drain changes only under d.mu, so next and
+		// IsClosed agree.
+		d.mu.Lock()
+		_, _, next := d.drain.Observe()
+		if !d.drain.IsClosed() {
+			d.active++
+			d.mu.Unlock()
+			return nil
+		}
+		d.mu.Unlock()
+		select {
+		case <-next:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release counts one fewer in flight.
+func (d *DB) release() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.active == 0 {
+		if d.drain.IsClosed() {
+			d.done.Bcast(d.drain.Peek())
+		}
+	}
+}
+
+// releaser returns a func that releases just once.
+func (d *DB) releaser() func() {
+	var once sync.Once
+	return func() { once.Do(d.release) }
+}
+
+// ExecContext is the sql.DB ExecContext.
+func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if err := d.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	return d.db.ExecContext(ctx, query, args...)
+}
+
+// QueryContext is the sql.DB QueryContext. The query is
+// in flight until the Rows are closed.
+func (d *DB) QueryContext(ctx context.Context, query string, args ...any) (*Rows, error) {
+	if err := d.acquire(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		d.release()
+		return nil, err
+	}
+	return &Rows{Rows: rows, release: d.releaser()}, nil
+}
+
+// QueryRowContext is the sql.DB QueryRowContext. The
+// query is in flight until the Row is Scanned.
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	if err := d.acquire(ctx); err != nil {
+		return &Row{err: err}
+	}
+	return &Row{row: d.db.QueryRowContext(ctx, query, args...), release: d.releaser()}
+}
+
+// BeginTx is the sql.DB BeginTx. The transaction is in
+// flight until committed or rolled back.
+func (d *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if err := d.acquire(ctx); err != nil {
+		return nil, err
+	}
+	tx, err := d.db.BeginTx(ctx, opts)
+	if err != nil {
+		d.release()
+		return nil, err
+	}
+	return &Tx{Tx: tx, release: d.releaser()}, nil
+}
+
+// Conn is the sql.DB Conn. The connection is in flight
+// until closed.
+func (d *DB) Conn(ctx context.Context) (*Conn, error) {
+	if err := d.acquire(ctx); err != nil {
+		return nil, err
+	}
+	c, err := d.db.Conn(ctx)
+	if err != nil {
+		d.release()
+		return nil, err
+	}
+	return &Conn{Conn: c, release: d.releaser()}, nil
+}
+
+// Rows is a *sql.Rows that is in flight until closed.
+type Rows struct {
+	*sql.Rows
+	release func()
+}
+
+// Next is the sql.Rows Next; the Rows are closed,
+// and so released, after the last one.
+func (r *Rows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.release()
+	return false
+}
+
+// Close is the sql.Rows Close.
+func (r *Rows) Close() error {
+	defer r.release()
+	return r.Rows.Close()
+}
+
+// Row is a *sql.Row that is in flight until Scanned.
+type Row struct {
+	row     *sql.Row
+	err     error
+	release func()
+}
+
+// Scan is the sql.Row Scan.
+func (r *Row) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.release()
+	return r.row.Scan(dest...)
+}
+
+// Err is the sql.Row Err.
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Err()
+}
+
+// Tx is a *sql.Tx that is in flight until committed
+// or rolled back.
+type Tx struct {
+	*sql.Tx
+	release func()
+}
+
+// Commit is the sql.Tx Commit.
+func (t *Tx) Commit() error {
+	defer t.release()
+	return t.Tx.Commit()
+}
+
+// Rollback is the sql.Tx Rollback.
+func (t *Tx) Rollback() error {
+	defer t.release()
+	return t.Tx.Rollback()
+}
+
+// Conn is a *sql.Conn that is in flight until closed.
+type Conn struct {
+	*sql.Conn
+	release func()
+}
+
+// Close is the sql.Conn Close.
+func (c *Conn) Close() error {
+	defer c.release()
+	return c.Conn.Close()
+}
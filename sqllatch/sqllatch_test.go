@@ -0,0 +1,157 @@
+package sqllatch
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/glycerine/latch"
+)
+
+// slow holds up any "slow" statement until closed;
+// each test makes its own.
+var slow chan struct{}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(q string) (driver.Stmt, error) { return fakeStmt(q), nil }
+func (fakeConn) Close() error                          { return nil }
+func (fakeConn) Begin() (driver.Tx, error)             { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt string
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if s == "slow" {
+		<-slow
+	}
+	return driver.RowsAffected(1), nil
+}
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error) { return &fakeRows{}, nil }
+
+type fakeRows struct{ done bool }
+
+func (*fakeRows) Columns() []string { return []string{"n"} }
+func (*fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func init() {
+	sql.Register("sqllatchfake", fakeDriver{})
+}
+
+func TestDrain(t *testing.T) {
+
+	sqlDB, err := sql.Open("sqllatchfake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db := New(sqlDB, 1)
+	ctx := context.Background()
+	slow = make(chan struct{})
+
+	var n int
+	if err := db.QueryRowContext(ctx, "one").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("QueryRow got %v, %v", n, err)
+	}
+	rows, err := db.QueryContext(ctx, "rows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	if db.InFlight() != 0 {
+		t.Fatal("reading to the end should release the Rows")
+	}
+
+	execDone := make(chan error)
+	go func() {
+		_, err := db.ExecContext(ctx, "slow")
+		execDone <- err
+	}()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for db.InFlight() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	db.Drain(&latch.Packet{Item: "shutdown"})
+	if !db.Draining().IsClosed() {
+		t.Fatal("Drain should close Draining")
+	}
+	wctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := db.ExecContext(wctx, "new"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("a new query should block while draining, got %v", err)
+	}
+
+	close(slow)
+	if err := <-execDone; err != nil {
+		t.Fatal(err)
+	}
+	if db.Done().IsClosed() {
+		t.Fatal("Done with a transaction still open")
+	}
+	tx.Commit()
+	pak, err := db.Done().Read(ctx)
+	if err != nil || pak.Item != "shutdown" {
+		t.Fatalf("Done got %v, %v", pak, err)
+	}
+
+	db.Resume()
+	if db.Done().IsClosed() || db.Draining().IsClosed() {
+		t.Fatal("Resume should open both latches")
+	}
+	if _, err := db.ExecContext(ctx, "after"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDrainNil(t *testing.T) {
+
+	sqlDB, err := sql.Open("sqllatchfake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db := New(sqlDB, 1)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Drain(nil)
+	wctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := db.ExecContext(wctx, "new"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain(nil) should block new queries, got %v", err)
+	}
+	tx.Commit()
+	if !db.Done().IsClosed() {
+		t.Fatal("Done should close once the drain finishes")
+	}
+}
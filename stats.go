@@ -0,0 +1,115 @@
+package latch
+
+import "time"
+
+// WithStats makes a latch keep Stats: how long it spends
+// open and closed, how often it changes, and how long
+// Read and WaitFor callers wait for it. It costs a Clock
+// reading per transition, and per blocking wait.
+func WithStats() Option {
+	return func(r *Latch) {
+		r.stats = &statsAcc{}
+	}
+}
+
+// Stats are a latch's cumulative statistics, as of At.
+// Sub gives those of an interval, between two Stats.
+type Stats struct {
+	At          time.Time
+	Open        time.Duration // total time spent open.
+	Closed      time.Duration // total time spent closed.
+	Transitions uint64
+
+	// Waits counts Read and WaitFor calls that had to
+	// block; WaitTime is their total time blocked.
+	Waits    uint64
+	WaitTime time.Duration
+}
+
+// DutyCycle returns the fraction of the time that the
+// latch was closed.
+func (s Stats) DutyCycle() float64 {
+	if s.Open+s.Closed == 0 {
+		return 0
+	}
+	return float64(s.Closed) / float64(s.Open+s.Closed)
+}
+
+// MeanWait returns the mean time a blocked reader waited.
+func (s Stats) MeanWait() time.Duration {
+	if s.Waits == 0 {
+		return 0
+	}
+	return s.WaitTime / time.Duration(s.Waits)
+}
+
+// Sub returns the Stats of the interval from prev to s.
+func (s Stats) Sub(prev Stats) Stats {
+	return Stats{
+		At:          s.At,
+		Open:        s.Open - prev.Open,
+		Closed:      s.Closed - prev.Closed,
+		Transitions: s.Transitions - prev.Transitions,
+		Waits:       s.Waits - prev.Waits,
+		WaitTime:    s.WaitTime - prev.WaitTime,
+	}
+}
+
+type statsAcc struct {
+	s      Stats
+	closed bool
+}
+
+// advance accounts for the time up to now.
+// Caller must hold r.mut.
+func (a *statsAcc) advance(now time.Time) {
+	if !a.s.At.IsZero() {
+		if a.closed {
+			a.s.Closed += now.Sub(a.s.At)
+		} else {
+			a.s.Open += now.Sub(a.s.At)
+		}
+	}
+	a.s.At = now
+}
+
+// record accounts for the transition r just made.
+// Caller must hold r.mut.
+func (a *statsAcc) record(r *Latch) {
+	a.advance(r.clock().Now())
+	a.s.Transitions++
+	a.closed = r.avail
+}
+
+// Stats returns r's Stats, and false if r lacks
+// WithStats.
+func (r *Latch) Stats() (Stats, bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.stats == nil {
+		return Stats{}, false
+	}
+	r.stats.advance(r.clock().Now())
+	return r.stats.s, true
+}
+
+// waitStart returns the time a reader started waiting,
+// if r keeps Stats.
+func (r *Latch) waitStart() time.Time {
+	if r.stats == nil {
+		return time.Time{}
+	}
+	return r.clock().Now()
+}
+
+// waited accounts for a reader that blocked from start.
+func (r *Latch) waited(start time.Time) {
+	if r.stats == nil || start.IsZero() {
+		return
+	}
+	now := r.clock().Now()
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.stats.s.Waits++
+	r.stats.s.WaitTime += now.Sub(start)
+}
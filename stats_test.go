@@ -0,0 +1,43 @@
+package latch
+
+import (
+	"context"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	if _, ok := NewLatch(1).Stats(); ok {
+		t.Fatal("no Stats without WithStats")
+	}
+	synctest.Test(t, func(t *testing.T) {
+		l := NewLatch(1, WithStats())
+		go func() {
+			time.Sleep(3 * time.Second)
+			l.Bcast(&Packet{})
+		}()
+		if _, err := l.Read(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		mid, _ := l.Stats()
+		time.Sleep(time.Second)
+		l.Clear()
+		time.Sleep(time.Second)
+
+		s, _ := l.Stats()
+		if s.Open != 4*time.Second || s.Closed != time.Second || s.Transitions != 2 {
+			t.Fatalf("got %+v", s)
+		}
+		if s.DutyCycle() != 0.2 {
+			t.Fatalf("duty cycle %v", s.DutyCycle())
+		}
+		if s.Waits != 1 || s.MeanWait() != 3*time.Second {
+			t.Fatalf("waits %d, mean %v", s.Waits, s.MeanWait())
+		}
+		d := s.Sub(mid)
+		if d.Transitions != 1 || d.Open != time.Second || d.Closed != time.Second || d.Waits != 0 {
+			t.Fatalf("interval got %+v", d)
+		}
+	})
+}
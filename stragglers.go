@@ -0,0 +1,101 @@
+package latch
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDoneTimeout is returned by WaitDoneTimeout when
+// some workers are still running at the deadline.
+var ErrDoneTimeout = errors.New("latch: timed out waiting for workers")
+
+// Straggler identifies a worker, or cleanup, that
+// didn't finish in time. Stack is its goroutine's
+// stack trace at the moment it was reported, or
+// empty if the goroutine could not be found.
+type Straggler struct {
+	Phase int    `json:"phase,omitempty"` // for Phases; zero for TaskGroup.
+	Name  string `json:"name"`
+	Stack string `json:"stack,omitempty"`
+}
+
+// tracked is a goroutine that may become a Straggler.
+type tracked struct {
+	name string
+	id   atomic.Uint64 // goroutine id, once started.
+	done atomic.Bool
+}
+
+// begin records that the calling goroutine runs t.
+func (t *tracked) begin() {
+	t.id.Store(goid())
+}
+
+// stragglers reports those of ts not yet done,
+// with their stacks.
+func stragglers(phase int, ts []*tracked) (out []Straggler) {
+	var stacks map[uint64]string
+	for _, t := range ts {
+		if t.done.Load() {
+			continue
+		}
+		if stacks == nil {
+			stacks = allStacks()
+		}
+		out = append(out, Straggler{Phase: phase, Name: t.name, Stack: stacks[t.id.Load()]})
+	}
+	return out
+}
+
+// waitTimeout waits for done, for at most d on clk.
+func waitTimeout(clk Clock, done <-chan struct{}, d time.Duration) bool {
+	expired, timer := after(clk, d)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-expired:
+		return false
+	}
+}
+
+// goid returns the calling goroutine's id, as
+// shown in stack traces.
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	// "goroutine 123 [running]: ..."
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		id, _ := strconv.ParseUint(string(b[:i]), 10, 64)
+		return id
+	}
+	return 0
+}
+
+// allStacks returns every goroutine's stack trace, by id.
+func allStacks() map[uint64]string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := make(map[uint64]string)
+	for _, s := range bytes.Split(buf, []byte("\n\n")) {
+		rest := bytes.TrimPrefix(s, []byte("goroutine "))
+		if i := bytes.IndexByte(rest, ' '); i > 0 {
+			if id, err := strconv.ParseUint(string(rest[:i]), 10, 64); err == nil {
+				stacks[id] = string(s)
+			}
+		}
+	}
+	return stacks
+}
@@ -0,0 +1,33 @@
+package latch
+
+import "reflect"
+
+// Swap is Bcast(pak), also returning the previous value
+// (nil if the latch was open) and whether the value
+// actually changed; so writers can make idempotent
+// transitions ("only log if this changed anything")
+// without a separate, racy, read. Values are compared
+// with reflect.DeepEqual. A Bcast refused (see
+// TryBcast) does not change anything.
+func (r *Latch) Swap(pak *Packet) (prev *Packet, changed bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	wasClosed := r.avail
+	if wasClosed {
+		prev = r.cur
+	}
+	if !r.bcast(pak) {
+		return r.copyOf(prev), false
+	}
+	// compare r.cur, not pak, in case of WithReducer.
+	return r.copyOf(prev), !wasClosed || !samePacket(prev, r.cur)
+}
+
+// samePacket reports whether a and b hold equal values;
+// either may be nil, as from Bcast(nil).
+func samePacket(a, b *Packet) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return reflect.DeepEqual(a.Item, b.Item) && reflect.DeepEqual(a.Err, b.Err)
+}
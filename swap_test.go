@@ -0,0 +1,51 @@
+package latch
+
+import "testing"
+
+func TestSwap(t *testing.T) {
+
+	latch := NewLatch(1)
+	prev, changed := latch.Swap(&Packet{Item: "draining"})
+	if prev != nil || !changed {
+		t.Fatal("closing an open latch is a change, with no previous value")
+	}
+	prev, changed = latch.Swap(&Packet{Item: "draining"})
+	if prev == nil || prev.Item != "draining" || changed {
+		t.Fatal("an equal value should report no change")
+	}
+	_, changed = latch.Swap(&Packet{Item: "stopped"})
+	if !changed {
+		t.Fatal("a different value should report a change")
+	}
+}
+
+func TestSwapNil(t *testing.T) {
+
+	l := NewLatch(1)
+	if prev, changed := l.Swap(nil); prev != nil || !changed {
+		t.Fatalf("closing with nil: %v, %v", prev, changed)
+	}
+	if prev, changed := l.Swap(nil); prev != nil || changed {
+		t.Fatalf("nil again should change nothing: %v, %v", prev, changed)
+	}
+	if _, changed := l.Swap(&Packet{Item: 1}); !changed {
+		t.Fatal("nil to a value is a change")
+	}
+	if prev, changed := l.Swap(nil); prev.Item != 1 || !changed {
+		t.Fatalf("a value to nil: %v, %v", prev, changed)
+	}
+}
+
+func TestSwapClones(t *testing.T) {
+
+	l := NewLatch(1, WithCloner(func(x interface{}) interface{} {
+		return append([]int(nil), x.([]int)...)
+	}))
+	orig := []int{1}
+	l.Bcast(&Packet{Item: orig})
+	prev, _ := l.Swap(&Packet{Item: []int{2}})
+	prev.Item.([]int)[0] = 9
+	if orig[0] != 1 {
+		t.Fatal("Swap should return a clone of the previous value")
+	}
+}
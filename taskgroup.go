@@ -0,0 +1,131 @@
+package latch
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// TaskGroup runs worker goroutines that share one stop
+// latch: the dominant use of latches, in one type. It is
+// like errgroup, but with the latch as the shutdown
+// signal. The first worker to return a non-nil error
+// closes the stop latch with that error, telling the
+// rest to finish up; Wait then returns that error.
+// A worker that panics fails the same way, with a
+// *PanicError.
+type TaskGroup struct {
+	stop *Latch
+	wg   sync.WaitGroup
+
+	mu       sync.Mutex
+	firstErr error
+	workers  []*tracked
+	clk      Clock // see SetClock.
+}
+
+// NewTaskGroup makes a new TaskGroup whose stop latch
+// has a backing channel of size sz. The stop latch is
+// write-once: the first reason to stop wins.
+func NewTaskGroup(sz int) *TaskGroup {
+	return &TaskGroup{
+		stop: NewLatch(sz, WithWriteOnce()),
+	}
+}
+
+// SetClock makes WaitDoneTimeout use c, as WithClock
+// does for a Latch.
+func (g *TaskGroup) SetClock(c Clock) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.clk = c
+}
+
+// StopLatch returns the shared stop latch.
+func (g *TaskGroup) StopLatch() *Latch {
+	return g.stop
+}
+
+// Go runs fn in a new goroutine, handing it the stop
+// latch to watch.
+func (g *TaskGroup) Go(fn func(stop *Latch) error) {
+	g.mu.Lock()
+	name := fmt.Sprintf("worker %d", len(g.workers))
+	g.mu.Unlock()
+	g.GoNamed(name, fn)
+}
+
+// GoNamed is Go, naming the worker for
+// WaitDoneTimeout's Straggler reports.
+func (g *TaskGroup) GoNamed(name string, fn func(stop *Latch) error) {
+	w := &tracked{name: name}
+	g.mu.Lock()
+	g.workers = append(g.workers, w)
+	g.mu.Unlock()
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		w.begin()
+		defer w.done.Store(true)
+		defer func() {
+			if v := recover(); v != nil {
+				g.fail(&PanicError{Value: v, Stack: debug.Stack()})
+			}
+		}()
+		if err := fn(g.stop); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// fail records err if it is the first, and stops the
+// group. The Bcast is under g.mu, so that the stop latch
+// holds the same error that Wait returns.
+func (g *TaskGroup) fail(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.firstErr == nil {
+		g.firstErr = err
+		g.stop.Bcast(&Packet{Err: err})
+	}
+}
+
+// Shutdown asks all workers to stop, by closing the
+// stop latch with pak; unless something closed it
+// first. A pak.Err does not count as a worker
+// error for Wait.
+func (g *TaskGroup) Shutdown(pak *Packet) {
+	g.stop.Bcast(pak)
+}
+
+// Wait blocks until every worker has returned, and
+// then returns the first non-nil worker error, if any.
+func (g *TaskGroup) Wait() error {
+	g.wg.Wait()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.firstErr
+}
+
+// WaitDoneTimeout is Wait, giving up after d. If workers
+// are still running then, it returns them as Stragglers,
+// with their stacks, and ErrDoneTimeout; they are left
+// running.
+func (g *TaskGroup) WaitDoneTimeout(d time.Duration) ([]Straggler, error) {
+	done := make(chan struct{})
+	goLabeled(RoleSupervisor, "", func() {
+		g.wg.Wait()
+		close(done)
+	})
+	g.mu.Lock()
+	clk := g.clk
+	g.mu.Unlock()
+	if waitTimeout(clk, done, d) {
+		return nil, g.Wait()
+	}
+	g.mu.Lock()
+	ws := append([]*tracked(nil), g.workers...)
+	g.mu.Unlock()
+	return stragglers(0, ws), ErrDoneTimeout
+}
@@ -0,0 +1,119 @@
+package latch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskGroup(t *testing.T) {
+
+	g := NewTaskGroup(1)
+	boom := errors.New("boom")
+
+	for i := 0; i < 3; i++ {
+		g.Go(func(stop *Latch) error {
+			<-stop.Done()
+			return nil
+		})
+	}
+	g.Go(func(stop *Latch) error {
+		return boom
+	})
+
+	if err := g.Wait(); err != boom {
+		t.Fatalf("Wait should return the first worker error, got %v", err)
+	}
+	if pak := g.StopLatch().Peek(); pak == nil || pak.Err != boom {
+		t.Fatal("the stop latch should carry the first error")
+	}
+
+	// a Shutdown is not an error.
+	g = NewTaskGroup(1)
+	g.Go(func(stop *Latch) error {
+		<-stop.Done()
+		return nil
+	})
+	g.Shutdown(&Packet{Item: "bye"})
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Shutdown should not produce an error, got %v", err)
+	}
+}
+
+func TestTaskGroupWaitDoneTimeout(t *testing.T) {
+
+	g := NewTaskGroup(1)
+	hang := make(chan struct{})
+	g.GoNamed("well-behaved", func(stop *Latch) error {
+		<-stop.Done()
+		return nil
+	})
+	g.GoNamed("ignores-stop", func(stop *Latch) error {
+		<-hang
+		return nil
+	})
+	g.Shutdown(&Packet{})
+
+	s, err := g.WaitDoneTimeout(20 * time.Millisecond)
+	if err != ErrDoneTimeout {
+		t.Fatalf("expected ErrDoneTimeout, got %v", err)
+	}
+	if len(s) != 1 || s[0].Name != "ignores-stop" || !strings.Contains(s[0].Stack, "TestTaskGroupWaitDoneTimeout") {
+		t.Fatalf("expected the hung worker, with its stack, got %#v", s)
+	}
+
+	close(hang)
+	if s, err = g.WaitDoneTimeout(5 * time.Second); s != nil || err != nil {
+		t.Fatalf("expected a clean finish, got %v, %v", s, err)
+	}
+}
+
+func TestTaskGroupConcurrentFailures(t *testing.T) {
+
+	for i := 0; i < 100; i++ {
+		g := NewTaskGroup(1)
+		start := make(chan struct{})
+		for j := 0; j < 4; j++ {
+			err := errors.New(strings.Repeat("x", j+1))
+			g.Go(func(stop *Latch) error {
+				<-start
+				return err
+			})
+		}
+		close(start)
+		err := g.Wait()
+		if got := g.StopLatch().Peek().Err; got != err {
+			t.Fatalf("stop latch holds %v, but Wait returned %v", got, err)
+		}
+	}
+}
+
+func TestTaskGroupClock(t *testing.T) {
+
+	w := newTimerWheel(time.Millisecond)
+	g := NewTaskGroup(1)
+	g.SetClock(w)
+	hang := make(chan struct{})
+	defer close(hang)
+	g.Go(func(*Latch) error {
+		<-hang
+		return nil
+	})
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := g.WaitDoneTimeout(10 * time.Millisecond)
+		errc <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case err := <-errc:
+		t.Fatalf("timed out in real time, not on the Clock: %v", err)
+	default:
+	}
+	advanceBy(w, 10*time.Millisecond)
+	if err := <-errc; err != ErrDoneTimeout {
+		t.Fatalf("expected ErrDoneTimeout, got %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package latch
+
+import (
+	"sync"
+	"time"
+)
+
+// TimerWheel is a Clock whose timers live in a
+// hierarchical timer wheel, serviced by one goroutine.
+// Arming, stopping, and firing a timer are O(1), and
+// the wheel's cost per tick doesn't grow with the number
+// of timers; so thousands of time-armed latches (with
+// CloseAfter, WithTTL, Watchdog, Lease, or in a
+// RefresherPool) don't mean thousands of runtime timers.
+//
+// Timers fire on the first tick at or after they are due,
+// so resolution is the wheel's tick. The wheel keeps to
+// the wall clock: ticks its goroutine misses are made up
+// at the next, so timers don't drift late. A wheel is
+// opt-in: share one by giving each latch WithClock(w).
+type TimerWheel struct {
+	tick  time.Duration
+	start time.Time // when ticking began; zero if driven by hand.
+
+	mu     sync.Mutex
+	now    uint64 // ticks elapsed.
+	levels [wheelLevels][wheelSlots]map[*wheelTimer]struct{}
+
+	stop chan struct{}
+	once sync.Once
+}
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelMask   = wheelSlots - 1
+	wheelLevels = 4
+)
+
+// NewTimerWheel starts a wheel that ticks every tick.
+func NewTimerWheel(tick time.Duration) *TimerWheel {
+	w := newTimerWheel(tick)
+	w.start = time.Now()
+	t := time.NewTicker(tick)
+	goLabeled(RoleTimer, "", func() {
+		defer t.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case now := <-t.C:
+				w.catchUp(now)
+			}
+		}
+	})
+	return w
+}
+
+// catchUp advances w to the tick for now, however many
+// ticks that takes.
+func (w *TimerWheel) catchUp(now time.Time) {
+	due := uint64(now.Sub(w.start) / w.tick)
+	for {
+		w.mu.Lock()
+		behind := w.now < due
+		w.mu.Unlock()
+		if !behind {
+			return
+		}
+		w.advance()
+	}
+}
+
+// newTimerWheel makes a wheel, without its goroutine.
+func newTimerWheel(tick time.Duration) *TimerWheel {
+	w := &TimerWheel{tick: tick, stop: make(chan struct{})}
+	for l := range w.levels {
+		for s := range w.levels[l] {
+			w.levels[l][s] = make(map[*wheelTimer]struct{})
+		}
+	}
+	return w
+}
+
+// Stop stops the wheel. Pending timers never fire.
+func (w *TimerWheel) Stop() {
+	w.once.Do(func() { close(w.stop) })
+}
+
+// Now returns the current time.
+func (w *TimerWheel) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc calls f, in its own goroutine,
+// on the first tick at least d from now.
+func (w *TimerWheel) AfterFunc(d time.Duration, f func()) Timer {
+	t := &wheelTimer{w: w, f: f}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.add(t, d)
+	return t
+}
+
+// NewTicker returns a Ticker that ticks every d,
+// rounded up to a whole number of wheel ticks.
+func (w *TimerWheel) NewTicker(d time.Duration) Ticker {
+	t := &wheelTimer{w: w, period: d, ch: make(chan time.Time, 1)}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.add(t, d)
+	return wheelTicker{t}
+}
+
+// add schedules t for d from now. Caller must hold w.mu.
+func (w *TimerWheel) add(t *wheelTimer, d time.Duration) {
+	if !w.start.IsZero() {
+		// the first tick at or after the wall time due.
+		due := time.Since(w.start) + d
+		t.expiry = max(uint64((due+w.tick-1)/w.tick), w.now+1)
+		w.place(t)
+		return
+	}
+	n := uint64((d + w.tick - 1) / w.tick)
+	if n == 0 {
+		n = 1
+	}
+	t.expiry = w.now + n
+	w.place(t)
+}
+
+// place files t in the slot for its expiry.
+// Caller must hold w.mu.
+func (w *TimerWheel) place(t *wheelTimer) {
+	delta := t.expiry - w.now
+	level := 0
+	for level < wheelLevels-1 && delta >= 1<<(wheelBits*(level+1)) {
+		level++
+	}
+	slot := (t.expiry >> (wheelBits * level)) & wheelMask
+	if level == wheelLevels-1 && delta >= 1<<(wheelBits*wheelLevels) {
+		// beyond the wheel: park in the furthest slot,
+		// to be re-placed when it comes round.
+		slot = ((w.now >> (wheelBits * level)) - 1) & wheelMask
+	}
+	t.slot = w.levels[level][slot]
+	t.slot[t] = struct{}{}
+}
+
+// advance moves the wheel on one tick, firing
+// what is due.
+func (w *TimerWheel) advance() {
+	w.mu.Lock()
+	w.now++
+	// cascade: as each lower level wraps, re-place the
+	// timers from the next level's current slot.
+	for level := 1; level < wheelLevels; level++ {
+		if w.now&((1<<(wheelBits*level))-1) != 0 {
+			break
+		}
+		slot := w.levels[level][(w.now>>(wheelBits*level))&wheelMask]
+		for t := range slot {
+			delete(slot, t)
+			w.place(t)
+		}
+	}
+	due := w.levels[0][w.now&wheelMask]
+	var fire []*wheelTimer
+	for t := range due {
+		delete(due, t)
+		t.slot = nil
+		fire = append(fire, t)
+	}
+	for _, t := range fire {
+		if t.period > 0 {
+			select {
+			case t.ch <- time.Now():
+			default:
+			}
+			w.add(t, t.period)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, t := range fire {
+		if t.f != nil {
+			go t.f()
+		}
+	}
+}
+
+// wheelTimer is a Timer (f set), or underlies
+// a wheelTicker (period and ch set).
+type wheelTimer struct {
+	w      *TimerWheel
+	expiry uint64
+	slot   map[*wheelTimer]struct{} // nil unless pending.
+	f      func()
+	period time.Duration
+	ch     chan time.Time
+}
+
+func (t *wheelTimer) Stop() bool {
+	t.w.mu.Lock()
+	defer t.w.mu.Unlock()
+	if t.slot == nil {
+		return false
+	}
+	delete(t.slot, t)
+	t.slot = nil
+	return true
+}
+
+func (t *wheelTimer) Reset(d time.Duration) bool {
+	t.w.mu.Lock()
+	defer t.w.mu.Unlock()
+	was := t.slot != nil
+	if was {
+		delete(t.slot, t)
+	}
+	t.w.add(t, d)
+	return was
+}
+
+type wheelTicker struct {
+	*wheelTimer
+}
+
+func (t wheelTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t wheelTicker) Stop() {
+	t.wheelTimer.Stop()
+}
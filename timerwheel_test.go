@@ -0,0 +1,101 @@
+package latch
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTimerWheelCascade(t *testing.T) {
+
+	// drive the wheel by hand.
+	w := newTimerWheel(time.Millisecond)
+	var fired atomic.Int64
+	due := []uint64{1, 63, 64, 65, 4095, 4096, 4097, 300000, 1 << 25}
+	var when []chan uint64
+	for _, n := range due {
+		ch := make(chan uint64, 1)
+		when = append(when, ch)
+		w.AfterFunc(time.Duration(n)*time.Millisecond, func() {
+			fired.Add(1)
+			ch <- 0
+		})
+	}
+	stopped := w.AfterFunc(10*time.Millisecond, func() { t.Error("stopped timer fired") })
+	if !stopped.Stop() || stopped.Stop() {
+		t.Fatal("Stop should report stopping a pending timer once")
+	}
+
+	for i, n := range due {
+		for w.now < n-1 {
+			w.advance()
+		}
+		select {
+		case <-when[i]:
+			t.Fatalf("timer for %v ticks fired early, at %v", n, w.now)
+		default:
+		}
+		w.advance()
+		select {
+		case <-when[i]:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timer for %v ticks did not fire on time", n)
+		}
+	}
+	if fired.Load() != int64(len(due)) {
+		t.Fatalf("expected %v fired, got %v", len(due), fired.Load())
+	}
+}
+
+func TestTimerWheelClock(t *testing.T) {
+
+	w := NewTimerWheel(time.Millisecond)
+	defer w.Stop()
+	opt := WithClock(w)
+
+	d := NewDeadlineLatch(1, 5*time.Millisecond, &Packet{Item: "late"}, opt)
+	wd := NewWatchdog(1, 5*time.Millisecond, opt)
+	ttl := NewLatch(1, opt, WithTTL(5*time.Millisecond, nil))
+	ttl.Bcast(&Packet{})
+
+	p := NewRefresherPool(time.Millisecond, w)
+	defer p.Stop()
+	l := NewLatch(1)
+	l.Bcast(&Packet{Item: 1})
+	p.Add(l)
+	<-l.Ch()
+
+	waitFor(t, func() bool {
+		return d.IsClosed() && wd.IsClosed() && !ttl.IsClosed() && len(l.Ch()) == 1
+	})
+}
+
+func TestTimerWheelCatchUp(t *testing.T) {
+
+	// a wheel on the wall clock, driven by hand.
+	w := newTimerWheel(time.Millisecond)
+	w.start = time.Now()
+	fired := make(chan struct{}, 1)
+	w.AfterFunc(5*time.Millisecond, func() { fired <- struct{}{} })
+
+	// one late tick makes up for all those missed.
+	w.catchUp(w.start.Add(3 * time.Millisecond))
+	select {
+	case <-fired:
+		t.Fatal("timer fired early")
+	case <-time.After(10 * time.Millisecond):
+	}
+	w.catchUp(w.start.Add(time.Second))
+	select {
+	case <-fired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("missed ticks were not made up")
+	}
+}
+
+// advanceBy moves w on by d, a tick at a time.
+func advanceBy(w *TimerWheel, d time.Duration) {
+	for i := 0; i < int(d/w.tick); i++ {
+		w.advance()
+	}
+}
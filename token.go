@@ -0,0 +1,41 @@
+package latch
+
+// Token makes one writer's close idempotent; see CloseToken.
+// The zero Token is not valid.
+type Token struct {
+	t *closeToken
+}
+
+type closeToken struct {
+	l    *Latch
+	used bool // guarded by l.mut.
+}
+
+// CloseToken returns a new Token for CloseWith. A writer
+// that may retry, such as an RPC handler, takes a Token
+// once, and passes it on every attempt.
+func (r *Latch) CloseToken() Token {
+	return Token{t: &closeToken{l: r}}
+}
+
+// CloseWith is Bcast(pak), but at most once per tok:
+// later CloseWiths with the same tok do nothing, even if
+// the latch has since been cleared. Other writers, and
+// other tokens, are unaffected. It reports whether pak
+// was applied. It panics with ErrForeignToken if tok
+// came from another latch.
+func (r *Latch) CloseWith(tok Token, pak *Packet) bool {
+	if tok.t == nil || tok.t.l != r {
+		panic(ErrForeignToken)
+	}
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if tok.t.used {
+		return false
+	}
+	if !r.bcast(pak) {
+		return false
+	}
+	tok.t.used = true
+	return true
+}
@@ -0,0 +1,29 @@
+package latch
+
+import "testing"
+
+func TestCloseWith(t *testing.T) {
+
+	l := NewLatch(1)
+	tok := l.CloseToken()
+
+	if !l.CloseWith(tok, &Packet{Item: "first"}) {
+		t.Fatal("the first CloseWith should apply")
+	}
+	l.Clear()
+	if l.CloseWith(tok, &Packet{Item: "retry"}) || l.IsClosed() {
+		t.Fatal("a retry with the same token should do nothing")
+	}
+
+	// other writers are unaffected.
+	if !l.CloseWith(l.CloseToken(), &Packet{Item: "other"}) || l.Peek().Item != "other" {
+		t.Fatal("a different token should apply")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("a foreign token should panic")
+		}
+	}()
+	NewLatch(1).CloseWith(tok, &Packet{})
+}
@@ -0,0 +1,142 @@
+package latch
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Tracer receives a latch's transitions, and the values
+// Read returns, so they can be turned into trace spans.
+// The otellatch package implements it for OpenTelemetry;
+// the latch package itself depends on no tracing library.
+type Tracer interface {
+	// Transition is called for each transition, with the
+	// latch locked: it must not call back into the latch.
+	Transition(ev TraceEvent)
+
+	// Read is called as Read returns a value, with the
+	// reader's ctx and the Version of the close that it
+	// observed.
+	Read(ctx context.Context, version uint64)
+}
+
+// TraceEvent describes one transition to a Tracer.
+type TraceEvent struct {
+	// Op is "close" when an open latch is closed, "set"
+	// when a closed latch gets a new value, and "open"
+	// on Clear.
+	Op string
+
+	// Version is the latch's Version after the transition.
+	Version uint64
+
+	// Err is the new value's Err, if any.
+	Err error
+
+	// Function, File and Line locate the caller outside
+	// this package that caused the transition; empty if
+	// it couldn't be found.
+	Function string
+	File     string
+	Line     int
+}
+
+// WithTracer hands each transition of the latch, and each
+// value returned by Read, to t.
+func WithTracer(t Tracer) Option {
+	return func(r *Latch) {
+		r.tracing = &tracing{t: t}
+	}
+}
+
+type tracing struct {
+	t      Tracer
+	closed bool
+}
+
+// record reports the transition that r just made.
+// Caller must hold r.mut.
+func (t *tracing) record(r *Latch) {
+	ev := TraceEvent{Op: "open", Version: r.version}
+	switch {
+	case r.avail && t.closed:
+		ev.Op = "set"
+	case r.avail:
+		ev.Op = "close"
+	}
+	t.closed = r.avail
+
+	if fn, file, line, ok := callerOutside(); ok {
+		ev.Function, ev.File, ev.Line = fn, file, line
+	}
+	if r.avail && r.cur != nil {
+		ev.Err = r.cur.Err
+	}
+	t.t.Transition(ev)
+}
+
+// Read waits until the latch is closed, and returns
+// its value; or returns ctx.Err() if ctx is done first.
+// Like WaitFor, it consumes nothing from Ch().
+//
+// If the latch has WithTracer, Read tells the Tracer
+// which close it observed.
+func (r *Latch) Read(ctx context.Context) (*Packet, error) {
+	var start time.Time
+	defer func() { r.waited(start) }()
+	for {
+		r.mut.Lock()
+		var cur *Packet
+		avail, next, version := r.avail, r.chg, r.version
+		if avail {
+			cur = r.copyOf(r.cur)
+		}
+		var t Tracer
+		if r.tracing != nil {
+			t = r.tracing.t
+		}
+		r.mut.Unlock()
+
+		if avail {
+			if t != nil {
+				t.Read(ctx, version)
+			}
+			return cur, nil
+		}
+		if start.IsZero() {
+			start = r.waitStart()
+		}
+		select {
+		case <-next:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// pkgDir is this package's source directory.
+var pkgDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// callerOutside finds the nearest caller on the stack
+// that isn't latch's own (non-test) code.
+func callerOutside() (fn, file string, line int, ok bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if filepath.Dir(f.File) != pkgDir ||
+			strings.HasSuffix(f.File, "_test.go") {
+			return f.Function, f.File, f.Line, true
+		}
+		if !more {
+			return "", "", 0, false
+		}
+	}
+}
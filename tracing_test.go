@@ -0,0 +1,47 @@
+package latch
+
+import (
+	"context"
+	"testing"
+)
+
+type recTracer struct {
+	evs   []TraceEvent
+	reads []uint64
+}
+
+func (t *recTracer) Transition(ev TraceEvent) { t.evs = append(t.evs, ev) }
+
+func (t *recTracer) Read(ctx context.Context, version uint64) {
+	t.reads = append(t.reads, version)
+}
+
+func TestTracer(t *testing.T) {
+
+	rec := &recTracer{}
+	l := NewLatch(1, WithTracer(rec))
+
+	l.Bcast(&Packet{Item: 1})
+	l.Bcast(&Packet{Item: 2})
+	l.Clear()
+	l.Bcast(nil)
+	if _, err := l.Read(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"close", "set", "open", "close"}
+	if len(rec.evs) != len(want) {
+		t.Fatalf("expected %v, got %v", want, rec.evs)
+	}
+	for i, ev := range rec.evs {
+		if ev.Op != want[i] || ev.Version != uint64(i+1) {
+			t.Fatalf("event %v: expected %v at version %v, got %+v", i, want[i], i+1, ev)
+		}
+		if ev.Function != "github.com/glycerine/latch.TestTracer" {
+			t.Fatalf("expected the caller to be the test, got %v", ev.Function)
+		}
+	}
+	if len(rec.reads) != 1 || rec.reads[0] != 4 {
+		t.Fatalf("Read should report the close it observed, got %v", rec.reads)
+	}
+}
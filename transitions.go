@@ -0,0 +1,66 @@
+package latch
+
+import (
+	"context"
+	"iter"
+)
+
+// Transitions returns an iterator over the latch's
+// closed values, as (Version, Packet) pairs, for use
+// with range:
+//
+//	for v, pak := range l.Transitions(ctx) {
+//		...
+//	}
+//
+// It yields the current value straight away, if the
+// latch is closed, and then each new value once, in
+// order. Clears are skipped. If values arrive faster than
+// the loop body handles them, the intermediate ones are
+// missed, unless the latch has WithHistory (deep enough
+// to cover the gap). The iteration ends when ctx is done.
+func (r *Latch) Transitions(ctx context.Context) iter.Seq2[uint64, *Packet] {
+	return func(yield func(uint64, *Packet) bool) {
+		var seen uint64
+		first := true
+		for {
+			cur, version, next := r.Observe()
+			if first || version != seen {
+				if !r.yieldSince(seen, first, version, cur, yield) {
+					return
+				}
+				seen, first = version, false
+			}
+			select {
+			case <-next:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// yieldSince yields the closed values after seen, up
+// to version: from History if it has them all, else
+// just cur. It returns false if yield said to stop.
+func (r *Latch) yieldSince(seen uint64, first bool, version uint64,
+	cur *Packet, yield func(uint64, *Packet) bool) bool {
+
+	if !first {
+		if ups, complete := r.History(seen); complete {
+			for _, u := range ups {
+				if u.Version > version {
+					break
+				}
+				if u.Packet != nil && !yield(u.Version, r.copyOf(u.Packet)) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	if cur == nil {
+		return true
+	}
+	return yield(version, cur)
+}
@@ -0,0 +1,42 @@
+package latch
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTransitions(t *testing.T) {
+
+	l := NewLatch(1, WithHistory(8))
+	l.Bcast(&Packet{Item: 0})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	got := make(chan []interface{})
+	go func() {
+		var items []interface{}
+		for v, pak := range l.Transitions(ctx) {
+			items = append(items, pak.Item)
+			if v == 1 {
+				// let the writer run ahead of us.
+				l.CloseSeq([]*Packet{{Item: 1}, {Item: 2}})
+				l.Clear()
+				l.Bcast(&Packet{Item: 3})
+			}
+			if pak.Item == 3 {
+				cancel()
+			}
+		}
+		got <- items
+	}()
+
+	items := <-got
+	want := []interface{}{0, 1, 2, 3}
+	if len(items) != len(want) {
+		t.Fatalf("expected %v, got %v", want, items)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, items)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package latch
+
+import "time"
+
+// WithTTL makes the value of a closed latch expire:
+// unless it is refreshed by another Bcast within d, the
+// latch reverts to open, as if by Clear. If stale is not
+// nil, the latch is instead closed with stale, which
+// does not itself expire; readers can then tell "no
+// value" from "a value nobody has vouched for lately".
+//
+// This models lease-like signals, such as a leader
+// announcement that is good for ten seconds unless
+// re-announced. Timing uses the latch's Clock.
+func WithTTL(d time.Duration, stale *Packet) Option {
+	return func(r *Latch) {
+		r.ttl = &ttl{d: d, stale: stale}
+	}
+}
+
+type ttl struct {
+	d     time.Duration
+	stale *Packet
+	timer Timer
+
+	// gen counts arms, so a timer that fires
+	// just as it is replaced can tell.
+	gen      uint64
+	expiring bool
+}
+
+// rearm restarts the countdown after a transition;
+// or stops it, if r is now open, or stale.
+// Caller must hold r.mut.
+func (t *ttl) rearm(r *Latch) {
+	t.stop()
+	if !r.avail || t.expiring {
+		return
+	}
+	gen := t.gen
+	t.timer = r.clock().AfterFunc(t.d, func() {
+		r.mut.Lock()
+		defer r.mut.Unlock()
+		if t.gen != gen {
+			return
+		}
+		t.expiring = true
+		if t.stale == nil {
+			r.clear()
+		} else {
+			r.bcast(t.stale)
+		}
+		t.expiring = false
+	})
+}
+
+// stop cancels any countdown. Caller must hold r.mut.
+func (t *ttl) stop() {
+	t.gen++
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
@@ -0,0 +1,46 @@
+package latch
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		l := NewLatch(1, WithTTL(10*time.Second, nil))
+		l.Bcast(&Packet{Item: "leader-a"})
+
+		time.Sleep(8 * time.Second)
+		l.Bcast(&Packet{Item: "leader-a"}) // re-announced.
+		time.Sleep(8 * time.Second)
+		synctest.Wait()
+		if !l.IsClosed() {
+			t.Fatal("a refreshed value should not expire")
+		}
+		time.Sleep(3 * time.Second)
+		synctest.Wait()
+		if l.IsClosed() {
+			t.Fatal("the value should have expired")
+		}
+	})
+}
+
+func TestTTLStale(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		stale := &Packet{Item: "stale"}
+		l := NewLatch(1, WithTTL(time.Second, stale))
+		l.Bcast(&Packet{Item: "fresh"})
+
+		time.Sleep(time.Second)
+		synctest.Wait()
+		if l.Peek() != stale {
+			t.Fatal("the value should have been marked stale")
+		}
+		time.Sleep(time.Hour)
+		synctest.Wait()
+		if l.Peek() != stale {
+			t.Fatal("the stale marker should not itself expire")
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package latch
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrTxDone: the Tx was already committed or
+	// rolled back.
+	ErrTxDone = errors.New("latch: transaction already committed or rolled back")
+
+	// ErrTxRefused: a latch in the Tx would refuse its
+	// write (see TryBcast), so none was applied.
+	ErrTxRefused = errors.New("latch: transaction refused by a latch")
+)
+
+// Tx stages Set, Close and Open operations on one or
+// more latches, to be applied together by Commit, or
+// dropped by Rollback. Until then, nothing is applied.
+//
+// Commit locks every latch in the Tx before changing
+// any, so anyone taking a latch's lock (Peek, Observe,
+// Version, Read, and the like) sees either none of the
+// Tx or all of it; each latch makes at most one
+// transition, to its final staged state. Receivers on
+// Ch(), which take no lock, may see one latch's new
+// value a moment before another's.
+type Tx struct {
+	mu   sync.Mutex
+	ops  map[*Latch]txOp
+	done bool
+}
+
+type txOp struct {
+	kind txKind
+	pak  *Packet
+}
+
+type txKind int
+
+const (
+	txSet txKind = iota
+	txClose
+	txOpen
+)
+
+// Begin starts a new, empty, transaction.
+func Begin() *Tx {
+	return &Tx{ops: make(map[*Latch]txOp)}
+}
+
+// Set stages a Bcast(pak) on l. A later staging for l
+// replaces an earlier one.
+func (tx *Tx) Set(l *Latch, pak *Packet) {
+	tx.stage(l, txOp{kind: txSet, pak: pak})
+}
+
+// Close stages closing l with pak, if l is open at
+// Commit; a closed l keeps its value.
+func (tx *Tx) Close(l *Latch, pak *Packet) {
+	tx.stage(l, txOp{kind: txClose, pak: pak})
+}
+
+// Open stages a Clear of l.
+func (tx *Tx) Open(l *Latch) {
+	tx.stage(l, txOp{kind: txOpen})
+}
+
+func (tx *Tx) stage(l *Latch, op txOp) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.done {
+		tx.ops[l] = op
+	}
+}
+
+// Rollback drops the staged operations.
+func (tx *Tx) Rollback() {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.done = true
+	tx.ops = nil
+}
+
+// Commit applies the staged operations atomically. If
+// any latch would refuse its write, none is applied,
+// and Commit returns ErrTxRefused; the Tx is finished
+// either way.
+func (tx *Tx) Commit() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	ls := make([]*Latch, 0, len(tx.ops))
+	for l := range tx.ops {
+		ls = append(ls, l)
+	}
+	defer lockAll(ls)()
+
+	for _, l := range ls {
+		if !l.accepts(tx.ops[l]) {
+			return ErrTxRefused
+		}
+	}
+	for _, l := range ls {
+		op := tx.ops[l]
+		switch {
+		case op.kind == txOpen:
+			l.clear()
+		case op.kind == txClose && l.avail:
+			// already closed; keeps its value.
+		default:
+			// accepts has run the veto hooks; don't
+			// ask them again, lest they change their
+			// minds part way through.
+			if l.setVetted(op.pak, true) {
+				l.publish()
+			}
+		}
+	}
+	tx.ops = nil
+	return nil
+}
+
+// lockAll locks each of ls once, in address order, so
+// that concurrent lockAlls can't deadlock; and returns
+// the func to unlock them.
+func lockAll(ls []*Latch) (unlock func()) {
+	sorted := append([]*Latch(nil), ls...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return reflect.ValueOf(sorted[i]).Pointer() < reflect.ValueOf(sorted[j]).Pointer()
+	})
+	var locked []*Latch
+	for i, l := range sorted {
+		if i > 0 && l == sorted[i-1] {
+			continue
+		}
+		l.mut.Lock()
+		locked = append(locked, l)
+	}
+	return func() {
+		for _, l := range locked {
+			l.mut.Unlock()
+		}
+	}
+}
+
+// accepts reports whether op would be accepted, as far
+// as veto hooks, WithWriteOnce and WithWritePolicy are
+// concerned.
+// Caller must hold r.mut.
+func (r *Latch) accepts(op txOp) bool {
+	if op.kind == txClose && r.avail {
+		return true
+	}
+	if op.kind != txOpen && r.vet(op.pak) != nil {
+		return false
+	}
+	if r.writeOnce && r.written {
+		return false
+	}
+	if op.kind == txOpen || r.policy == nil || !r.avail {
+		return true
+	}
+	switch r.policy.policy {
+	case HighestPriorityWins:
+		return priorityOf(op.pak) >= priorityOf(r.cur)
+	case RejectIfClosed:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package latch
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTx(t *testing.T) {
+
+	a, b, c := NewLatch(1), NewLatch(1), NewLatch(1)
+	c.Bcast(&Packet{Item: "c0"})
+
+	tx := Begin()
+	tx.Set(a, &Packet{Item: "a1"})
+	tx.Set(a, &Packet{Item: "a2"})
+	tx.Close(b, &Packet{Item: "b1"})
+	tx.Open(c)
+	if a.IsClosed() || b.IsClosed() || !c.IsClosed() {
+		t.Fatal("nothing should apply before Commit")
+	}
+	va := a.Version()
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if a.Version() != va+1 {
+		t.Fatal("a should make one transition, to its final state")
+	}
+	if a.Peek().Item != "a2" || b.Peek().Item != "b1" || c.IsClosed() {
+		t.Fatal("Commit should apply every staged operation")
+	}
+	if err := tx.Commit(); err != ErrTxDone {
+		t.Fatalf("second Commit got %v", err)
+	}
+
+	tx = Begin()
+	tx.Set(a, &Packet{Item: "a3"})
+	tx.Rollback()
+	if err := tx.Commit(); err != ErrTxDone || a.Peek().Item != "a2" {
+		t.Fatal("Rollback should drop the staged operations")
+	}
+
+	once := NewLatch(1, WithWriteOnce())
+	once.Bcast(&Packet{})
+	tx = Begin()
+	tx.Set(a, &Packet{Item: "a4"})
+	tx.Set(once, &Packet{})
+	if err := tx.Commit(); err != ErrTxRefused || a.Peek().Item != "a2" {
+		t.Fatalf("a refused write should abort the Tx, got %v", err)
+	}
+}
+
+func TestTxVetsOnce(t *testing.T) {
+
+	a, b := NewLatch(1), NewLatch(1)
+	calls := 0
+	b.AddVeto(func(pak *Packet) error {
+		// a fickle hook: yes, then no.
+		calls++
+		if calls > 1 {
+			return errors.New("changed my mind")
+		}
+		return nil
+	})
+	tx := Begin()
+	tx.Set(a, &Packet{Item: "a"})
+	tx.Set(b, &Packet{Item: "b"})
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("the veto hook ran %v times", calls)
+	}
+	if !a.IsClosed() || !b.IsClosed() {
+		t.Fatal("Commit should apply all of the Tx, or none")
+	}
+}
@@ -0,0 +1,99 @@
+package latch
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ValuePacket is the value-type, typed, analog of
+// Packet, as held by a ValueLatch.
+type ValuePacket[T any] struct {
+	Item T
+	Err  error
+}
+
+// ValueLatch is a latch for readers that want the
+// cheapest possible reads of a closed latch. The current
+// value is held behind an atomic pointer, so Load and the
+// fast path of Wait perform no allocations, no locking,
+// and no channel operations; there is nothing to
+// Refresh. Only Bcast allocates.
+//
+// The trade-off is that ValueLatch has no Ch() to
+// select on; use Wait, or Observe for select-friendly
+// notification of transitions.
+type ValueLatch[T any] struct {
+	cur atomic.Pointer[ValuePacket[T]] // nil when open.
+
+	mu      sync.Mutex
+	version uint64
+	chg     chan struct{}
+}
+
+// NewValueLatch makes a new, open, ValueLatch.
+func NewValueLatch[T any]() *ValueLatch[T] {
+	return &ValueLatch[T]{
+		chg: make(chan struct{}),
+	}
+}
+
+// Bcast closes the latch with item and err,
+// replacing any previous value.
+func (v *ValueLatch[T]) Bcast(item T, err error) {
+	v.mu.Lock()
+	v.cur.Store(&ValuePacket[T]{Item: item, Err: err})
+	v.transition()
+	v.mu.Unlock()
+}
+
+// Clear opens the latch; subsequent Waits block
+// until the next Bcast.
+func (v *ValueLatch[T]) Clear() {
+	v.mu.Lock()
+	v.cur.Store(nil)
+	v.transition()
+	v.mu.Unlock()
+}
+
+// transition wakes Wait-ers. Caller must hold v.mu.
+func (v *ValueLatch[T]) transition() {
+	v.version++
+	close(v.chg)
+	v.chg = make(chan struct{})
+}
+
+// Load returns a copy of the current value, and true;
+// or the zero ValuePacket and false if the latch is open.
+func (v *ValueLatch[T]) Load() (ValuePacket[T], bool) {
+	if p := v.cur.Load(); p != nil {
+		return *p, true
+	}
+	return ValuePacket[T]{}, false
+}
+
+// Wait blocks until the latch is closed, and returns
+// its value. If cancel is closed first, Wait returns
+// the zero ValuePacket and false. A nil cancel
+// never fires.
+func (v *ValueLatch[T]) Wait(cancel <-chan struct{}) (ValuePacket[T], bool) {
+	for {
+		if p := v.cur.Load(); p != nil {
+			return *p, true
+		}
+		_, _, next := v.Observe()
+		select {
+		case <-next:
+		case <-cancel:
+			return ValuePacket[T]{}, false
+		}
+	}
+}
+
+// Observe returns, atomically: the current value (nil
+// if open), the count of transitions so far, and a
+// channel that will be closed at the next transition.
+func (v *ValueLatch[T]) Observe() (cur *ValuePacket[T], version uint64, next <-chan struct{}) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.cur.Load(), v.version, v.chg
+}
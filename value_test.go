@@ -0,0 +1,69 @@
+package latch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValueLatch(t *testing.T) {
+
+	v := NewValueLatch[int]()
+	if _, ok := v.Load(); ok {
+		t.Fatal("new ValueLatch should be open")
+	}
+
+	got := make(chan int)
+	go func() {
+		p, _ := v.Wait(nil)
+		got <- p.Item
+	}()
+	v.Bcast(42, nil)
+	select {
+	case n := <-got:
+		if n != 42 {
+			t.Fatalf("Wait returned %v, want 42", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait never woke")
+	}
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		if p, ok := v.Load(); !ok || p.Item != 42 {
+			t.Fatal("Load lost the value")
+		}
+		v.Wait(nil)
+	})
+	if allocs != 0 {
+		t.Fatalf("closed-state reads should not allocate, got %v allocs", allocs)
+	}
+
+	v.Clear()
+	cancel := make(chan struct{})
+	close(cancel)
+	if _, ok := v.Wait(cancel); ok {
+		t.Fatal("Wait on an open latch should honor cancel")
+	}
+}
+
+func BenchmarkValueLatchLoad(b *testing.B) {
+	v := NewValueLatch[int]()
+	v.Bcast(1, nil)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			v.Load()
+		}
+	})
+}
+
+func BenchmarkLatchChRead(b *testing.B) {
+	latch := NewLatch(8)
+	latch.Bcast(&Packet{Item: 1})
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			<-latch.Ch()
+			latch.Refresh()
+		}
+	})
+}
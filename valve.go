@@ -0,0 +1,74 @@
+package latch
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Valve pauses and resumes a pool of workers. Workers
+// call Wait at the top of each loop iteration; while the
+// Valve is shut, they park there. Shut pauses them all,
+// Open lets them all flow again. Parked reports how many
+// are waiting, for observability.
+//
+// The Valve is open exactly when its latch is closed
+// (the electrical sense: the circuit is complete).
+type Valve struct {
+	l      *Latch
+	parked atomic.Int64
+}
+
+// NewValve makes a new Valve, initially open or shut.
+func NewValve(open bool) *Valve {
+	v := &Valve{l: NewLatch(1)}
+	if open {
+		v.Open()
+	}
+	return v
+}
+
+// Latch returns the Valve's latch.
+func (v *Valve) Latch() *Latch {
+	return v.l
+}
+
+// Open lets workers through.
+func (v *Valve) Open() {
+	v.l.Bcast(&Packet{})
+}
+
+// Shut pauses workers at their next Wait.
+func (v *Valve) Shut() {
+	v.l.Clear()
+}
+
+// IsOpen reports whether workers may pass.
+func (v *Valve) IsOpen() bool {
+	return v.l.IsClosed()
+}
+
+// Wait returns immediately if the Valve is open;
+// otherwise it parks until the Valve opens, or
+// ctx is done.
+func (v *Valve) Wait(ctx context.Context) error {
+	done := v.l.Done()
+	select {
+	case <-done:
+		return nil
+	default:
+	}
+	v.parked.Add(1)
+	defer v.parked.Add(-1)
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Parked returns the number of workers
+// currently waiting in Wait.
+func (v *Valve) Parked() int {
+	return int(v.parked.Load())
+}
@@ -0,0 +1,38 @@
+package latch
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValve(t *testing.T) {
+
+	v := NewValve(false)
+	ctx := context.Background()
+
+	const workers = 3
+	passed := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			passed <- v.Wait(ctx)
+		}()
+	}
+	waitFor(t, func() bool { return v.Parked() == workers })
+
+	v.Open()
+	for i := 0; i < workers; i++ {
+		if err := <-passed; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v.Parked() != 0 {
+		t.Fatal("no workers should remain parked")
+	}
+
+	v.Shut()
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := v.Wait(cctx); err != context.Canceled {
+		t.Fatalf("Wait on a shut valve should honor ctx, got %v", err)
+	}
+}
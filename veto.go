@@ -0,0 +1,55 @@
+package latch
+
+import "fmt"
+
+// vetoHook is one AddVeto hook.
+type vetoHook struct {
+	fn func(pak *Packet) error
+}
+
+// AddVeto guards the latch with fn, which sees every
+// close (or new value) before it happens, and can cancel
+// it by returning an error: "terminate cluster" latches
+// can sit behind confirmation logic. A vetoed Bcast
+// leaves the latch alone; TryBcast reports false, and
+// BcastErr returns the error, wrapped in ErrVetoed.
+// Clear is never vetoed.
+//
+// Hooks run in the order added, under the latch's lock,
+// so they must not call back into the latch. Calling
+// remove removes the hook.
+func (r *Latch) AddVeto(fn func(pak *Packet) error) (remove func()) {
+	h := &vetoHook{fn: fn}
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.vetoes = append(r.vetoes, h)
+	return func() {
+		r.mut.Lock()
+		defer r.mut.Unlock()
+		for i, v := range r.vetoes {
+			if v == h {
+				r.vetoes = append(r.vetoes[:i:i], r.vetoes[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// DryRun asks the veto hooks about closing the latch
+// with pak, without closing it: nil if they would allow
+// it, or the first veto, wrapped in ErrVetoed.
+func (r *Latch) DryRun(pak *Packet) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.vet(pak)
+}
+
+// vet runs the veto hooks on pak. Caller must hold r.mut.
+func (r *Latch) vet(pak *Packet) error {
+	for _, h := range r.vetoes {
+		if err := h.fn(pak); err != nil {
+			return fmt.Errorf("%w: %w", ErrVetoed, err)
+		}
+	}
+	return nil
+}
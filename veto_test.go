@@ -0,0 +1,50 @@
+package latch
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVeto(t *testing.T) {
+
+	l := NewLatch(1)
+	unconfirmed := errors.New("not confirmed")
+	confirmed := false
+	remove := l.AddVeto(func(pak *Packet) error {
+		if !confirmed {
+			return unconfirmed
+		}
+		return nil
+	})
+
+	pak := &Packet{Item: "terminate cluster"}
+	if err := l.DryRun(pak); !errors.Is(err, ErrVetoed) || !errors.Is(err, unconfirmed) {
+		t.Fatalf("DryRun got %v", err)
+	}
+	if err := l.BcastErr(pak); !errors.Is(err, unconfirmed) {
+		t.Fatalf("BcastErr got %v", err)
+	}
+	l.Bcast(pak)
+	if l.IsClosed() {
+		t.Fatal("a vetoed close should leave the latch open")
+	}
+	tx := Begin()
+	tx.Set(l, pak)
+	if err := tx.Commit(); err != ErrTxRefused {
+		t.Fatalf("a vetoed Tx got %v", err)
+	}
+
+	confirmed = true
+	if err := l.DryRun(pak); err != nil || l.IsClosed() {
+		t.Fatalf("DryRun shouldn't close: %v", err)
+	}
+	if err := l.BcastErr(pak); err != nil || !l.IsClosed() {
+		t.Fatalf("a confirmed close got %v", err)
+	}
+
+	confirmed = false
+	remove()
+	if err := l.BcastErr(pak); err != nil {
+		t.Fatalf("a removed hook still vetoed: %v", err)
+	}
+}
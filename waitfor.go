@@ -0,0 +1,33 @@
+package latch
+
+import (
+	"context"
+	"time"
+)
+
+// WaitFor blocks until the latch is closed with a value
+// satisfying pred, and returns that value. pred is
+// re-evaluated on every transition, so this supports
+// conditions like "wait until config version >= N".
+// If ctx is done first, WaitFor returns ctx.Err().
+//
+// WaitFor doesn't consume anything from Ch(), and
+// doesn't need Refresh.
+func (r *Latch) WaitFor(ctx context.Context, pred func(*Packet) bool) (*Packet, error) {
+	var start time.Time
+	defer func() { r.waited(start) }()
+	for {
+		cur, _, next := r.Observe()
+		if cur != nil && pred(cur) {
+			return cur, nil
+		}
+		if start.IsZero() {
+			start = r.waitStart()
+		}
+		select {
+		case <-next:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
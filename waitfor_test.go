@@ -0,0 +1,38 @@
+package latch
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitFor(t *testing.T) {
+
+	latch := NewLatch(1)
+	atLeast3 := func(p *Packet) bool { return p.Item.(int) >= 3 }
+
+	got := make(chan *Packet)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		p, err := latch.WaitFor(ctx, atLeast3)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- p
+	}()
+
+	for v := 1; v <= 4; v++ {
+		latch.Bcast(&Packet{Item: v})
+	}
+	if p := <-got; p.Item.(int) < 3 {
+		t.Fatalf("WaitFor returned a value failing the predicate: %v", p.Item)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	never := func(*Packet) bool { return false }
+	if _, err := latch.WaitFor(ctx, never); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+}
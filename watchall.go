@@ -0,0 +1,210 @@
+package latch
+
+import (
+	"context"
+	"sort"
+)
+
+// Change is the kind of a RegistryEvent.
+type Change int
+
+const (
+	// Closed: the latch was closed, or given a new value.
+	Closed Change = iota
+	// Opened: the latch was opened (cleared).
+	Opened
+	// Unregistered: the name was removed from the
+	// Registry.
+	Unregistered
+)
+
+func (c Change) String() string {
+	switch c {
+	case Closed:
+		return "closed"
+	case Opened:
+		return "opened"
+	case Unregistered:
+		return "unregistered"
+	}
+	return "Change(?)"
+}
+
+// RegistryEvent reports a change to a registered latch.
+type RegistryEvent struct {
+	Name    string
+	Change  Change
+	Packet  *Packet // the new value, when Closed.
+	Version uint64  // the latch's Version.
+}
+
+// WatchAll streams a RegistryEvent for every change to
+// the Registry's latches, until ctx is done, when the
+// channel is closed. It starts with the state of every
+// latch registered, in name order; a latch registered
+// later is reported in the same way when it appears,
+// and a name registered anew reports Unregistered for
+// the latch it replaces first.
+//
+// Every transition is reported, in the order they
+// happened, even those CloseSeq applies in one go:
+// events are queued for the stream as the latches
+// change, so a reader that falls behind makes the queue
+// grow, rather than missing any.
+func (g *Registry) WatchAll(ctx context.Context) <-chan RegistryEvent {
+	w := &allWatcher{
+		primed: make(map[string]bool),
+		more:   make(chan struct{}, 1),
+	}
+	e := g.epoch
+
+	// holding g.mu keeps membership still while we
+	// prime w with the state of each latch.
+	g.mu.Lock()
+	e.mu.Lock()
+	if e.watchers == nil {
+		e.watchers = make(map[*allWatcher]struct{})
+	}
+	e.watchers[w] = struct{}{}
+	e.mu.Unlock()
+	names := make([]string, 0, len(g.m))
+	for name := range g.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.m[name].prime(e, w, name)
+	}
+	g.mu.Unlock()
+
+	ch := make(chan RegistryEvent)
+	goLabeled(RoleWatcher, "", func() {
+		defer close(ch)
+		defer e.unwatch(w)
+		for {
+			evs := e.take(w)
+			for _, ev := range evs {
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if len(evs) > 0 {
+				continue
+			}
+			select {
+			case <-w.more:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return ch
+}
+
+// allWatcher is the queue behind one WatchAll stream.
+// Its fields are guarded by the epoch's mu.
+type allWatcher struct {
+	// primed are the names w has been given the state
+	// of; it hears transitions only for those.
+	primed map[string]bool
+	q      []RegistryEvent
+	more   chan struct{} // signalled when q grows.
+}
+
+// push queues ev for w. Caller must hold e.mu.
+func (w *allWatcher) push(ev RegistryEvent) {
+	w.q = append(w.q, ev)
+	select {
+	case w.more <- struct{}{}:
+	default:
+	}
+}
+
+// event describes r's state as name.
+// Caller must hold r.mut.
+func (r *Latch) event(name string) RegistryEvent {
+	ev := RegistryEvent{Name: name, Change: Opened, Version: r.version}
+	if r.avail {
+		ev.Change, ev.Packet = Closed, r.copyOf(r.cur)
+	}
+	return ev
+}
+
+// prime gives w the state of r, registered as name.
+func (r *Latch) prime(e *epoch, w *allWatcher, name string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	w.primed[name] = true
+	w.push(r.event(name))
+}
+
+// join notes r is registered as name, giving the
+// watchers its state. Caller must hold r.mut.
+func (e *epoch) join(r *Latch, name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.names == nil {
+		e.names = make(map[*Latch][]string)
+	}
+	e.names[r] = append(e.names[r], name)
+	for w := range e.watchers {
+		w.primed[name] = true
+		w.push(r.event(name))
+	}
+}
+
+// leave undoes join. Caller must hold r.mut.
+func (e *epoch) leave(r *Latch, name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	names := e.names[r]
+	for i, n := range names {
+		if n == name {
+			names = append(names[:i:i], names[i+1:]...)
+			break
+		}
+	}
+	if len(names) == 0 {
+		delete(e.names, r)
+	} else {
+		e.names[r] = names
+	}
+	for w := range e.watchers {
+		delete(w.primed, name)
+		w.push(RegistryEvent{Name: name, Change: Unregistered, Version: r.version})
+	}
+}
+
+// note queues r's transition for the watchers.
+// Caller must hold r.mut.
+func (e *epoch) note(r *Latch) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, name := range e.names[r] {
+		for w := range e.watchers {
+			if w.primed[name] {
+				w.push(r.event(name))
+			}
+		}
+	}
+}
+
+// take empties w's queue.
+func (e *epoch) take(w *allWatcher) []RegistryEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	q := w.q
+	w.q = nil
+	return q
+}
+
+// unwatch stops queueing for w.
+func (e *epoch) unwatch(w *allWatcher) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.watchers, w)
+}
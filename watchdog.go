@@ -0,0 +1,86 @@
+package latch
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrWatchdogTimeout is the Err in the Packet broadcast
+// by a Watchdog that was not petted in time.
+var ErrWatchdogTimeout = errors.New("latch: watchdog timeout")
+
+// Watchdog is a dead-man switch. Callers must Pet()
+// it at least once every interval. If they fail to,
+// the Watchdog closes itself with a Packet whose Err
+// is ErrWatchdogTimeout, and every reader of Ch()
+// sees the timeout.
+type Watchdog struct {
+	*Latch
+
+	mu       sync.Mutex
+	interval time.Duration
+	deadline time.Time
+	timer    Timer
+	clk      Clock
+	fired    bool
+	disarmed bool
+}
+
+// NewWatchdog makes a new Watchdog whose latch has a
+// backing channel of size sz. The first interval
+// starts now. opts are as for NewLatch.
+func NewWatchdog(sz int, interval time.Duration, opts ...Option) *Watchdog {
+	l := NewLatch(sz, opts...)
+	w := &Watchdog{
+		Latch:    l,
+		interval: interval,
+		clk:      l.clock(),
+	}
+	w.deadline = w.clk.Now().Add(interval)
+	w.timer = w.clk.AfterFunc(interval, w.expire)
+	return w
+}
+
+// Pet pushes the deadline out by another interval.
+// It returns false if the Watchdog has already
+// fired or been disarmed, in which case petting
+// has no effect.
+func (w *Watchdog) Pet() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fired || w.disarmed {
+		return false
+	}
+	w.deadline = w.clk.Now().Add(w.interval)
+	return true
+}
+
+// Disarm stops the Watchdog without closing
+// its latch.
+func (w *Watchdog) Disarm() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.disarmed = true
+	w.timer.Stop()
+}
+
+// expire runs on the timer goroutine. Pet only
+// moves the deadline, so here we check whether
+// we were petted in the meantime and re-arm if so.
+func (w *Watchdog) expire() {
+	w.mu.Lock()
+	if w.disarmed {
+		w.mu.Unlock()
+		return
+	}
+	if left := w.deadline.Sub(w.clk.Now()); left > 0 {
+		w.timer.Reset(left)
+		w.mu.Unlock()
+		return
+	}
+	w.fired = true
+	w.mu.Unlock()
+
+	w.Bcast(&Packet{Err: ErrWatchdogTimeout})
+}
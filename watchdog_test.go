@@ -0,0 +1,47 @@
+package latch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+
+	w := NewWatchdog(1, 30*time.Millisecond)
+
+	// keep it alive for a while.
+	for i := 0; i < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if !w.Pet() {
+			t.Fatal("Pet should succeed before the watchdog fires")
+		}
+	}
+	select {
+	case <-w.Ch():
+		t.Fatal("petted watchdog should not have fired")
+	default:
+	}
+
+	// stop petting; it should fire.
+	select {
+	case b := <-w.Ch():
+		if b.Err != ErrWatchdogTimeout {
+			t.Fatalf("expected ErrWatchdogTimeout, got %v", b.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchdog never fired")
+	}
+	if w.Pet() {
+		t.Fatal("Pet after firing should report false")
+	}
+
+	// a disarmed watchdog never fires.
+	w = NewWatchdog(1, 10*time.Millisecond)
+	w.Disarm()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-w.Ch():
+		t.Fatal("disarmed watchdog should not fire")
+	default:
+	}
+}
@@ -0,0 +1,12 @@
+package latch
+
+// WithWriteOnce makes the first Bcast on a latch stick:
+// later Bcast and Clear calls are ignored (TryBcast and
+// TryClear report false). Shutdown reasons are often
+// "first error wins"; this saves guarding every
+// Bcast with a sync.Once. Reset re-arms the latch.
+func WithWriteOnce() Option {
+	return func(r *Latch) {
+		r.writeOnce = true
+	}
+}
@@ -0,0 +1,96 @@
+package latch
+
+// WritePolicy decides between concurrent writers closing
+// an already closed latch; see WithWritePolicy.
+type WritePolicy int
+
+const (
+	// LastWriteWins: every Bcast replaces the current
+	// value. This is the default.
+	LastWriteWins WritePolicy = iota
+
+	// HighestPriorityWins: a Bcast replaces the current
+	// value only if its Packet.Priority is at least as
+	// high; a shutdown for a fatal error, say, is not
+	// overwritten by a routine one.
+	HighestPriorityWins
+
+	// RejectIfClosed: a Bcast on a closed latch is
+	// refused; the first close stands until Clear.
+	RejectIfClosed
+)
+
+func (p WritePolicy) String() string {
+	switch p {
+	case LastWriteWins:
+		return "LastWriteWins"
+	case HighestPriorityWins:
+		return "HighestPriorityWins"
+	case RejectIfClosed:
+		return "RejectIfClosed"
+	}
+	return "WritePolicy(?)"
+}
+
+// WithWritePolicy applies p to every Bcast. A refused
+// Bcast leaves the latch alone (TryBcast reports false),
+// but every decision, accepted or not, is recorded for
+// ObserveDecisions, so an audit can see who lost.
+func WithWritePolicy(p WritePolicy) Option {
+	return func(r *Latch) {
+		r.policy = &writePolicy{
+			policy: p,
+			chg:    make(chan struct{}),
+		}
+	}
+}
+
+// WriteDecision records how the write policy dealt with
+// one Bcast.
+type WriteDecision struct {
+	Policy   WritePolicy
+	Offered  *Packet // what the Bcast offered.
+	Current  *Packet // the value before; nil if open.
+	Accepted bool
+}
+
+type writePolicy struct {
+	policy WritePolicy
+	last   WriteDecision
+	seq    uint64
+	chg    chan struct{}
+}
+
+// decide applies the policy to pak, recording the
+// decision. Caller must hold r.mut.
+func (w *writePolicy) decide(r *Latch, pak *Packet) bool {
+	d := WriteDecision{Policy: w.policy, Offered: pak, Accepted: true}
+	if r.avail {
+		d.Current = r.cur
+		switch w.policy {
+		case HighestPriorityWins:
+			d.Accepted = priorityOf(pak) >= priorityOf(r.cur)
+		case RejectIfClosed:
+			d.Accepted = false
+		}
+	}
+	w.last = d
+	w.seq++
+	close(w.chg)
+	w.chg = make(chan struct{})
+	return d.Accepted
+}
+
+// ObserveDecisions returns the latest write decision,
+// its sequence number (0 before any Bcast), and a
+// channel that is closed at the next decision: the
+// Observe of write decisions. The latch must have
+// WithWritePolicy; without it, next is nil.
+func (r *Latch) ObserveDecisions() (d WriteDecision, seq uint64, next <-chan struct{}) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.policy == nil {
+		return WriteDecision{}, 0, nil
+	}
+	return r.policy.last, r.policy.seq, r.policy.chg
+}
@@ -0,0 +1,37 @@
+package latch
+
+import "testing"
+
+func TestWritePolicy(t *testing.T) {
+
+	l := NewLatch(1, WithWritePolicy(HighestPriorityWins))
+	_, seq, next := l.ObserveDecisions()
+	if seq != 0 {
+		t.Fatal("no decisions yet")
+	}
+	l.Bcast(&Packet{Item: "fatal", Priority: 10})
+	<-next
+	if l.TryBcast(&Packet{Item: "routine", Priority: 1}) {
+		t.Fatal("a lower priority write should lose")
+	}
+	d, seq, _ := l.ObserveDecisions()
+	if d.Accepted || seq != 2 || d.Offered.Item != "routine" || d.Current.Item != "fatal" {
+		t.Fatalf("decision not recorded: %+v, seq %d", d, seq)
+	}
+	if pak := l.Peek(); pak.Item != "fatal" {
+		t.Fatalf("got %v", pak.Item)
+	}
+	if !l.TryBcast(&Packet{Item: "worse", Priority: 11}) {
+		t.Fatal("a higher priority write should win")
+	}
+
+	l = NewLatch(1, WithWritePolicy(RejectIfClosed))
+	l.Bcast(&Packet{Item: 1})
+	if l.TryBcast(&Packet{Item: 2}) {
+		t.Fatal("RejectIfClosed should refuse a second close")
+	}
+	l.Clear()
+	if !l.TryBcast(&Packet{Item: 3}) {
+		t.Fatal("after Clear, a close should be accepted")
+	}
+}
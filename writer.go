@@ -0,0 +1,56 @@
+package latch
+
+// Writer is the write side of a Latch, as ROLatch is the
+// read side. A component that owns a latch keeps its
+// Writer, and hands out ROLatches; reviewers can then see
+// exactly which code controls the latch by following the
+// Writer.
+type Writer struct {
+	l *Latch
+}
+
+// WithSingleWriter lets Writer be called only once, so
+// the one Writer it returns is the latch's only write
+// handle; a second call panics with ErrWriterIssued. Code holding the *Latch
+// itself can still write to it, so hand out ROLatches.
+func WithSingleWriter() Option {
+	return func(r *Latch) {
+		r.singleWriter = true
+	}
+}
+
+// Writer returns a write handle on r. See
+// WithSingleWriter.
+func (r *Latch) Writer() *Writer {
+	w, err := r.TryWriter()
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// Bcast is the Latch Bcast.
+func (w *Writer) Bcast(pak *Packet) {
+	w.l.Bcast(pak)
+}
+
+// TryBcast is the Latch TryBcast.
+func (w *Writer) TryBcast(pak *Packet) bool {
+	return w.l.TryBcast(pak)
+}
+
+// Clear is the Latch Clear.
+func (w *Writer) Clear() {
+	w.l.Clear()
+}
+
+// TryClear is the Latch TryClear.
+func (w *Writer) TryClear() bool {
+	return w.l.TryClear()
+}
+
+// ReadOnly returns a read-only view of
+// the Writer's latch, to hand out.
+func (w *Writer) ReadOnly() *ROLatch {
+	return w.l.ReadOnly()
+}
@@ -0,0 +1,25 @@
+package latch
+
+import "testing"
+
+func TestWriter(t *testing.T) {
+
+	l := NewLatch(1, WithSingleWriter())
+	w := l.Writer()
+	ro := w.ReadOnly()
+
+	w.Bcast(&Packet{Item: "on"})
+	if ro.Peek().Item != "on" {
+		t.Fatal("the Writer should write the latch")
+	}
+	if !w.TryClear() || ro.IsClosed() {
+		t.Fatal("the Writer should clear the latch")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("a second Writer should panic")
+		}
+	}()
+	l.Writer()
+}